@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"github.com/mrcrowl/swarm/bundle"
+	"github.com/mrcrowl/swarm/config"
+	"github.com/mrcrowl/swarm/source"
+	"github.com/mrcrowl/swarm/ui"
+	"github.com/mrcrowl/swarm/util"
+)
+
+// runAnalyzeDuplicates lists the files that are bundled by more than one module, failing if there are any
+func runAnalyzeDuplicates(cmd *command, args []string) int {
+	if len(args) != 0 {
+		cmd.printUsage()
+		return 2
+	}
+
+	_, _, _, moduleSet := loadBuild(buildArgs())
+	duplicates := moduleSet.Duplicates()
+	if len(duplicates) == 0 {
+		fmt.Println("No files are bundled by more than one module")
+		return 0
+	}
+	printDuplicates(duplicates)
+	return 1
+}
+
+// printDuplicates lists each duplicated file with its cost, and the chain of imports that pulled it into each module
+func printDuplicates(duplicates []*bundle.Duplicate) {
+	totalCost := 0
+	for _, duplicate := range duplicates {
+		totalCost += duplicate.Cost()
+		fmt.Printf("%s (%s, bundled %d times)\n", duplicate.ID, util.FormatByteSize(duplicate.Size), len(duplicate.Chains))
+		for _, chain := range duplicate.Chains {
+			fmt.Printf("   %s: %s\n", chain.Module, chain)
+		}
+	}
+	fmt.Printf("%d files are bundled by more than one module, costing %s\n", len(duplicates), util.FormatByteSize(totalCost))
+}
+
+// runAnalyzeSize builds the modules, then breaks down the size of each bundle by directory and file, failing if a
+// module exceeds its size budget
+func runAnalyzeSize(cmd *command, args []string) int {
+	if len(args) != 0 {
+		cmd.printUsage()
+		return 2
+	}
+
+	_, _, _, moduleSet := loadBuild(buildArgs())
+	if *moduleFlag != "" && findModule(moduleSet, *moduleFlag) == nil {
+		return 2
+	}
+	moduleSet.NotifyChanges(nil)
+
+	exitCode := 0
+	for _, size := range moduleSet.Sizes() {
+		if *moduleFlag != "" && size.Module != *moduleFlag {
+			continue
+		}
+		if printBundleSize(size) {
+			exitCode = 1
+		}
+	}
+	return exitCode
+}
+
+// printBundleSize lists the size of a bundle and its budget, then the size of each directory and file in it,
+// returning whether the bundle is over budget
+func printBundleSize(size *bundle.BundleSize) bool {
+	fmt.Printf("\n%s: %s (%s, %s gzipped)\n", size.Module, size.URL, util.FormatByteSize(size.Raw), util.FormatByteSize(size.Gzip))
+	if size.Budget != nil {
+		var limits []string
+		if size.Budget.Raw > 0 {
+			limits = append(limits, util.FormatByteSize(int(size.Budget.Raw)))
+		}
+		if size.Budget.Gzip > 0 {
+			limits = append(limits, util.FormatByteSize(int(size.Budget.Gzip))+" gzipped")
+		}
+		if len(limits) > 0 {
+			fmt.Printf("   Budget: %s\n", strings.Join(limits, ", "))
+		}
+	}
+	overBudget := size.OverBudget()
+	for _, exceeded := range overBudget {
+		fmt.Printf("   OVER BUDGET: %s\n", exceeded)
+	}
+	for _, dir := range size.Directories() {
+		fmt.Printf("   %-10s %s/ (%d files)\n", util.FormatByteSize(dir.Size), dir.ID, len(dir.Files))
+		for _, file := range dir.Files {
+			fmt.Printf("      %-10s %s\n", util.FormatByteSize(file.Size), file.ID)
+		}
+	}
+	return len(overBudget) > 0
+}
+
+// runAnalyzeUnused lists the source files in the workspace that no module of any build (or just the build named by
+// --build) uses, failing if there are any
+func runAnalyzeUnused(cmd *command, args []string) int {
+	if len(args) != 0 {
+		cmd.printUsage()
+		return 2
+	}
+
+	swarmConfig := loadSwarmConfig()
+	ws := source.NewWorkspace(swarmConfig.RootPath)
+	var moduleSets bundle.ModuleSets
+	if *buildFlag != "" {
+		moduleSets = append(moduleSets, loadModuleSet(ws, ui.ChooseBuild(swarmConfig.Builds, buildArgs())))
+	} else {
+		for _, runtimeConfig := range ui.ChooseBuilds(swarmConfig.Builds, sortedBuildNames(swarmConfig.Builds)) {
+			moduleSets = append(moduleSets, loadModuleSet(ws, runtimeConfig))
+		}
+	}
+
+	unused, err := moduleSets.UnusedFiles(ws.RootPath(), *ignoreFlag)
+	util.ExitIfError(err, "Failed to list the files in the workspace: %s", err)
+	if len(unused) == 0 {
+		fmt.Println("All files are used by a module")
+		return 0
+	}
+	totalSize := 0
+	for _, file := range unused {
+		totalSize += file.Size
+		fmt.Printf("%s (%s)\n", file.ID, util.FormatByteSize(file.Size))
+	}
+	fmt.Printf("%d files aren't used by any module, totalling %s\n", len(unused), util.FormatByteSize(totalSize))
+	return 1
+}
+
+func sortedBuildNames(builds map[string]*config.RuntimeConfig) []string {
+	names := make([]string, 0, len(builds))
+	for name := range builds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
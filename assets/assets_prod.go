@@ -139,7 +139,1144 @@ var FS *FileSystem
 func init() {
 	FS = &FileSystem{
 		files: map[string]File{
-			"/assets/static/HotReload.js": File{
+			"/assets/static/Analyze.html": File{
+				data: []byte{
+					0x3c, 0x21, 0x44, 0x4f, 0x43, 0x54, 0x59, 0x50, 0x45, 0x20, 0x68, 0x74,
+					0x6d, 0x6c, 0x3e, 0x0d, 0x0a, 0x3c, 0x68, 0x74, 0x6d, 0x6c, 0x3e, 0x0d,
+					0x0a, 0x3c, 0x68, 0x65, 0x61, 0x64, 0x3e, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x3c, 0x6d, 0x65, 0x74, 0x61, 0x20, 0x63, 0x68, 0x61, 0x72, 0x73,
+					0x65, 0x74, 0x3d, 0x22, 0x75, 0x74, 0x66, 0x2d, 0x38, 0x22, 0x3e, 0x0d,
+					0x0a, 0x20, 0x20, 0x20, 0x20, 0x3c, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x3e,
+					0x73, 0x77, 0x61, 0x72, 0x6d, 0x3a, 0x20, 0x62, 0x75, 0x6e, 0x64, 0x6c,
+					0x65, 0x20, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x73, 0x69, 0x73, 0x3c, 0x2f,
+					0x74, 0x69, 0x74, 0x6c, 0x65, 0x3e, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x3c, 0x73, 0x74, 0x79, 0x6c, 0x65, 0x3e, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x68, 0x74, 0x6d, 0x6c, 0x2c, 0x20, 0x62,
+					0x6f, 0x64, 0x79, 0x20, 0x7b, 0x20, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74,
+					0x3a, 0x20, 0x31, 0x30, 0x30, 0x25, 0x3b, 0x20, 0x6d, 0x61, 0x72, 0x67,
+					0x69, 0x6e, 0x3a, 0x20, 0x30, 0x3b, 0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x62, 0x6f, 0x64, 0x79, 0x20, 0x7b,
+					0x20, 0x64, 0x69, 0x73, 0x70, 0x6c, 0x61, 0x79, 0x3a, 0x20, 0x66, 0x6c,
+					0x65, 0x78, 0x3b, 0x20, 0x66, 0x6c, 0x65, 0x78, 0x2d, 0x64, 0x69, 0x72,
+					0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x3a, 0x20, 0x63, 0x6f, 0x6c, 0x75,
+					0x6d, 0x6e, 0x3b, 0x20, 0x66, 0x6f, 0x6e, 0x74, 0x3a, 0x20, 0x31, 0x32,
+					0x70, 0x78, 0x2f, 0x31, 0x2e, 0x34, 0x20, 0x2d, 0x61, 0x70, 0x70, 0x6c,
+					0x65, 0x2d, 0x73, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x2c, 0x20, 0x22, 0x53,
+					0x65, 0x67, 0x6f, 0x65, 0x20, 0x55, 0x49, 0x22, 0x2c, 0x20, 0x48, 0x65,
+					0x6c, 0x76, 0x65, 0x74, 0x69, 0x63, 0x61, 0x2c, 0x20, 0x41, 0x72, 0x69,
+					0x61, 0x6c, 0x2c, 0x20, 0x73, 0x61, 0x6e, 0x73, 0x2d, 0x73, 0x65, 0x72,
+					0x69, 0x66, 0x3b, 0x20, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x3a, 0x20, 0x23,
+					0x32, 0x32, 0x32, 0x3b, 0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x20, 0x7b,
+					0x20, 0x64, 0x69, 0x73, 0x70, 0x6c, 0x61, 0x79, 0x3a, 0x20, 0x66, 0x6c,
+					0x65, 0x78, 0x3b, 0x20, 0x61, 0x6c, 0x69, 0x67, 0x6e, 0x2d, 0x69, 0x74,
+					0x65, 0x6d, 0x73, 0x3a, 0x20, 0x63, 0x65, 0x6e, 0x74, 0x65, 0x72, 0x3b,
+					0x20, 0x67, 0x61, 0x70, 0x3a, 0x20, 0x31, 0x32, 0x70, 0x78, 0x3b, 0x20,
+					0x70, 0x61, 0x64, 0x64, 0x69, 0x6e, 0x67, 0x3a, 0x20, 0x38, 0x70, 0x78,
+					0x20, 0x31, 0x32, 0x70, 0x78, 0x3b, 0x20, 0x62, 0x61, 0x63, 0x6b, 0x67,
+					0x72, 0x6f, 0x75, 0x6e, 0x64, 0x3a, 0x20, 0x23, 0x32, 0x33, 0x37, 0x61,
+					0x62, 0x65, 0x3b, 0x20, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x3a, 0x20, 0x23,
+					0x66, 0x66, 0x66, 0x3b, 0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x20, 0x68,
+					0x31, 0x20, 0x7b, 0x20, 0x6d, 0x61, 0x72, 0x67, 0x69, 0x6e, 0x3a, 0x20,
+					0x30, 0x3b, 0x20, 0x66, 0x6f, 0x6e, 0x74, 0x2d, 0x73, 0x69, 0x7a, 0x65,
+					0x3a, 0x20, 0x31, 0x36, 0x70, 0x78, 0x3b, 0x20, 0x66, 0x6f, 0x6e, 0x74,
+					0x2d, 0x77, 0x65, 0x69, 0x67, 0x68, 0x74, 0x3a, 0x20, 0x36, 0x30, 0x30,
+					0x3b, 0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x20, 0x69, 0x6e, 0x70, 0x75,
+					0x74, 0x20, 0x7b, 0x20, 0x77, 0x69, 0x64, 0x74, 0x68, 0x3a, 0x20, 0x32,
+					0x38, 0x30, 0x70, 0x78, 0x3b, 0x20, 0x70, 0x61, 0x64, 0x64, 0x69, 0x6e,
+					0x67, 0x3a, 0x20, 0x34, 0x70, 0x78, 0x20, 0x36, 0x70, 0x78, 0x3b, 0x20,
+					0x62, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x3a, 0x20, 0x30, 0x3b, 0x20, 0x62,
+					0x6f, 0x72, 0x64, 0x65, 0x72, 0x2d, 0x72, 0x61, 0x64, 0x69, 0x75, 0x73,
+					0x3a, 0x20, 0x33, 0x70, 0x78, 0x3b, 0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x23, 0x62, 0x72, 0x65, 0x61, 0x64,
+					0x63, 0x72, 0x75, 0x6d, 0x62, 0x73, 0x20, 0x7b, 0x20, 0x70, 0x61, 0x64,
+					0x64, 0x69, 0x6e, 0x67, 0x3a, 0x20, 0x36, 0x70, 0x78, 0x20, 0x31, 0x32,
+					0x70, 0x78, 0x3b, 0x20, 0x62, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x2d, 0x62,
+					0x6f, 0x74, 0x74, 0x6f, 0x6d, 0x3a, 0x20, 0x31, 0x70, 0x78, 0x20, 0x73,
+					0x6f, 0x6c, 0x69, 0x64, 0x20, 0x23, 0x64, 0x64, 0x64, 0x3b, 0x20, 0x7d,
+					0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x23, 0x62,
+					0x72, 0x65, 0x61, 0x64, 0x63, 0x72, 0x75, 0x6d, 0x62, 0x73, 0x20, 0x61,
+					0x20, 0x7b, 0x20, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x3a, 0x20, 0x23, 0x32,
+					0x33, 0x37, 0x61, 0x62, 0x65, 0x3b, 0x20, 0x63, 0x75, 0x72, 0x73, 0x6f,
+					0x72, 0x3a, 0x20, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x3b, 0x20,
+					0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x6d,
+					0x61, 0x69, 0x6e, 0x20, 0x7b, 0x20, 0x66, 0x6c, 0x65, 0x78, 0x3a, 0x20,
+					0x31, 0x3b, 0x20, 0x64, 0x69, 0x73, 0x70, 0x6c, 0x61, 0x79, 0x3a, 0x20,
+					0x66, 0x6c, 0x65, 0x78, 0x3b, 0x20, 0x6d, 0x69, 0x6e, 0x2d, 0x68, 0x65,
+					0x69, 0x67, 0x68, 0x74, 0x3a, 0x20, 0x30, 0x3b, 0x20, 0x7d, 0x0d, 0x0a,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x23, 0x62, 0x75, 0x6e,
+					0x64, 0x6c, 0x65, 0x73, 0x20, 0x7b, 0x20, 0x77, 0x69, 0x64, 0x74, 0x68,
+					0x3a, 0x20, 0x32, 0x36, 0x30, 0x70, 0x78, 0x3b, 0x20, 0x6f, 0x76, 0x65,
+					0x72, 0x66, 0x6c, 0x6f, 0x77, 0x2d, 0x79, 0x3a, 0x20, 0x61, 0x75, 0x74,
+					0x6f, 0x3b, 0x20, 0x62, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x2d, 0x72, 0x69,
+					0x67, 0x68, 0x74, 0x3a, 0x20, 0x31, 0x70, 0x78, 0x20, 0x73, 0x6f, 0x6c,
+					0x69, 0x64, 0x20, 0x23, 0x64, 0x64, 0x64, 0x3b, 0x20, 0x7d, 0x0d, 0x0a,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x23, 0x62, 0x75, 0x6e,
+					0x64, 0x6c, 0x65, 0x73, 0x20, 0x2e, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65,
+					0x20, 0x7b, 0x20, 0x70, 0x61, 0x64, 0x64, 0x69, 0x6e, 0x67, 0x3a, 0x20,
+					0x36, 0x70, 0x78, 0x20, 0x31, 0x32, 0x70, 0x78, 0x3b, 0x20, 0x62, 0x6f,
+					0x72, 0x64, 0x65, 0x72, 0x2d, 0x62, 0x6f, 0x74, 0x74, 0x6f, 0x6d, 0x3a,
+					0x20, 0x31, 0x70, 0x78, 0x20, 0x73, 0x6f, 0x6c, 0x69, 0x64, 0x20, 0x23,
+					0x65, 0x65, 0x65, 0x3b, 0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x23, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x73,
+					0x20, 0x2e, 0x6e, 0x61, 0x6d, 0x65, 0x20, 0x7b, 0x20, 0x66, 0x6f, 0x6e,
+					0x74, 0x2d, 0x77, 0x65, 0x69, 0x67, 0x68, 0x74, 0x3a, 0x20, 0x36, 0x30,
+					0x30, 0x3b, 0x20, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x3a, 0x20, 0x23, 0x32,
+					0x33, 0x37, 0x61, 0x62, 0x65, 0x3b, 0x20, 0x63, 0x75, 0x72, 0x73, 0x6f,
+					0x72, 0x3a, 0x20, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x3b, 0x20,
+					0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x23,
+					0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x73, 0x20, 0x2e, 0x6f, 0x76, 0x65,
+					0x72, 0x2d, 0x62, 0x75, 0x64, 0x67, 0x65, 0x74, 0x20, 0x7b, 0x20, 0x63,
+					0x6f, 0x6c, 0x6f, 0x72, 0x3a, 0x20, 0x23, 0x63, 0x30, 0x33, 0x39, 0x32,
+					0x62, 0x3b, 0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x23, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x73, 0x20, 0x2e,
+					0x65, 0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x73, 0x20, 0x61, 0x20, 0x7b,
+					0x20, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x3a, 0x20, 0x23, 0x32, 0x33, 0x37,
+					0x61, 0x62, 0x65, 0x3b, 0x20, 0x63, 0x75, 0x72, 0x73, 0x6f, 0x72, 0x3a,
+					0x20, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x3b, 0x20, 0x7d, 0x0d,
+					0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x23, 0x74, 0x72,
+					0x65, 0x65, 0x6d, 0x61, 0x70, 0x20, 0x7b, 0x20, 0x66, 0x6c, 0x65, 0x78,
+					0x3a, 0x20, 0x31, 0x3b, 0x20, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f,
+					0x6e, 0x3a, 0x20, 0x72, 0x65, 0x6c, 0x61, 0x74, 0x69, 0x76, 0x65, 0x3b,
+					0x20, 0x6f, 0x76, 0x65, 0x72, 0x66, 0x6c, 0x6f, 0x77, 0x3a, 0x20, 0x68,
+					0x69, 0x64, 0x64, 0x65, 0x6e, 0x3b, 0x20, 0x6d, 0x61, 0x72, 0x67, 0x69,
+					0x6e, 0x3a, 0x20, 0x34, 0x70, 0x78, 0x3b, 0x20, 0x7d, 0x0d, 0x0a, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x2e, 0x62, 0x6f, 0x78, 0x20,
+					0x7b, 0x20, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x3a, 0x20,
+					0x61, 0x62, 0x73, 0x6f, 0x6c, 0x75, 0x74, 0x65, 0x3b, 0x20, 0x62, 0x6f,
+					0x78, 0x2d, 0x73, 0x69, 0x7a, 0x69, 0x6e, 0x67, 0x3a, 0x20, 0x62, 0x6f,
+					0x72, 0x64, 0x65, 0x72, 0x2d, 0x62, 0x6f, 0x78, 0x3b, 0x20, 0x6f, 0x76,
+					0x65, 0x72, 0x66, 0x6c, 0x6f, 0x77, 0x3a, 0x20, 0x68, 0x69, 0x64, 0x64,
+					0x65, 0x6e, 0x3b, 0x20, 0x62, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x3a, 0x20,
+					0x31, 0x70, 0x78, 0x20, 0x73, 0x6f, 0x6c, 0x69, 0x64, 0x20, 0x72, 0x67,
+					0x62, 0x61, 0x28, 0x30, 0x2c, 0x20, 0x30, 0x2c, 0x20, 0x30, 0x2c, 0x20,
+					0x30, 0x2e, 0x32, 0x35, 0x29, 0x3b, 0x20, 0x63, 0x75, 0x72, 0x73, 0x6f,
+					0x72, 0x3a, 0x20, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x3b, 0x20,
+					0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x2e,
+					0x62, 0x6f, 0x78, 0x3a, 0x68, 0x6f, 0x76, 0x65, 0x72, 0x20, 0x7b, 0x20,
+					0x62, 0x6f, 0x72, 0x64, 0x65, 0x72, 0x2d, 0x63, 0x6f, 0x6c, 0x6f, 0x72,
+					0x3a, 0x20, 0x23, 0x30, 0x30, 0x30, 0x3b, 0x20, 0x7d, 0x0d, 0x0a, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x2e, 0x62, 0x6f, 0x78, 0x20,
+					0x2e, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x20, 0x7b, 0x20, 0x70, 0x61, 0x64,
+					0x64, 0x69, 0x6e, 0x67, 0x3a, 0x20, 0x31, 0x70, 0x78, 0x20, 0x34, 0x70,
+					0x78, 0x3b, 0x20, 0x77, 0x68, 0x69, 0x74, 0x65, 0x2d, 0x73, 0x70, 0x61,
+					0x63, 0x65, 0x3a, 0x20, 0x6e, 0x6f, 0x77, 0x72, 0x61, 0x70, 0x3b, 0x20,
+					0x6f, 0x76, 0x65, 0x72, 0x66, 0x6c, 0x6f, 0x77, 0x3a, 0x20, 0x68, 0x69,
+					0x64, 0x64, 0x65, 0x6e, 0x3b, 0x20, 0x74, 0x65, 0x78, 0x74, 0x2d, 0x6f,
+					0x76, 0x65, 0x72, 0x66, 0x6c, 0x6f, 0x77, 0x3a, 0x20, 0x65, 0x6c, 0x6c,
+					0x69, 0x70, 0x73, 0x69, 0x73, 0x3b, 0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x2e, 0x62, 0x6f, 0x78, 0x2e, 0x64,
+					0x69, 0x6d, 0x6d, 0x65, 0x64, 0x20, 0x7b, 0x20, 0x6f, 0x70, 0x61, 0x63,
+					0x69, 0x74, 0x79, 0x3a, 0x20, 0x30, 0x2e, 0x32, 0x35, 0x3b, 0x20, 0x7d,
+					0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x2e, 0x62,
+					0x6f, 0x78, 0x2e, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x20, 0x7b, 0x20, 0x6f,
+					0x75, 0x74, 0x6c, 0x69, 0x6e, 0x65, 0x3a, 0x20, 0x32, 0x70, 0x78, 0x20,
+					0x73, 0x6f, 0x6c, 0x69, 0x64, 0x20, 0x23, 0x66, 0x31, 0x63, 0x34, 0x30,
+					0x66, 0x3b, 0x20, 0x6f, 0x75, 0x74, 0x6c, 0x69, 0x6e, 0x65, 0x2d, 0x6f,
+					0x66, 0x66, 0x73, 0x65, 0x74, 0x3a, 0x20, 0x2d, 0x32, 0x70, 0x78, 0x3b,
+					0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x3c, 0x2f, 0x73, 0x74,
+					0x79, 0x6c, 0x65, 0x3e, 0x0d, 0x0a, 0x3c, 0x2f, 0x68, 0x65, 0x61, 0x64,
+					0x3e, 0x0d, 0x0a, 0x3c, 0x62, 0x6f, 0x64, 0x79, 0x3e, 0x0d, 0x0a, 0x20,
+					0x20, 0x20, 0x20, 0x3c, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x3e, 0x0d,
+					0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x3c, 0x68, 0x31,
+					0x3e, 0x73, 0x77, 0x61, 0x72, 0x6d, 0x20, 0x62, 0x75, 0x6e, 0x64, 0x6c,
+					0x65, 0x73, 0x3c, 0x2f, 0x68, 0x31, 0x3e, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x3c, 0x69, 0x6e, 0x70, 0x75, 0x74, 0x20,
+					0x69, 0x64, 0x3d, 0x22, 0x73, 0x65, 0x61, 0x72, 0x63, 0x68, 0x22, 0x20,
+					0x74, 0x79, 0x70, 0x65, 0x3d, 0x22, 0x73, 0x65, 0x61, 0x72, 0x63, 0x68,
+					0x22, 0x20, 0x70, 0x6c, 0x61, 0x63, 0x65, 0x68, 0x6f, 0x6c, 0x64, 0x65,
+					0x72, 0x3d, 0x22, 0x53, 0x65, 0x61, 0x72, 0x63, 0x68, 0x20, 0x66, 0x69,
+					0x6c, 0x65, 0x73, 0x2c, 0x20, 0x65, 0x2e, 0x67, 0x2e, 0x20, 0x6c, 0x6f,
+					0x64, 0x61, 0x73, 0x68, 0x22, 0x3e, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x3c, 0x73, 0x70, 0x61, 0x6e, 0x20, 0x69, 0x64,
+					0x3d, 0x22, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x22, 0x3e, 0x3c, 0x2f,
+					0x73, 0x70, 0x61, 0x6e, 0x3e, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x3c,
+					0x2f, 0x68, 0x65, 0x61, 0x64, 0x65, 0x72, 0x3e, 0x0d, 0x0a, 0x20, 0x20,
+					0x20, 0x20, 0x3c, 0x6e, 0x61, 0x76, 0x20, 0x69, 0x64, 0x3d, 0x22, 0x62,
+					0x72, 0x65, 0x61, 0x64, 0x63, 0x72, 0x75, 0x6d, 0x62, 0x73, 0x22, 0x3e,
+					0x3c, 0x2f, 0x6e, 0x61, 0x76, 0x3e, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x3c, 0x6d, 0x61, 0x69, 0x6e, 0x3e, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x3c, 0x61, 0x73, 0x69, 0x64, 0x65, 0x20, 0x69,
+					0x64, 0x3d, 0x22, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x73, 0x22, 0x3e,
+					0x3c, 0x2f, 0x61, 0x73, 0x69, 0x64, 0x65, 0x3e, 0x0d, 0x0a, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x3c, 0x64, 0x69, 0x76, 0x20, 0x69,
+					0x64, 0x3d, 0x22, 0x74, 0x72, 0x65, 0x65, 0x6d, 0x61, 0x70, 0x22, 0x3e,
+					0x3c, 0x2f, 0x64, 0x69, 0x76, 0x3e, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x3c, 0x2f, 0x6d, 0x61, 0x69, 0x6e, 0x3e, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x3c, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x20, 0x74, 0x79, 0x70,
+					0x65, 0x3d, 0x22, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x65, 0x22, 0x20, 0x73,
+					0x72, 0x63, 0x3d, 0x22, 0x2f, 0x5f, 0x5f, 0x73, 0x77, 0x61, 0x72, 0x6d,
+					0x5f, 0x5f, 0x2f, 0x41, 0x6e, 0x61, 0x6c, 0x79, 0x7a, 0x65, 0x2e, 0x6a,
+					0x73, 0x22, 0x3e, 0x3c, 0x2f, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x3e,
+					0x0d, 0x0a, 0x3c, 0x2f, 0x62, 0x6f, 0x64, 0x79, 0x3e, 0x0d, 0x0a, 0x3c,
+					0x2f, 0x68, 0x74, 0x6d, 0x6c, 0x3e, 0x0d, 0x0a, 
+				},
+				fi: FileInfo{
+					name:    "Analyze.html",
+					size:    2048,
+					modTime: time.Unix(0, 1792431609313943557),
+					isDir:   false,
+				},
+			},"/assets/static/Analyze.js": File{
+				data: []byte{
+					0x69, 0x6d, 0x70, 0x6f, 0x72, 0x74, 0x20, 0x7b, 0x20, 0x53, 0x6f, 0x63,
+					0x6b, 0x65, 0x74, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x20, 0x7d, 0x20,
+					0x66, 0x72, 0x6f, 0x6d, 0x20, 0x22, 0x2e, 0x2f, 0x53, 0x6f, 0x63, 0x6b,
+					0x65, 0x74, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x2e, 0x6a, 0x73, 0x22,
+					0x3b, 0x0d, 0x0a, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x64, 0x61, 0x74,
+					0x61, 0x55, 0x52, 0x4c, 0x20, 0x3d, 0x20, 0x22, 0x2f, 0x5f, 0x5f, 0x73,
+					0x77, 0x61, 0x72, 0x6d, 0x5f, 0x5f, 0x2f, 0x61, 0x6e, 0x61, 0x6c, 0x79,
+					0x7a, 0x65, 0x2e, 0x6a, 0x73, 0x6f, 0x6e, 0x22, 0x3b, 0x0d, 0x0a, 0x2f,
+					0x2a, 0x2a, 0x20, 0x48, 0x65, 0x69, 0x67, 0x68, 0x74, 0x20, 0x6f, 0x66,
+					0x20, 0x74, 0x68, 0x65, 0x20, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x20, 0x61,
+					0x74, 0x20, 0x74, 0x68, 0x65, 0x20, 0x74, 0x6f, 0x70, 0x20, 0x6f, 0x66,
+					0x20, 0x61, 0x20, 0x62, 0x6f, 0x78, 0x20, 0x74, 0x68, 0x61, 0x74, 0x20,
+					0x63, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x73, 0x20, 0x73, 0x6d, 0x61,
+					0x6c, 0x6c, 0x65, 0x72, 0x20, 0x62, 0x6f, 0x78, 0x65, 0x73, 0x20, 0x2a,
+					0x2f, 0x0d, 0x0a, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x6c, 0x61, 0x62,
+					0x65, 0x6c, 0x48, 0x65, 0x69, 0x67, 0x68, 0x74, 0x20, 0x3d, 0x20, 0x31,
+					0x38, 0x3b, 0x0d, 0x0a, 0x2f, 0x2a, 0x2a, 0x20, 0x42, 0x6f, 0x78, 0x65,
+					0x73, 0x20, 0x61, 0x72, 0x65, 0x20, 0x6e, 0x65, 0x73, 0x74, 0x65, 0x64,
+					0x20, 0x74, 0x68, 0x69, 0x73, 0x20, 0x6d, 0x61, 0x6e, 0x79, 0x20, 0x6c,
+					0x65, 0x76, 0x65, 0x6c, 0x73, 0x20, 0x62, 0x65, 0x6e, 0x65, 0x61, 0x74,
+					0x68, 0x20, 0x74, 0x68, 0x65, 0x20, 0x7a, 0x6f, 0x6f, 0x6d, 0x65, 0x64,
+					0x20, 0x62, 0x6f, 0x78, 0x20, 0x2a, 0x2f, 0x0d, 0x0a, 0x63, 0x6f, 0x6e,
+					0x73, 0x74, 0x20, 0x6d, 0x61, 0x78, 0x44, 0x65, 0x70, 0x74, 0x68, 0x20,
+					0x3d, 0x20, 0x34, 0x3b, 0x0d, 0x0a, 0x2f, 0x2a, 0x2a, 0x20, 0x42, 0x6f,
+					0x78, 0x65, 0x73, 0x20, 0x6e, 0x61, 0x72, 0x72, 0x6f, 0x77, 0x65, 0x72,
+					0x20, 0x6f, 0x72, 0x20, 0x73, 0x68, 0x6f, 0x72, 0x74, 0x65, 0x72, 0x20,
+					0x74, 0x68, 0x61, 0x6e, 0x20, 0x74, 0x68, 0x69, 0x73, 0x20, 0x61, 0x72,
+					0x65, 0x6e, 0x27, 0x74, 0x20, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x6c, 0x65,
+					0x64, 0x2c, 0x20, 0x6f, 0x72, 0x20, 0x64, 0x69, 0x76, 0x69, 0x64, 0x65,
+					0x64, 0x20, 0x69, 0x6e, 0x74, 0x6f, 0x20, 0x73, 0x6d, 0x61, 0x6c, 0x6c,
+					0x65, 0x72, 0x20, 0x62, 0x6f, 0x78, 0x65, 0x73, 0x20, 0x2a, 0x2f, 0x0d,
+					0x0a, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x6d, 0x69, 0x6e, 0x53, 0x69,
+					0x64, 0x65, 0x20, 0x3d, 0x20, 0x32, 0x34, 0x3b, 0x0d, 0x0a, 0x63, 0x6f,
+					0x6e, 0x73, 0x74, 0x20, 0x74, 0x72, 0x65, 0x65, 0x6d, 0x61, 0x70, 0x20,
+					0x3d, 0x20, 0x64, 0x6f, 0x63, 0x75, 0x6d, 0x65, 0x6e, 0x74, 0x2e, 0x67,
+					0x65, 0x74, 0x45, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x42, 0x79, 0x49,
+					0x64, 0x28, 0x22, 0x74, 0x72, 0x65, 0x65, 0x6d, 0x61, 0x70, 0x22, 0x29,
+					0x3b, 0x0d, 0x0a, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x62, 0x72, 0x65,
+					0x61, 0x64, 0x63, 0x72, 0x75, 0x6d, 0x62, 0x73, 0x20, 0x3d, 0x20, 0x64,
+					0x6f, 0x63, 0x75, 0x6d, 0x65, 0x6e, 0x74, 0x2e, 0x67, 0x65, 0x74, 0x45,
+					0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x42, 0x79, 0x49, 0x64, 0x28, 0x22,
+					0x62, 0x72, 0x65, 0x61, 0x64, 0x63, 0x72, 0x75, 0x6d, 0x62, 0x73, 0x22,
+					0x29, 0x3b, 0x0d, 0x0a, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x62, 0x75,
+					0x6e, 0x64, 0x6c, 0x65, 0x4c, 0x69, 0x73, 0x74, 0x20, 0x3d, 0x20, 0x64,
+					0x6f, 0x63, 0x75, 0x6d, 0x65, 0x6e, 0x74, 0x2e, 0x67, 0x65, 0x74, 0x45,
+					0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x42, 0x79, 0x49, 0x64, 0x28, 0x22,
+					0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x73, 0x22, 0x29, 0x3b, 0x0d, 0x0a,
+					0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x73, 0x65, 0x61, 0x72, 0x63, 0x68,
+					0x20, 0x3d, 0x20, 0x64, 0x6f, 0x63, 0x75, 0x6d, 0x65, 0x6e, 0x74, 0x2e,
+					0x67, 0x65, 0x74, 0x45, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x42, 0x79,
+					0x49, 0x64, 0x28, 0x22, 0x73, 0x65, 0x61, 0x72, 0x63, 0x68, 0x22, 0x29,
+					0x3b, 0x0d, 0x0a, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x73, 0x74, 0x61,
+					0x74, 0x75, 0x73, 0x20, 0x3d, 0x20, 0x64, 0x6f, 0x63, 0x75, 0x6d, 0x65,
+					0x6e, 0x74, 0x2e, 0x67, 0x65, 0x74, 0x45, 0x6c, 0x65, 0x6d, 0x65, 0x6e,
+					0x74, 0x42, 0x79, 0x49, 0x64, 0x28, 0x22, 0x73, 0x74, 0x61, 0x74, 0x75,
+					0x73, 0x22, 0x29, 0x3b, 0x0d, 0x0a, 0x6c, 0x65, 0x74, 0x20, 0x72, 0x6f,
+					0x6f, 0x74, 0x20, 0x3d, 0x20, 0x6e, 0x75, 0x6c, 0x6c, 0x3b, 0x0d, 0x0a,
+					0x6c, 0x65, 0x74, 0x20, 0x7a, 0x6f, 0x6f, 0x6d, 0x65, 0x64, 0x20, 0x3d,
+					0x20, 0x6e, 0x75, 0x6c, 0x6c, 0x3b, 0x0d, 0x0a, 0x6c, 0x65, 0x74, 0x20,
+					0x73, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x20, 0x3d, 0x20, 0x6e, 0x75, 0x6c,
+					0x6c, 0x3b, 0x0d, 0x0a, 0x66, 0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+					0x20, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x42, 0x79, 0x74, 0x65, 0x53,
+					0x69, 0x7a, 0x65, 0x28, 0x73, 0x69, 0x7a, 0x65, 0x29, 0x20, 0x7b, 0x0d,
+					0x0a, 0x20, 0x20, 0x20, 0x20, 0x69, 0x66, 0x20, 0x28, 0x73, 0x69, 0x7a,
+					0x65, 0x20, 0x3c, 0x20, 0x31, 0x30, 0x32, 0x34, 0x29, 0x20, 0x7b, 0x0d,
+					0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x72, 0x65, 0x74,
+					0x75, 0x72, 0x6e, 0x20, 0x73, 0x69, 0x7a, 0x65, 0x20, 0x2b, 0x20, 0x22,
+					0x20, 0x42, 0x22, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d,
+					0x0a, 0x20, 0x20, 0x20, 0x20, 0x69, 0x66, 0x20, 0x28, 0x73, 0x69, 0x7a,
+					0x65, 0x20, 0x3c, 0x20, 0x31, 0x30, 0x32, 0x34, 0x20, 0x2a, 0x20, 0x31,
+					0x30, 0x32, 0x34, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x72, 0x65, 0x74, 0x75, 0x72, 0x6e, 0x20, 0x28,
+					0x73, 0x69, 0x7a, 0x65, 0x20, 0x2f, 0x20, 0x31, 0x30, 0x32, 0x34, 0x29,
+					0x2e, 0x74, 0x6f, 0x46, 0x69, 0x78, 0x65, 0x64, 0x28, 0x31, 0x29, 0x20,
+					0x2b, 0x20, 0x22, 0x20, 0x4b, 0x42, 0x22, 0x3b, 0x0d, 0x0a, 0x20, 0x20,
+					0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x72, 0x65, 0x74,
+					0x75, 0x72, 0x6e, 0x20, 0x28, 0x73, 0x69, 0x7a, 0x65, 0x20, 0x2f, 0x20,
+					0x28, 0x31, 0x30, 0x32, 0x34, 0x20, 0x2a, 0x20, 0x31, 0x30, 0x32, 0x34,
+					0x29, 0x29, 0x2e, 0x74, 0x6f, 0x46, 0x69, 0x78, 0x65, 0x64, 0x28, 0x31,
+					0x29, 0x20, 0x2b, 0x20, 0x22, 0x20, 0x4d, 0x42, 0x22, 0x3b, 0x0d, 0x0a,
+					0x7d, 0x0d, 0x0a, 0x66, 0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x20,
+					0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x4b, 0x65, 0x79, 0x28, 0x62, 0x75,
+					0x6e, 0x64, 0x6c, 0x65, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x72, 0x65, 0x74, 0x75, 0x72, 0x6e, 0x20, 0x62, 0x75, 0x6e, 0x64,
+					0x6c, 0x65, 0x2e, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x20, 0x2b, 0x20, 0x22,
+					0x3a, 0x22, 0x20, 0x2b, 0x20, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x2e,
+					0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x65, 0x3b, 0x0d, 0x0a, 0x7d, 0x0d, 0x0a,
+					0x66, 0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x20, 0x6f, 0x76, 0x65,
+					0x72, 0x42, 0x75, 0x64, 0x67, 0x65, 0x74, 0x28, 0x62, 0x75, 0x6e, 0x64,
+					0x6c, 0x65, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x63,
+					0x6f, 0x6e, 0x73, 0x74, 0x20, 0x62, 0x75, 0x64, 0x67, 0x65, 0x74, 0x20,
+					0x3d, 0x20, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x2e, 0x62, 0x75, 0x64,
+					0x67, 0x65, 0x74, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x72, 0x65,
+					0x74, 0x75, 0x72, 0x6e, 0x20, 0x21, 0x21, 0x62, 0x75, 0x64, 0x67, 0x65,
+					0x74, 0x20, 0x26, 0x26, 0x20, 0x28, 0x28, 0x62, 0x75, 0x64, 0x67, 0x65,
+					0x74, 0x2e, 0x72, 0x61, 0x77, 0x20, 0x3e, 0x20, 0x30, 0x20, 0x26, 0x26,
+					0x20, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x2e, 0x72, 0x61, 0x77, 0x20,
+					0x3e, 0x20, 0x62, 0x75, 0x64, 0x67, 0x65, 0x74, 0x2e, 0x72, 0x61, 0x77,
+					0x29, 0x20, 0x7c, 0x7c, 0x20, 0x28, 0x62, 0x75, 0x64, 0x67, 0x65, 0x74,
+					0x2e, 0x67, 0x7a, 0x69, 0x70, 0x20, 0x3e, 0x20, 0x30, 0x20, 0x26, 0x26,
+					0x20, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x2e, 0x67, 0x7a, 0x69, 0x70,
+					0x20, 0x3e, 0x20, 0x62, 0x75, 0x64, 0x67, 0x65, 0x74, 0x2e, 0x67, 0x7a,
+					0x69, 0x70, 0x29, 0x29, 0x3b, 0x0d, 0x0a, 0x7d, 0x0d, 0x0a, 0x2f, 0x2a,
+					0x2a, 0x20, 0x42, 0x75, 0x69, 0x6c, 0x64, 0x73, 0x20, 0x61, 0x20, 0x74,
+					0x72, 0x65, 0x65, 0x20, 0x6f, 0x66, 0x20, 0x62, 0x75, 0x6e, 0x64, 0x6c,
+					0x65, 0x73, 0x2c, 0x20, 0x74, 0x68, 0x65, 0x6e, 0x20, 0x74, 0x68, 0x65,
+					0x20, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x69, 0x65, 0x73,
+					0x20, 0x61, 0x6e, 0x64, 0x20, 0x66, 0x69, 0x6c, 0x65, 0x73, 0x20, 0x6f,
+					0x66, 0x20, 0x65, 0x61, 0x63, 0x68, 0x2c, 0x20, 0x6d, 0x65, 0x72, 0x67,
+					0x69, 0x6e, 0x67, 0x20, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x6f, 0x72,
+					0x69, 0x65, 0x73, 0x20, 0x77, 0x69, 0x74, 0x68, 0x20, 0x61, 0x20, 0x73,
+					0x69, 0x6e, 0x67, 0x6c, 0x65, 0x20, 0x73, 0x75, 0x62, 0x64, 0x69, 0x72,
+					0x65, 0x63, 0x74, 0x6f, 0x72, 0x79, 0x20, 0x2a, 0x2f, 0x0d, 0x0a, 0x66,
+					0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x20, 0x62, 0x75, 0x69, 0x6c,
+					0x64, 0x54, 0x72, 0x65, 0x65, 0x28, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65,
+					0x73, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f,
+					0x6e, 0x73, 0x74, 0x20, 0x74, 0x72, 0x65, 0x65, 0x20, 0x3d, 0x20, 0x7b,
+					0x20, 0x6e, 0x61, 0x6d, 0x65, 0x3a, 0x20, 0x22, 0x41, 0x6c, 0x6c, 0x20,
+					0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x73, 0x22, 0x2c, 0x20, 0x70, 0x61,
+					0x74, 0x68, 0x3a, 0x20, 0x22, 0x22, 0x2c, 0x20, 0x73, 0x69, 0x7a, 0x65,
+					0x3a, 0x20, 0x30, 0x2c, 0x20, 0x63, 0x68, 0x69, 0x6c, 0x64, 0x72, 0x65,
+					0x6e, 0x3a, 0x20, 0x5b, 0x5d, 0x2c, 0x20, 0x68, 0x75, 0x65, 0x3a, 0x20,
+					0x30, 0x20, 0x7d, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x62, 0x75,
+					0x6e, 0x64, 0x6c, 0x65, 0x73, 0x2e, 0x66, 0x6f, 0x72, 0x45, 0x61, 0x63,
+					0x68, 0x28, 0x28, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x2c, 0x20, 0x69,
+					0x29, 0x20, 0x3d, 0x3e, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x62, 0x75,
+					0x6e, 0x64, 0x6c, 0x65, 0x4e, 0x6f, 0x64, 0x65, 0x20, 0x3d, 0x20, 0x7b,
+					0x20, 0x6e, 0x61, 0x6d, 0x65, 0x3a, 0x20, 0x62, 0x75, 0x6e, 0x64, 0x6c,
+					0x65, 0x2e, 0x6d, 0x6f, 0x64, 0x75, 0x6c, 0x65, 0x2c, 0x20, 0x70, 0x61,
+					0x74, 0x68, 0x3a, 0x20, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x4b, 0x65,
+					0x79, 0x28, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x29, 0x2c, 0x20, 0x73,
+					0x69, 0x7a, 0x65, 0x3a, 0x20, 0x30, 0x2c, 0x20, 0x63, 0x68, 0x69, 0x6c,
+					0x64, 0x72, 0x65, 0x6e, 0x3a, 0x20, 0x5b, 0x5d, 0x2c, 0x20, 0x70, 0x61,
+					0x72, 0x65, 0x6e, 0x74, 0x3a, 0x20, 0x74, 0x72, 0x65, 0x65, 0x2c, 0x20,
+					0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x2c, 0x20, 0x68, 0x75, 0x65, 0x3a,
+					0x20, 0x28, 0x69, 0x20, 0x2a, 0x20, 0x36, 0x37, 0x29, 0x20, 0x25, 0x20,
+					0x33, 0x36, 0x30, 0x20, 0x7d, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x2e, 0x66,
+					0x69, 0x6c, 0x65, 0x73, 0x2e, 0x66, 0x6f, 0x72, 0x45, 0x61, 0x63, 0x68,
+					0x28, 0x66, 0x69, 0x6c, 0x65, 0x20, 0x3d, 0x3e, 0x20, 0x7b, 0x0d, 0x0a,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x6c, 0x65, 0x74, 0x20, 0x6e, 0x6f, 0x64, 0x65, 0x20, 0x3d, 0x20, 0x62,
+					0x75, 0x6e, 0x64, 0x6c, 0x65, 0x4e, 0x6f, 0x64, 0x65, 0x3b, 0x0d, 0x0a,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x66, 0x69, 0x6c, 0x65, 0x2e, 0x69, 0x64, 0x2e, 0x73, 0x70, 0x6c, 0x69,
+					0x74, 0x28, 0x22, 0x2f, 0x22, 0x29, 0x2e, 0x66, 0x6f, 0x72, 0x45, 0x61,
+					0x63, 0x68, 0x28, 0x6e, 0x61, 0x6d, 0x65, 0x20, 0x3d, 0x3e, 0x20, 0x7b,
+					0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x6c, 0x65, 0x74, 0x20, 0x63, 0x68,
+					0x69, 0x6c, 0x64, 0x20, 0x3d, 0x20, 0x6e, 0x6f, 0x64, 0x65, 0x2e, 0x63,
+					0x68, 0x69, 0x6c, 0x64, 0x72, 0x65, 0x6e, 0x2e, 0x66, 0x69, 0x6e, 0x64,
+					0x28, 0x63, 0x20, 0x3d, 0x3e, 0x20, 0x63, 0x2e, 0x6e, 0x61, 0x6d, 0x65,
+					0x20, 0x3d, 0x3d, 0x3d, 0x20, 0x6e, 0x61, 0x6d, 0x65, 0x29, 0x3b, 0x0d,
+					0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x69, 0x66, 0x20, 0x28, 0x21, 0x63, 0x68,
+					0x69, 0x6c, 0x64, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x63, 0x68, 0x69, 0x6c, 0x64, 0x20, 0x3d, 0x20,
+					0x7b, 0x20, 0x6e, 0x61, 0x6d, 0x65, 0x2c, 0x20, 0x70, 0x61, 0x74, 0x68,
+					0x3a, 0x20, 0x6e, 0x6f, 0x64, 0x65, 0x2e, 0x70, 0x61, 0x74, 0x68, 0x20,
+					0x2b, 0x20, 0x22, 0x2f, 0x22, 0x20, 0x2b, 0x20, 0x6e, 0x61, 0x6d, 0x65,
+					0x2c, 0x20, 0x73, 0x69, 0x7a, 0x65, 0x3a, 0x20, 0x30, 0x2c, 0x20, 0x63,
+					0x68, 0x69, 0x6c, 0x64, 0x72, 0x65, 0x6e, 0x3a, 0x20, 0x5b, 0x5d, 0x2c,
+					0x20, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x3a, 0x20, 0x6e, 0x6f, 0x64,
+					0x65, 0x2c, 0x20, 0x68, 0x75, 0x65, 0x3a, 0x20, 0x62, 0x75, 0x6e, 0x64,
+					0x6c, 0x65, 0x4e, 0x6f, 0x64, 0x65, 0x2e, 0x68, 0x75, 0x65, 0x20, 0x7d,
+					0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x6e,
+					0x6f, 0x64, 0x65, 0x2e, 0x63, 0x68, 0x69, 0x6c, 0x64, 0x72, 0x65, 0x6e,
+					0x2e, 0x70, 0x75, 0x73, 0x68, 0x28, 0x63, 0x68, 0x69, 0x6c, 0x64, 0x29,
+					0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x63, 0x68, 0x69, 0x6c, 0x64, 0x2e, 0x73, 0x69, 0x7a, 0x65,
+					0x20, 0x2b, 0x3d, 0x20, 0x66, 0x69, 0x6c, 0x65, 0x2e, 0x73, 0x69, 0x7a,
+					0x65, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x6e, 0x6f, 0x64, 0x65,
+					0x20, 0x3d, 0x20, 0x63, 0x68, 0x69, 0x6c, 0x64, 0x3b, 0x0d, 0x0a, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x7d,
+					0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x4e, 0x6f,
+					0x64, 0x65, 0x2e, 0x73, 0x69, 0x7a, 0x65, 0x20, 0x2b, 0x3d, 0x20, 0x66,
+					0x69, 0x6c, 0x65, 0x2e, 0x73, 0x69, 0x7a, 0x65, 0x3b, 0x0d, 0x0a, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x29, 0x3b, 0x0d, 0x0a,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x62, 0x75, 0x6e, 0x64,
+					0x6c, 0x65, 0x4e, 0x6f, 0x64, 0x65, 0x2e, 0x63, 0x68, 0x69, 0x6c, 0x64,
+					0x72, 0x65, 0x6e, 0x2e, 0x66, 0x6f, 0x72, 0x45, 0x61, 0x63, 0x68, 0x28,
+					0x6d, 0x65, 0x72, 0x67, 0x65, 0x53, 0x69, 0x6e, 0x67, 0x6c, 0x65, 0x43,
+					0x68, 0x69, 0x6c, 0x64, 0x72, 0x65, 0x6e, 0x29, 0x3b, 0x0d, 0x0a, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x74, 0x72, 0x65, 0x65, 0x2e,
+					0x63, 0x68, 0x69, 0x6c, 0x64, 0x72, 0x65, 0x6e, 0x2e, 0x70, 0x75, 0x73,
+					0x68, 0x28, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x4e, 0x6f, 0x64, 0x65,
+					0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x74, 0x72, 0x65, 0x65, 0x2e, 0x73, 0x69, 0x7a, 0x65, 0x20, 0x2b, 0x3d,
+					0x20, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x4e, 0x6f, 0x64, 0x65, 0x2e,
+					0x73, 0x69, 0x7a, 0x65, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x7d,
+					0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x73, 0x6f, 0x72, 0x74,
+					0x42, 0x79, 0x53, 0x69, 0x7a, 0x65, 0x28, 0x74, 0x72, 0x65, 0x65, 0x29,
+					0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x72, 0x65, 0x74, 0x75, 0x72,
+					0x6e, 0x20, 0x74, 0x72, 0x65, 0x65, 0x3b, 0x0d, 0x0a, 0x7d, 0x0d, 0x0a,
+					0x66, 0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x20, 0x6d, 0x65, 0x72,
+					0x67, 0x65, 0x53, 0x69, 0x6e, 0x67, 0x6c, 0x65, 0x43, 0x68, 0x69, 0x6c,
+					0x64, 0x72, 0x65, 0x6e, 0x28, 0x6e, 0x6f, 0x64, 0x65, 0x29, 0x20, 0x7b,
+					0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x77, 0x68, 0x69, 0x6c, 0x65, 0x20,
+					0x28, 0x6e, 0x6f, 0x64, 0x65, 0x2e, 0x63, 0x68, 0x69, 0x6c, 0x64, 0x72,
+					0x65, 0x6e, 0x2e, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x20, 0x3d, 0x3d,
+					0x3d, 0x20, 0x31, 0x20, 0x26, 0x26, 0x20, 0x6e, 0x6f, 0x64, 0x65, 0x2e,
+					0x63, 0x68, 0x69, 0x6c, 0x64, 0x72, 0x65, 0x6e, 0x5b, 0x30, 0x5d, 0x2e,
+					0x63, 0x68, 0x69, 0x6c, 0x64, 0x72, 0x65, 0x6e, 0x2e, 0x6c, 0x65, 0x6e,
+					0x67, 0x74, 0x68, 0x20, 0x3e, 0x20, 0x30, 0x29, 0x20, 0x7b, 0x0d, 0x0a,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e, 0x73,
+					0x74, 0x20, 0x63, 0x68, 0x69, 0x6c, 0x64, 0x20, 0x3d, 0x20, 0x6e, 0x6f,
+					0x64, 0x65, 0x2e, 0x63, 0x68, 0x69, 0x6c, 0x64, 0x72, 0x65, 0x6e, 0x5b,
+					0x30, 0x5d, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x6e, 0x6f, 0x64, 0x65, 0x2e, 0x6e, 0x61, 0x6d, 0x65, 0x20, 0x2b,
+					0x3d, 0x20, 0x22, 0x2f, 0x22, 0x20, 0x2b, 0x20, 0x63, 0x68, 0x69, 0x6c,
+					0x64, 0x2e, 0x6e, 0x61, 0x6d, 0x65, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x6e, 0x6f, 0x64, 0x65, 0x2e, 0x70, 0x61,
+					0x74, 0x68, 0x20, 0x3d, 0x20, 0x63, 0x68, 0x69, 0x6c, 0x64, 0x2e, 0x70,
+					0x61, 0x74, 0x68, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x6e, 0x6f, 0x64, 0x65, 0x2e, 0x63, 0x68, 0x69, 0x6c, 0x64,
+					0x72, 0x65, 0x6e, 0x20, 0x3d, 0x20, 0x63, 0x68, 0x69, 0x6c, 0x64, 0x2e,
+					0x63, 0x68, 0x69, 0x6c, 0x64, 0x72, 0x65, 0x6e, 0x3b, 0x0d, 0x0a, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x6e, 0x6f, 0x64, 0x65, 0x2e,
+					0x63, 0x68, 0x69, 0x6c, 0x64, 0x72, 0x65, 0x6e, 0x2e, 0x66, 0x6f, 0x72,
+					0x45, 0x61, 0x63, 0x68, 0x28, 0x63, 0x20, 0x3d, 0x3e, 0x20, 0x63, 0x2e,
+					0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x20, 0x3d, 0x20, 0x6e, 0x6f, 0x64,
+					0x65, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d, 0x0a,
+					0x20, 0x20, 0x20, 0x20, 0x6e, 0x6f, 0x64, 0x65, 0x2e, 0x63, 0x68, 0x69,
+					0x6c, 0x64, 0x72, 0x65, 0x6e, 0x2e, 0x66, 0x6f, 0x72, 0x45, 0x61, 0x63,
+					0x68, 0x28, 0x6d, 0x65, 0x72, 0x67, 0x65, 0x53, 0x69, 0x6e, 0x67, 0x6c,
+					0x65, 0x43, 0x68, 0x69, 0x6c, 0x64, 0x72, 0x65, 0x6e, 0x29, 0x3b, 0x0d,
+					0x0a, 0x7d, 0x0d, 0x0a, 0x66, 0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+					0x20, 0x73, 0x6f, 0x72, 0x74, 0x42, 0x79, 0x53, 0x69, 0x7a, 0x65, 0x28,
+					0x6e, 0x6f, 0x64, 0x65, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x6e, 0x6f, 0x64, 0x65, 0x2e, 0x63, 0x68, 0x69, 0x6c, 0x64, 0x72,
+					0x65, 0x6e, 0x2e, 0x73, 0x6f, 0x72, 0x74, 0x28, 0x28, 0x61, 0x2c, 0x20,
+					0x62, 0x29, 0x20, 0x3d, 0x3e, 0x20, 0x62, 0x2e, 0x73, 0x69, 0x7a, 0x65,
+					0x20, 0x2d, 0x20, 0x61, 0x2e, 0x73, 0x69, 0x7a, 0x65, 0x20, 0x7c, 0x7c,
+					0x20, 0x28, 0x61, 0x2e, 0x6e, 0x61, 0x6d, 0x65, 0x20, 0x3c, 0x20, 0x62,
+					0x2e, 0x6e, 0x61, 0x6d, 0x65, 0x20, 0x3f, 0x20, 0x2d, 0x31, 0x20, 0x3a,
+					0x20, 0x31, 0x29, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x6e,
+					0x6f, 0x64, 0x65, 0x2e, 0x63, 0x68, 0x69, 0x6c, 0x64, 0x72, 0x65, 0x6e,
+					0x2e, 0x66, 0x6f, 0x72, 0x45, 0x61, 0x63, 0x68, 0x28, 0x73, 0x6f, 0x72,
+					0x74, 0x42, 0x79, 0x53, 0x69, 0x7a, 0x65, 0x29, 0x3b, 0x0d, 0x0a, 0x7d,
+					0x0d, 0x0a, 0x66, 0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x20, 0x66,
+					0x69, 0x6e, 0x64, 0x4e, 0x6f, 0x64, 0x65, 0x28, 0x6e, 0x6f, 0x64, 0x65,
+					0x2c, 0x20, 0x70, 0x61, 0x74, 0x68, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20,
+					0x20, 0x20, 0x20, 0x69, 0x66, 0x20, 0x28, 0x6e, 0x6f, 0x64, 0x65, 0x2e,
+					0x70, 0x61, 0x74, 0x68, 0x20, 0x3d, 0x3d, 0x3d, 0x20, 0x70, 0x61, 0x74,
+					0x68, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x72, 0x65, 0x74, 0x75, 0x72, 0x6e, 0x20, 0x6e, 0x6f, 0x64,
+					0x65, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x20,
+					0x20, 0x20, 0x20, 0x66, 0x6f, 0x72, 0x20, 0x28, 0x63, 0x6f, 0x6e, 0x73,
+					0x74, 0x20, 0x63, 0x68, 0x69, 0x6c, 0x64, 0x20, 0x6f, 0x66, 0x20, 0x6e,
+					0x6f, 0x64, 0x65, 0x2e, 0x63, 0x68, 0x69, 0x6c, 0x64, 0x72, 0x65, 0x6e,
+					0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x66, 0x6f, 0x75, 0x6e, 0x64,
+					0x20, 0x3d, 0x20, 0x66, 0x69, 0x6e, 0x64, 0x4e, 0x6f, 0x64, 0x65, 0x28,
+					0x63, 0x68, 0x69, 0x6c, 0x64, 0x2c, 0x20, 0x70, 0x61, 0x74, 0x68, 0x29,
+					0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x69,
+					0x66, 0x20, 0x28, 0x66, 0x6f, 0x75, 0x6e, 0x64, 0x29, 0x20, 0x7b, 0x0d,
+					0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x72, 0x65, 0x74, 0x75, 0x72, 0x6e, 0x20, 0x66, 0x6f, 0x75, 0x6e,
+					0x64, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20,
+					0x20, 0x20, 0x72, 0x65, 0x74, 0x75, 0x72, 0x6e, 0x20, 0x6e, 0x75, 0x6c,
+					0x6c, 0x3b, 0x0d, 0x0a, 0x7d, 0x0d, 0x0a, 0x2f, 0x2a, 0x2a, 0x20, 0x54,
+					0x68, 0x65, 0x20, 0x77, 0x6f, 0x72, 0x73, 0x74, 0x20, 0x61, 0x73, 0x70,
+					0x65, 0x63, 0x74, 0x20, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x20, 0x6f, 0x66,
+					0x20, 0x61, 0x20, 0x72, 0x6f, 0x77, 0x20, 0x6f, 0x66, 0x20, 0x62, 0x6f,
+					0x78, 0x65, 0x73, 0x20, 0x77, 0x69, 0x74, 0x68, 0x20, 0x74, 0x68, 0x65,
+					0x73, 0x65, 0x20, 0x61, 0x72, 0x65, 0x61, 0x73, 0x2c, 0x20, 0x6c, 0x61,
+					0x69, 0x64, 0x20, 0x61, 0x6c, 0x6f, 0x6e, 0x67, 0x20, 0x61, 0x20, 0x73,
+					0x69, 0x64, 0x65, 0x20, 0x2a, 0x2f, 0x0d, 0x0a, 0x66, 0x75, 0x6e, 0x63,
+					0x74, 0x69, 0x6f, 0x6e, 0x20, 0x77, 0x6f, 0x72, 0x73, 0x74, 0x41, 0x73,
+					0x70, 0x65, 0x63, 0x74, 0x52, 0x61, 0x74, 0x69, 0x6f, 0x28, 0x61, 0x72,
+					0x65, 0x61, 0x73, 0x2c, 0x20, 0x73, 0x69, 0x64, 0x65, 0x29, 0x20, 0x7b,
+					0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20,
+					0x74, 0x6f, 0x74, 0x61, 0x6c, 0x20, 0x3d, 0x20, 0x61, 0x72, 0x65, 0x61,
+					0x73, 0x2e, 0x72, 0x65, 0x64, 0x75, 0x63, 0x65, 0x28, 0x28, 0x73, 0x75,
+					0x6d, 0x2c, 0x20, 0x61, 0x72, 0x65, 0x61, 0x29, 0x20, 0x3d, 0x3e, 0x20,
+					0x73, 0x75, 0x6d, 0x20, 0x2b, 0x20, 0x61, 0x72, 0x65, 0x61, 0x2c, 0x20,
+					0x30, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e,
+					0x73, 0x74, 0x20, 0x6c, 0x61, 0x72, 0x67, 0x65, 0x73, 0x74, 0x20, 0x3d,
+					0x20, 0x4d, 0x61, 0x74, 0x68, 0x2e, 0x6d, 0x61, 0x78, 0x28, 0x2e, 0x2e,
+					0x2e, 0x61, 0x72, 0x65, 0x61, 0x73, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20,
+					0x20, 0x20, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x73, 0x6d, 0x61, 0x6c,
+					0x6c, 0x65, 0x73, 0x74, 0x20, 0x3d, 0x20, 0x4d, 0x61, 0x74, 0x68, 0x2e,
+					0x6d, 0x69, 0x6e, 0x28, 0x2e, 0x2e, 0x2e, 0x61, 0x72, 0x65, 0x61, 0x73,
+					0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x72, 0x65, 0x74, 0x75,
+					0x72, 0x6e, 0x20, 0x4d, 0x61, 0x74, 0x68, 0x2e, 0x6d, 0x61, 0x78, 0x28,
+					0x73, 0x69, 0x64, 0x65, 0x20, 0x2a, 0x20, 0x73, 0x69, 0x64, 0x65, 0x20,
+					0x2a, 0x20, 0x6c, 0x61, 0x72, 0x67, 0x65, 0x73, 0x74, 0x20, 0x2f, 0x20,
+					0x28, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x20, 0x2a, 0x20, 0x74, 0x6f, 0x74,
+					0x61, 0x6c, 0x29, 0x2c, 0x20, 0x28, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x20,
+					0x2a, 0x20, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x29, 0x20, 0x2f, 0x20, 0x28,
+					0x73, 0x69, 0x64, 0x65, 0x20, 0x2a, 0x20, 0x73, 0x69, 0x64, 0x65, 0x20,
+					0x2a, 0x20, 0x73, 0x6d, 0x61, 0x6c, 0x6c, 0x65, 0x73, 0x74, 0x29, 0x29,
+					0x3b, 0x0d, 0x0a, 0x7d, 0x0d, 0x0a, 0x2f, 0x2a, 0x2a, 0x20, 0x4c, 0x61,
+					0x79, 0x73, 0x20, 0x6f, 0x75, 0x74, 0x20, 0x62, 0x6f, 0x78, 0x65, 0x73,
+					0x20, 0x77, 0x69, 0x74, 0x68, 0x20, 0x61, 0x72, 0x65, 0x61, 0x73, 0x20,
+					0x70, 0x72, 0x6f, 0x70, 0x6f, 0x72, 0x74, 0x69, 0x6f, 0x6e, 0x61, 0x6c,
+					0x20, 0x74, 0x6f, 0x20, 0x74, 0x68, 0x65, 0x69, 0x72, 0x20, 0x73, 0x69,
+					0x7a, 0x65, 0x73, 0x2c, 0x20, 0x6b, 0x65, 0x65, 0x70, 0x69, 0x6e, 0x67,
+					0x20, 0x74, 0x68, 0x65, 0x6d, 0x20, 0x61, 0x73, 0x20, 0x73, 0x71, 0x75,
+					0x61, 0x72, 0x65, 0x20, 0x61, 0x73, 0x20, 0x70, 0x6f, 0x73, 0x73, 0x69,
+					0x62, 0x6c, 0x65, 0x20, 0x28, 0x73, 0x71, 0x75, 0x61, 0x72, 0x69, 0x66,
+					0x69, 0x65, 0x64, 0x20, 0x74, 0x72, 0x65, 0x65, 0x6d, 0x61, 0x70, 0x29,
+					0x20, 0x2a, 0x2f, 0x0d, 0x0a, 0x66, 0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f,
+					0x6e, 0x20, 0x73, 0x71, 0x75, 0x61, 0x72, 0x69, 0x66, 0x79, 0x28, 0x6e,
+					0x6f, 0x64, 0x65, 0x73, 0x2c, 0x20, 0x72, 0x65, 0x63, 0x74, 0x29, 0x20,
+					0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e, 0x73, 0x74,
+					0x20, 0x6c, 0x61, 0x79, 0x6f, 0x75, 0x74, 0x20, 0x3d, 0x20, 0x5b, 0x5d,
+					0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e, 0x73, 0x74,
+					0x20, 0x73, 0x69, 0x7a, 0x65, 0x64, 0x20, 0x3d, 0x20, 0x6e, 0x6f, 0x64,
+					0x65, 0x73, 0x2e, 0x66, 0x69, 0x6c, 0x74, 0x65, 0x72, 0x28, 0x6e, 0x6f,
+					0x64, 0x65, 0x20, 0x3d, 0x3e, 0x20, 0x6e, 0x6f, 0x64, 0x65, 0x2e, 0x73,
+					0x69, 0x7a, 0x65, 0x20, 0x3e, 0x20, 0x30, 0x29, 0x3b, 0x0d, 0x0a, 0x20,
+					0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x74, 0x6f, 0x74,
+					0x61, 0x6c, 0x20, 0x3d, 0x20, 0x73, 0x69, 0x7a, 0x65, 0x64, 0x2e, 0x72,
+					0x65, 0x64, 0x75, 0x63, 0x65, 0x28, 0x28, 0x73, 0x75, 0x6d, 0x2c, 0x20,
+					0x6e, 0x6f, 0x64, 0x65, 0x29, 0x20, 0x3d, 0x3e, 0x20, 0x73, 0x75, 0x6d,
+					0x20, 0x2b, 0x20, 0x6e, 0x6f, 0x64, 0x65, 0x2e, 0x73, 0x69, 0x7a, 0x65,
+					0x2c, 0x20, 0x30, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x69,
+					0x66, 0x20, 0x28, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x20, 0x3d, 0x3d, 0x3d,
+					0x20, 0x30, 0x20, 0x7c, 0x7c, 0x20, 0x72, 0x65, 0x63, 0x74, 0x2e, 0x77,
+					0x20, 0x3c, 0x3d, 0x20, 0x30, 0x20, 0x7c, 0x7c, 0x20, 0x72, 0x65, 0x63,
+					0x74, 0x2e, 0x68, 0x20, 0x3c, 0x3d, 0x20, 0x30, 0x29, 0x20, 0x7b, 0x0d,
+					0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x72, 0x65, 0x74,
+					0x75, 0x72, 0x6e, 0x20, 0x6c, 0x61, 0x79, 0x6f, 0x75, 0x74, 0x3b, 0x0d,
+					0x0a, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x73, 0x63, 0x61, 0x6c, 0x65, 0x20,
+					0x3d, 0x20, 0x72, 0x65, 0x63, 0x74, 0x2e, 0x77, 0x20, 0x2a, 0x20, 0x72,
+					0x65, 0x63, 0x74, 0x2e, 0x68, 0x20, 0x2f, 0x20, 0x74, 0x6f, 0x74, 0x61,
+					0x6c, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x6c, 0x65, 0x74, 0x20,
+					0x7b, 0x20, 0x78, 0x2c, 0x20, 0x79, 0x2c, 0x20, 0x77, 0x2c, 0x20, 0x68,
+					0x20, 0x7d, 0x20, 0x3d, 0x20, 0x72, 0x65, 0x63, 0x74, 0x3b, 0x0d, 0x0a,
+					0x20, 0x20, 0x20, 0x20, 0x6c, 0x65, 0x74, 0x20, 0x69, 0x20, 0x3d, 0x20,
+					0x30, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x77, 0x68, 0x69, 0x6c,
+					0x65, 0x20, 0x28, 0x69, 0x20, 0x3c, 0x20, 0x73, 0x69, 0x7a, 0x65, 0x64,
+					0x2e, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x29, 0x20, 0x7b, 0x0d, 0x0a,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e, 0x73,
+					0x74, 0x20, 0x73, 0x69, 0x64, 0x65, 0x20, 0x3d, 0x20, 0x4d, 0x61, 0x74,
+					0x68, 0x2e, 0x6d, 0x69, 0x6e, 0x28, 0x77, 0x2c, 0x20, 0x68, 0x29, 0x3b,
+					0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f,
+					0x6e, 0x73, 0x74, 0x20, 0x72, 0x6f, 0x77, 0x20, 0x3d, 0x20, 0x5b, 0x73,
+					0x69, 0x7a, 0x65, 0x64, 0x5b, 0x69, 0x5d, 0x5d, 0x3b, 0x0d, 0x0a, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e, 0x73, 0x74,
+					0x20, 0x61, 0x72, 0x65, 0x61, 0x73, 0x20, 0x3d, 0x20, 0x5b, 0x73, 0x69,
+					0x7a, 0x65, 0x64, 0x5b, 0x69, 0x5d, 0x2e, 0x73, 0x69, 0x7a, 0x65, 0x20,
+					0x2a, 0x20, 0x73, 0x63, 0x61, 0x6c, 0x65, 0x5d, 0x3b, 0x0d, 0x0a, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x66, 0x6f, 0x72, 0x20, 0x28,
+					0x69, 0x2b, 0x2b, 0x3b, 0x20, 0x69, 0x20, 0x3c, 0x20, 0x73, 0x69, 0x7a,
+					0x65, 0x64, 0x2e, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x3b, 0x20, 0x69,
+					0x2b, 0x2b, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e, 0x73, 0x74,
+					0x20, 0x61, 0x72, 0x65, 0x61, 0x20, 0x3d, 0x20, 0x73, 0x69, 0x7a, 0x65,
+					0x64, 0x5b, 0x69, 0x5d, 0x2e, 0x73, 0x69, 0x7a, 0x65, 0x20, 0x2a, 0x20,
+					0x73, 0x63, 0x61, 0x6c, 0x65, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x69, 0x66, 0x20, 0x28,
+					0x77, 0x6f, 0x72, 0x73, 0x74, 0x41, 0x73, 0x70, 0x65, 0x63, 0x74, 0x52,
+					0x61, 0x74, 0x69, 0x6f, 0x28, 0x61, 0x72, 0x65, 0x61, 0x73, 0x2e, 0x63,
+					0x6f, 0x6e, 0x63, 0x61, 0x74, 0x28, 0x61, 0x72, 0x65, 0x61, 0x29, 0x2c,
+					0x20, 0x73, 0x69, 0x64, 0x65, 0x29, 0x20, 0x3e, 0x20, 0x77, 0x6f, 0x72,
+					0x73, 0x74, 0x41, 0x73, 0x70, 0x65, 0x63, 0x74, 0x52, 0x61, 0x74, 0x69,
+					0x6f, 0x28, 0x61, 0x72, 0x65, 0x61, 0x73, 0x2c, 0x20, 0x73, 0x69, 0x64,
+					0x65, 0x29, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x62,
+					0x72, 0x65, 0x61, 0x6b, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x72, 0x6f,
+					0x77, 0x2e, 0x70, 0x75, 0x73, 0x68, 0x28, 0x73, 0x69, 0x7a, 0x65, 0x64,
+					0x5b, 0x69, 0x5d, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x61, 0x72, 0x65, 0x61, 0x73,
+					0x2e, 0x70, 0x75, 0x73, 0x68, 0x28, 0x61, 0x72, 0x65, 0x61, 0x29, 0x3b,
+					0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d,
+					0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e,
+					0x73, 0x74, 0x20, 0x74, 0x68, 0x69, 0x63, 0x6b, 0x6e, 0x65, 0x73, 0x73,
+					0x20, 0x3d, 0x20, 0x61, 0x72, 0x65, 0x61, 0x73, 0x2e, 0x72, 0x65, 0x64,
+					0x75, 0x63, 0x65, 0x28, 0x28, 0x73, 0x75, 0x6d, 0x2c, 0x20, 0x61, 0x72,
+					0x65, 0x61, 0x29, 0x20, 0x3d, 0x3e, 0x20, 0x73, 0x75, 0x6d, 0x20, 0x2b,
+					0x20, 0x61, 0x72, 0x65, 0x61, 0x2c, 0x20, 0x30, 0x29, 0x20, 0x2f, 0x20,
+					0x73, 0x69, 0x64, 0x65, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x6c, 0x65, 0x74, 0x20, 0x6f, 0x66, 0x66, 0x73, 0x65,
+					0x74, 0x20, 0x3d, 0x20, 0x30, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x72, 0x6f, 0x77, 0x2e, 0x66, 0x6f, 0x72, 0x45,
+					0x61, 0x63, 0x68, 0x28, 0x28, 0x6e, 0x6f, 0x64, 0x65, 0x2c, 0x20, 0x6a,
+					0x29, 0x20, 0x3d, 0x3e, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e, 0x73,
+					0x74, 0x20, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x20, 0x3d, 0x20, 0x61,
+					0x72, 0x65, 0x61, 0x73, 0x5b, 0x6a, 0x5d, 0x20, 0x2f, 0x20, 0x74, 0x68,
+					0x69, 0x63, 0x6b, 0x6e, 0x65, 0x73, 0x73, 0x3b, 0x0d, 0x0a, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x6c, 0x61,
+					0x79, 0x6f, 0x75, 0x74, 0x2e, 0x70, 0x75, 0x73, 0x68, 0x28, 0x5b, 0x6e,
+					0x6f, 0x64, 0x65, 0x2c, 0x20, 0x77, 0x20, 0x3e, 0x3d, 0x20, 0x68, 0x20,
+					0x3f, 0x20, 0x7b, 0x20, 0x78, 0x2c, 0x20, 0x79, 0x3a, 0x20, 0x79, 0x20,
+					0x2b, 0x20, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x2c, 0x20, 0x77, 0x3a,
+					0x20, 0x74, 0x68, 0x69, 0x63, 0x6b, 0x6e, 0x65, 0x73, 0x73, 0x2c, 0x20,
+					0x68, 0x3a, 0x20, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x20, 0x7d, 0x20,
+					0x3a, 0x20, 0x7b, 0x20, 0x78, 0x3a, 0x20, 0x78, 0x20, 0x2b, 0x20, 0x6f,
+					0x66, 0x66, 0x73, 0x65, 0x74, 0x2c, 0x20, 0x79, 0x2c, 0x20, 0x77, 0x3a,
+					0x20, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x2c, 0x20, 0x68, 0x3a, 0x20,
+					0x74, 0x68, 0x69, 0x63, 0x6b, 0x6e, 0x65, 0x73, 0x73, 0x20, 0x7d, 0x5d,
+					0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74, 0x20, 0x2b,
+					0x3d, 0x20, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x3b, 0x0d, 0x0a, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x29, 0x3b, 0x0d, 0x0a,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x69, 0x66, 0x20, 0x28,
+					0x77, 0x20, 0x3e, 0x3d, 0x20, 0x68, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x78,
+					0x20, 0x2b, 0x3d, 0x20, 0x74, 0x68, 0x69, 0x63, 0x6b, 0x6e, 0x65, 0x73,
+					0x73, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x77, 0x20, 0x2d, 0x3d, 0x20, 0x74, 0x68, 0x69,
+					0x63, 0x6b, 0x6e, 0x65, 0x73, 0x73, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x20, 0x65, 0x6c, 0x73, 0x65, 0x20,
+					0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x79, 0x20, 0x2b, 0x3d, 0x20, 0x74, 0x68, 0x69, 0x63,
+					0x6b, 0x6e, 0x65, 0x73, 0x73, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x68, 0x20, 0x2d, 0x3d,
+					0x20, 0x74, 0x68, 0x69, 0x63, 0x6b, 0x6e, 0x65, 0x73, 0x73, 0x3b, 0x0d,
+					0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d, 0x0a,
+					0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x72,
+					0x65, 0x74, 0x75, 0x72, 0x6e, 0x20, 0x6c, 0x61, 0x79, 0x6f, 0x75, 0x74,
+					0x3b, 0x0d, 0x0a, 0x7d, 0x0d, 0x0a, 0x2f, 0x2a, 0x2a, 0x20, 0x54, 0x68,
+					0x65, 0x20, 0x70, 0x61, 0x74, 0x68, 0x20, 0x6f, 0x66, 0x20, 0x61, 0x20,
+					0x66, 0x69, 0x6c, 0x65, 0x20, 0x6f, 0x72, 0x20, 0x64, 0x69, 0x72, 0x65,
+					0x63, 0x74, 0x6f, 0x72, 0x79, 0x2c, 0x20, 0x77, 0x69, 0x74, 0x68, 0x6f,
+					0x75, 0x74, 0x20, 0x74, 0x68, 0x65, 0x20, 0x62, 0x75, 0x6e, 0x64, 0x6c,
+					0x65, 0x20, 0x74, 0x68, 0x61, 0x74, 0x20, 0x69, 0x74, 0x27, 0x73, 0x20,
+					0x69, 0x6e, 0x20, 0x2a, 0x2f, 0x0d, 0x0a, 0x66, 0x75, 0x6e, 0x63, 0x74,
+					0x69, 0x6f, 0x6e, 0x20, 0x72, 0x65, 0x6c, 0x61, 0x74, 0x69, 0x76, 0x65,
+					0x50, 0x61, 0x74, 0x68, 0x28, 0x6e, 0x6f, 0x64, 0x65, 0x29, 0x20, 0x7b,
+					0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x6c, 0x65, 0x74, 0x20, 0x62, 0x75,
+					0x6e, 0x64, 0x6c, 0x65, 0x4e, 0x6f, 0x64, 0x65, 0x20, 0x3d, 0x20, 0x6e,
+					0x6f, 0x64, 0x65, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x77, 0x68,
+					0x69, 0x6c, 0x65, 0x20, 0x28, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x4e,
+					0x6f, 0x64, 0x65, 0x2e, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x20, 0x26,
+					0x26, 0x20, 0x21, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x4e, 0x6f, 0x64,
+					0x65, 0x2e, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x29, 0x20, 0x7b, 0x0d,
+					0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x62, 0x75, 0x6e,
+					0x64, 0x6c, 0x65, 0x4e, 0x6f, 0x64, 0x65, 0x20, 0x3d, 0x20, 0x62, 0x75,
+					0x6e, 0x64, 0x6c, 0x65, 0x4e, 0x6f, 0x64, 0x65, 0x2e, 0x70, 0x61, 0x72,
+					0x65, 0x6e, 0x74, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d,
+					0x0a, 0x20, 0x20, 0x20, 0x20, 0x72, 0x65, 0x74, 0x75, 0x72, 0x6e, 0x20,
+					0x6e, 0x6f, 0x64, 0x65, 0x2e, 0x70, 0x61, 0x74, 0x68, 0x2e, 0x73, 0x75,
+					0x62, 0x73, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x28, 0x62, 0x75, 0x6e, 0x64,
+					0x6c, 0x65, 0x4e, 0x6f, 0x64, 0x65, 0x2e, 0x70, 0x61, 0x74, 0x68, 0x2e,
+					0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x20, 0x2b, 0x20, 0x31, 0x29, 0x3b,
+					0x0d, 0x0a, 0x7d, 0x0d, 0x0a, 0x66, 0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f,
+					0x6e, 0x20, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x65, 0x73, 0x28, 0x6e, 0x6f,
+					0x64, 0x65, 0x2c, 0x20, 0x71, 0x75, 0x65, 0x72, 0x79, 0x29, 0x20, 0x7b,
+					0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x69, 0x66, 0x20, 0x28, 0x6e, 0x6f,
+					0x64, 0x65, 0x2e, 0x63, 0x68, 0x69, 0x6c, 0x64, 0x72, 0x65, 0x6e, 0x2e,
+					0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x20, 0x3d, 0x3d, 0x3d, 0x20, 0x30,
+					0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x72, 0x65, 0x74, 0x75, 0x72, 0x6e, 0x20, 0x72, 0x65, 0x6c, 0x61,
+					0x74, 0x69, 0x76, 0x65, 0x50, 0x61, 0x74, 0x68, 0x28, 0x6e, 0x6f, 0x64,
+					0x65, 0x29, 0x2e, 0x74, 0x6f, 0x4c, 0x6f, 0x77, 0x65, 0x72, 0x43, 0x61,
+					0x73, 0x65, 0x28, 0x29, 0x2e, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x4f, 0x66,
+					0x28, 0x71, 0x75, 0x65, 0x72, 0x79, 0x29, 0x20, 0x3e, 0x3d, 0x20, 0x30,
+					0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20,
+					0x20, 0x20, 0x72, 0x65, 0x74, 0x75, 0x72, 0x6e, 0x20, 0x6e, 0x6f, 0x64,
+					0x65, 0x2e, 0x63, 0x68, 0x69, 0x6c, 0x64, 0x72, 0x65, 0x6e, 0x2e, 0x73,
+					0x6f, 0x6d, 0x65, 0x28, 0x63, 0x68, 0x69, 0x6c, 0x64, 0x20, 0x3d, 0x3e,
+					0x20, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x65, 0x73, 0x28, 0x63, 0x68, 0x69,
+					0x6c, 0x64, 0x2c, 0x20, 0x71, 0x75, 0x65, 0x72, 0x79, 0x29, 0x29, 0x3b,
+					0x0d, 0x0a, 0x7d, 0x0d, 0x0a, 0x66, 0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f,
+					0x6e, 0x20, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x28, 0x6e,
+					0x6f, 0x64, 0x65, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x69, 0x66, 0x20, 0x28, 0x6e, 0x6f, 0x64, 0x65, 0x2e, 0x62, 0x75, 0x6e,
+					0x64, 0x6c, 0x65, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x62, 0x75,
+					0x6e, 0x64, 0x6c, 0x65, 0x20, 0x3d, 0x20, 0x6e, 0x6f, 0x64, 0x65, 0x2e,
+					0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x72, 0x65, 0x74, 0x75, 0x72, 0x6e, 0x20,
+					0x60, 0x24, 0x7b, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x2e, 0x6d, 0x6f,
+					0x64, 0x75, 0x6c, 0x65, 0x7d, 0x20, 0x28, 0x24, 0x7b, 0x62, 0x75, 0x6e,
+					0x64, 0x6c, 0x65, 0x2e, 0x75, 0x72, 0x6c, 0x7d, 0x29, 0x3a, 0x20, 0x24,
+					0x7b, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x42, 0x79, 0x74, 0x65, 0x53,
+					0x69, 0x7a, 0x65, 0x28, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x2e, 0x72,
+					0x61, 0x77, 0x29, 0x7d, 0x2c, 0x20, 0x24, 0x7b, 0x66, 0x6f, 0x72, 0x6d,
+					0x61, 0x74, 0x42, 0x79, 0x74, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x28, 0x62,
+					0x75, 0x6e, 0x64, 0x6c, 0x65, 0x2e, 0x67, 0x7a, 0x69, 0x70, 0x29, 0x7d,
+					0x20, 0x67, 0x7a, 0x69, 0x70, 0x70, 0x65, 0x64, 0x60, 0x3b, 0x0d, 0x0a,
+					0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x72,
+					0x65, 0x74, 0x75, 0x72, 0x6e, 0x20, 0x60, 0x24, 0x7b, 0x72, 0x65, 0x6c,
+					0x61, 0x74, 0x69, 0x76, 0x65, 0x50, 0x61, 0x74, 0x68, 0x28, 0x6e, 0x6f,
+					0x64, 0x65, 0x29, 0x7d, 0x3a, 0x20, 0x24, 0x7b, 0x66, 0x6f, 0x72, 0x6d,
+					0x61, 0x74, 0x42, 0x79, 0x74, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x28, 0x6e,
+					0x6f, 0x64, 0x65, 0x2e, 0x73, 0x69, 0x7a, 0x65, 0x29, 0x7d, 0x60, 0x3b,
+					0x0d, 0x0a, 0x7d, 0x0d, 0x0a, 0x66, 0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f,
+					0x6e, 0x20, 0x72, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x42, 0x6f, 0x78, 0x28,
+					0x6e, 0x6f, 0x64, 0x65, 0x2c, 0x20, 0x72, 0x65, 0x63, 0x74, 0x2c, 0x20,
+					0x64, 0x65, 0x70, 0x74, 0x68, 0x2c, 0x20, 0x63, 0x6f, 0x6e, 0x74, 0x61,
+					0x69, 0x6e, 0x65, 0x72, 0x2c, 0x20, 0x71, 0x75, 0x65, 0x72, 0x79, 0x29,
+					0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e, 0x73,
+					0x74, 0x20, 0x62, 0x6f, 0x78, 0x20, 0x3d, 0x20, 0x64, 0x6f, 0x63, 0x75,
+					0x6d, 0x65, 0x6e, 0x74, 0x2e, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x45,
+					0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x28, 0x22, 0x64, 0x69, 0x76, 0x22,
+					0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x62, 0x6f, 0x78, 0x2e,
+					0x63, 0x6c, 0x61, 0x73, 0x73, 0x4e, 0x61, 0x6d, 0x65, 0x20, 0x3d, 0x20,
+					0x22, 0x62, 0x6f, 0x78, 0x22, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x62, 0x6f, 0x78, 0x2e, 0x73, 0x74, 0x79, 0x6c, 0x65, 0x2e, 0x6c, 0x65,
+					0x66, 0x74, 0x20, 0x3d, 0x20, 0x72, 0x65, 0x63, 0x74, 0x2e, 0x78, 0x20,
+					0x2b, 0x20, 0x22, 0x70, 0x78, 0x22, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x62, 0x6f, 0x78, 0x2e, 0x73, 0x74, 0x79, 0x6c, 0x65, 0x2e, 0x74,
+					0x6f, 0x70, 0x20, 0x3d, 0x20, 0x72, 0x65, 0x63, 0x74, 0x2e, 0x79, 0x20,
+					0x2b, 0x20, 0x22, 0x70, 0x78, 0x22, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x62, 0x6f, 0x78, 0x2e, 0x73, 0x74, 0x79, 0x6c, 0x65, 0x2e, 0x77,
+					0x69, 0x64, 0x74, 0x68, 0x20, 0x3d, 0x20, 0x72, 0x65, 0x63, 0x74, 0x2e,
+					0x77, 0x20, 0x2b, 0x20, 0x22, 0x70, 0x78, 0x22, 0x3b, 0x0d, 0x0a, 0x20,
+					0x20, 0x20, 0x20, 0x62, 0x6f, 0x78, 0x2e, 0x73, 0x74, 0x79, 0x6c, 0x65,
+					0x2e, 0x68, 0x65, 0x69, 0x67, 0x68, 0x74, 0x20, 0x3d, 0x20, 0x72, 0x65,
+					0x63, 0x74, 0x2e, 0x68, 0x20, 0x2b, 0x20, 0x22, 0x70, 0x78, 0x22, 0x3b,
+					0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x62, 0x6f, 0x78, 0x2e, 0x73, 0x74,
+					0x79, 0x6c, 0x65, 0x2e, 0x62, 0x61, 0x63, 0x6b, 0x67, 0x72, 0x6f, 0x75,
+					0x6e, 0x64, 0x20, 0x3d, 0x20, 0x60, 0x68, 0x73, 0x6c, 0x28, 0x24, 0x7b,
+					0x6e, 0x6f, 0x64, 0x65, 0x2e, 0x68, 0x75, 0x65, 0x7d, 0x2c, 0x20, 0x36,
+					0x30, 0x25, 0x2c, 0x20, 0x24, 0x7b, 0x4d, 0x61, 0x74, 0x68, 0x2e, 0x6d,
+					0x69, 0x6e, 0x28, 0x39, 0x30, 0x2c, 0x20, 0x35, 0x35, 0x20, 0x2b, 0x20,
+					0x64, 0x65, 0x70, 0x74, 0x68, 0x20, 0x2a, 0x20, 0x38, 0x29, 0x7d, 0x25,
+					0x29, 0x60, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x62, 0x6f, 0x78,
+					0x2e, 0x74, 0x69, 0x74, 0x6c, 0x65, 0x20, 0x3d, 0x20, 0x64, 0x65, 0x73,
+					0x63, 0x72, 0x69, 0x62, 0x65, 0x28, 0x6e, 0x6f, 0x64, 0x65, 0x29, 0x3b,
+					0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x69, 0x66, 0x20, 0x28, 0x71, 0x75,
+					0x65, 0x72, 0x79, 0x20, 0x26, 0x26, 0x20, 0x21, 0x6d, 0x61, 0x74, 0x63,
+					0x68, 0x65, 0x73, 0x28, 0x6e, 0x6f, 0x64, 0x65, 0x2c, 0x20, 0x71, 0x75,
+					0x65, 0x72, 0x79, 0x29, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x62, 0x6f, 0x78, 0x2e, 0x63, 0x6c, 0x61,
+					0x73, 0x73, 0x4c, 0x69, 0x73, 0x74, 0x2e, 0x61, 0x64, 0x64, 0x28, 0x22,
+					0x64, 0x69, 0x6d, 0x6d, 0x65, 0x64, 0x22, 0x29, 0x3b, 0x0d, 0x0a, 0x20,
+					0x20, 0x20, 0x20, 0x7d, 0x20, 0x65, 0x6c, 0x73, 0x65, 0x20, 0x69, 0x66,
+					0x20, 0x28, 0x71, 0x75, 0x65, 0x72, 0x79, 0x20, 0x26, 0x26, 0x20, 0x6e,
+					0x6f, 0x64, 0x65, 0x2e, 0x63, 0x68, 0x69, 0x6c, 0x64, 0x72, 0x65, 0x6e,
+					0x2e, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x20, 0x3d, 0x3d, 0x3d, 0x20,
+					0x30, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x62, 0x6f, 0x78, 0x2e, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x4c,
+					0x69, 0x73, 0x74, 0x2e, 0x61, 0x64, 0x64, 0x28, 0x22, 0x6d, 0x61, 0x74,
+					0x63, 0x68, 0x22, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x7d,
+					0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x62, 0x6f, 0x78, 0x2e, 0x61, 0x64,
+					0x64, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e,
+					0x65, 0x72, 0x28, 0x22, 0x63, 0x6c, 0x69, 0x63, 0x6b, 0x22, 0x2c, 0x20,
+					0x65, 0x20, 0x3d, 0x3e, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x65, 0x2e, 0x73, 0x74, 0x6f, 0x70, 0x50, 0x72,
+					0x6f, 0x70, 0x61, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x28, 0x29, 0x3b,
+					0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x7a, 0x6f,
+					0x6f, 0x6d, 0x28, 0x6e, 0x6f, 0x64, 0x65, 0x2e, 0x63, 0x68, 0x69, 0x6c,
+					0x64, 0x72, 0x65, 0x6e, 0x2e, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x20,
+					0x3e, 0x20, 0x30, 0x20, 0x3f, 0x20, 0x6e, 0x6f, 0x64, 0x65, 0x20, 0x3a,
+					0x20, 0x6e, 0x6f, 0x64, 0x65, 0x2e, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74,
+					0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x29, 0x3b, 0x0d,
+					0x0a, 0x20, 0x20, 0x20, 0x20, 0x69, 0x66, 0x20, 0x28, 0x72, 0x65, 0x63,
+					0x74, 0x2e, 0x77, 0x20, 0x3e, 0x3d, 0x20, 0x6d, 0x69, 0x6e, 0x53, 0x69,
+					0x64, 0x65, 0x20, 0x26, 0x26, 0x20, 0x72, 0x65, 0x63, 0x74, 0x2e, 0x68,
+					0x20, 0x3e, 0x3d, 0x20, 0x6d, 0x69, 0x6e, 0x53, 0x69, 0x64, 0x65, 0x29,
+					0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x20,
+					0x3d, 0x20, 0x64, 0x6f, 0x63, 0x75, 0x6d, 0x65, 0x6e, 0x74, 0x2e, 0x63,
+					0x72, 0x65, 0x61, 0x74, 0x65, 0x45, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74,
+					0x28, 0x22, 0x64, 0x69, 0x76, 0x22, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x2e,
+					0x63, 0x6c, 0x61, 0x73, 0x73, 0x4e, 0x61, 0x6d, 0x65, 0x20, 0x3d, 0x20,
+					0x22, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x22, 0x3b, 0x0d, 0x0a, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x2e,
+					0x74, 0x65, 0x78, 0x74, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x20,
+					0x3d, 0x20, 0x60, 0x24, 0x7b, 0x6e, 0x6f, 0x64, 0x65, 0x2e, 0x6e, 0x61,
+					0x6d, 0x65, 0x7d, 0x20, 0x24, 0x7b, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74,
+					0x42, 0x79, 0x74, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x28, 0x6e, 0x6f, 0x64,
+					0x65, 0x2e, 0x73, 0x69, 0x7a, 0x65, 0x29, 0x7d, 0x60, 0x3b, 0x0d, 0x0a,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x62, 0x6f, 0x78, 0x2e,
+					0x61, 0x70, 0x70, 0x65, 0x6e, 0x64, 0x43, 0x68, 0x69, 0x6c, 0x64, 0x28,
+					0x6c, 0x61, 0x62, 0x65, 0x6c, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x69,
+					0x6e, 0x6e, 0x65, 0x72, 0x20, 0x3d, 0x20, 0x7b, 0x20, 0x78, 0x3a, 0x20,
+					0x32, 0x2c, 0x20, 0x79, 0x3a, 0x20, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x48,
+					0x65, 0x69, 0x67, 0x68, 0x74, 0x2c, 0x20, 0x77, 0x3a, 0x20, 0x72, 0x65,
+					0x63, 0x74, 0x2e, 0x77, 0x20, 0x2d, 0x20, 0x36, 0x2c, 0x20, 0x68, 0x3a,
+					0x20, 0x72, 0x65, 0x63, 0x74, 0x2e, 0x68, 0x20, 0x2d, 0x20, 0x6c, 0x61,
+					0x62, 0x65, 0x6c, 0x48, 0x65, 0x69, 0x67, 0x68, 0x74, 0x20, 0x2d, 0x20,
+					0x34, 0x20, 0x7d, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x69, 0x66, 0x20, 0x28, 0x64, 0x65, 0x70, 0x74, 0x68, 0x20,
+					0x3c, 0x20, 0x6d, 0x61, 0x78, 0x44, 0x65, 0x70, 0x74, 0x68, 0x20, 0x26,
+					0x26, 0x20, 0x6e, 0x6f, 0x64, 0x65, 0x2e, 0x63, 0x68, 0x69, 0x6c, 0x64,
+					0x72, 0x65, 0x6e, 0x2e, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x20, 0x3e,
+					0x20, 0x30, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x73, 0x71, 0x75, 0x61, 0x72,
+					0x69, 0x66, 0x79, 0x28, 0x6e, 0x6f, 0x64, 0x65, 0x2e, 0x63, 0x68, 0x69,
+					0x6c, 0x64, 0x72, 0x65, 0x6e, 0x2c, 0x20, 0x69, 0x6e, 0x6e, 0x65, 0x72,
+					0x29, 0x2e, 0x66, 0x6f, 0x72, 0x45, 0x61, 0x63, 0x68, 0x28, 0x28, 0x5b,
+					0x63, 0x68, 0x69, 0x6c, 0x64, 0x2c, 0x20, 0x63, 0x68, 0x69, 0x6c, 0x64,
+					0x52, 0x65, 0x63, 0x74, 0x5d, 0x29, 0x20, 0x3d, 0x3e, 0x20, 0x72, 0x65,
+					0x6e, 0x64, 0x65, 0x72, 0x42, 0x6f, 0x78, 0x28, 0x63, 0x68, 0x69, 0x6c,
+					0x64, 0x2c, 0x20, 0x63, 0x68, 0x69, 0x6c, 0x64, 0x52, 0x65, 0x63, 0x74,
+					0x2c, 0x20, 0x64, 0x65, 0x70, 0x74, 0x68, 0x20, 0x2b, 0x20, 0x31, 0x2c,
+					0x20, 0x62, 0x6f, 0x78, 0x2c, 0x20, 0x71, 0x75, 0x65, 0x72, 0x79, 0x29,
+					0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20,
+					0x20, 0x20, 0x63, 0x6f, 0x6e, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x72, 0x2e,
+					0x61, 0x70, 0x70, 0x65, 0x6e, 0x64, 0x43, 0x68, 0x69, 0x6c, 0x64, 0x28,
+					0x62, 0x6f, 0x78, 0x29, 0x3b, 0x0d, 0x0a, 0x7d, 0x0d, 0x0a, 0x66, 0x75,
+					0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x20, 0x72, 0x65, 0x6e, 0x64, 0x65,
+					0x72, 0x54, 0x72, 0x65, 0x65, 0x6d, 0x61, 0x70, 0x28, 0x29, 0x20, 0x7b,
+					0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x74, 0x72, 0x65, 0x65, 0x6d, 0x61,
+					0x70, 0x2e, 0x69, 0x6e, 0x6e, 0x65, 0x72, 0x48, 0x54, 0x4d, 0x4c, 0x20,
+					0x3d, 0x20, 0x22, 0x22, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x69,
+					0x66, 0x20, 0x28, 0x21, 0x7a, 0x6f, 0x6f, 0x6d, 0x65, 0x64, 0x29, 0x20,
+					0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x72,
+					0x65, 0x74, 0x75, 0x72, 0x6e, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e, 0x73, 0x74,
+					0x20, 0x71, 0x75, 0x65, 0x72, 0x79, 0x20, 0x3d, 0x20, 0x73, 0x65, 0x61,
+					0x72, 0x63, 0x68, 0x2e, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x2e, 0x74, 0x72,
+					0x69, 0x6d, 0x28, 0x29, 0x2e, 0x74, 0x6f, 0x4c, 0x6f, 0x77, 0x65, 0x72,
+					0x43, 0x61, 0x73, 0x65, 0x28, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x72, 0x65, 0x63, 0x74, 0x20,
+					0x3d, 0x20, 0x7b, 0x20, 0x78, 0x3a, 0x20, 0x30, 0x2c, 0x20, 0x79, 0x3a,
+					0x20, 0x30, 0x2c, 0x20, 0x77, 0x3a, 0x20, 0x74, 0x72, 0x65, 0x65, 0x6d,
+					0x61, 0x70, 0x2e, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x57, 0x69, 0x64,
+					0x74, 0x68, 0x2c, 0x20, 0x68, 0x3a, 0x20, 0x74, 0x72, 0x65, 0x65, 0x6d,
+					0x61, 0x70, 0x2e, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x48, 0x65, 0x69,
+					0x67, 0x68, 0x74, 0x20, 0x7d, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x73, 0x71, 0x75, 0x61, 0x72, 0x69, 0x66, 0x79, 0x28, 0x7a, 0x6f, 0x6f,
+					0x6d, 0x65, 0x64, 0x2e, 0x63, 0x68, 0x69, 0x6c, 0x64, 0x72, 0x65, 0x6e,
+					0x2c, 0x20, 0x72, 0x65, 0x63, 0x74, 0x29, 0x2e, 0x66, 0x6f, 0x72, 0x45,
+					0x61, 0x63, 0x68, 0x28, 0x28, 0x5b, 0x6e, 0x6f, 0x64, 0x65, 0x2c, 0x20,
+					0x6e, 0x6f, 0x64, 0x65, 0x52, 0x65, 0x63, 0x74, 0x5d, 0x29, 0x20, 0x3d,
+					0x3e, 0x20, 0x72, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x42, 0x6f, 0x78, 0x28,
+					0x6e, 0x6f, 0x64, 0x65, 0x2c, 0x20, 0x6e, 0x6f, 0x64, 0x65, 0x52, 0x65,
+					0x63, 0x74, 0x2c, 0x20, 0x30, 0x2c, 0x20, 0x74, 0x72, 0x65, 0x65, 0x6d,
+					0x61, 0x70, 0x2c, 0x20, 0x71, 0x75, 0x65, 0x72, 0x79, 0x29, 0x29, 0x3b,
+					0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x69, 0x66, 0x20, 0x28, 0x71, 0x75,
+					0x65, 0x72, 0x79, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x6d, 0x61,
+					0x74, 0x63, 0x68, 0x69, 0x6e, 0x67, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x20,
+					0x3d, 0x20, 0x5b, 0x5d, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x63, 0x6f, 0x6c,
+					0x6c, 0x65, 0x63, 0x74, 0x20, 0x3d, 0x20, 0x28, 0x6e, 0x6f, 0x64, 0x65,
+					0x29, 0x20, 0x3d, 0x3e, 0x20, 0x6e, 0x6f, 0x64, 0x65, 0x2e, 0x63, 0x68,
+					0x69, 0x6c, 0x64, 0x72, 0x65, 0x6e, 0x2e, 0x6c, 0x65, 0x6e, 0x67, 0x74,
+					0x68, 0x20, 0x3d, 0x3d, 0x3d, 0x20, 0x30, 0x20, 0x3f, 0x20, 0x6d, 0x61,
+					0x74, 0x63, 0x68, 0x65, 0x73, 0x28, 0x6e, 0x6f, 0x64, 0x65, 0x2c, 0x20,
+					0x71, 0x75, 0x65, 0x72, 0x79, 0x29, 0x20, 0x26, 0x26, 0x20, 0x6d, 0x61,
+					0x74, 0x63, 0x68, 0x69, 0x6e, 0x67, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x2e,
+					0x70, 0x75, 0x73, 0x68, 0x28, 0x6e, 0x6f, 0x64, 0x65, 0x29, 0x20, 0x3a,
+					0x20, 0x6e, 0x6f, 0x64, 0x65, 0x2e, 0x63, 0x68, 0x69, 0x6c, 0x64, 0x72,
+					0x65, 0x6e, 0x2e, 0x66, 0x6f, 0x72, 0x45, 0x61, 0x63, 0x68, 0x28, 0x63,
+					0x6f, 0x6c, 0x6c, 0x65, 0x63, 0x74, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6c, 0x6c, 0x65, 0x63,
+					0x74, 0x28, 0x7a, 0x6f, 0x6f, 0x6d, 0x65, 0x64, 0x29, 0x3b, 0x0d, 0x0a,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e, 0x73,
+					0x74, 0x20, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x20, 0x3d, 0x20, 0x6d, 0x61,
+					0x74, 0x63, 0x68, 0x69, 0x6e, 0x67, 0x46, 0x69, 0x6c, 0x65, 0x73, 0x2e,
+					0x72, 0x65, 0x64, 0x75, 0x63, 0x65, 0x28, 0x28, 0x73, 0x75, 0x6d, 0x2c,
+					0x20, 0x6e, 0x6f, 0x64, 0x65, 0x29, 0x20, 0x3d, 0x3e, 0x20, 0x73, 0x75,
+					0x6d, 0x20, 0x2b, 0x20, 0x6e, 0x6f, 0x64, 0x65, 0x2e, 0x73, 0x69, 0x7a,
+					0x65, 0x2c, 0x20, 0x30, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x2e, 0x74,
+					0x65, 0x78, 0x74, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x20, 0x3d,
+					0x20, 0x60, 0x24, 0x7b, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x69, 0x6e, 0x67,
+					0x46, 0x69, 0x6c, 0x65, 0x73, 0x2e, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68,
+					0x7d, 0x20, 0x6d, 0x61, 0x74, 0x63, 0x68, 0x69, 0x6e, 0x67, 0x20, 0x66,
+					0x69, 0x6c, 0x65, 0x73, 0x2c, 0x20, 0x24, 0x7b, 0x66, 0x6f, 0x72, 0x6d,
+					0x61, 0x74, 0x42, 0x79, 0x74, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x28, 0x74,
+					0x6f, 0x74, 0x61, 0x6c, 0x29, 0x7d, 0x60, 0x3b, 0x0d, 0x0a, 0x20, 0x20,
+					0x20, 0x20, 0x7d, 0x20, 0x65, 0x6c, 0x73, 0x65, 0x20, 0x7b, 0x0d, 0x0a,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x73, 0x74, 0x61, 0x74,
+					0x75, 0x73, 0x2e, 0x74, 0x65, 0x78, 0x74, 0x43, 0x6f, 0x6e, 0x74, 0x65,
+					0x6e, 0x74, 0x20, 0x3d, 0x20, 0x60, 0x24, 0x7b, 0x72, 0x6f, 0x6f, 0x74,
+					0x2e, 0x63, 0x68, 0x69, 0x6c, 0x64, 0x72, 0x65, 0x6e, 0x2e, 0x6c, 0x65,
+					0x6e, 0x67, 0x74, 0x68, 0x7d, 0x20, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65,
+					0x73, 0x2c, 0x20, 0x24, 0x7b, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x42,
+					0x79, 0x74, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x28, 0x72, 0x6f, 0x6f, 0x74,
+					0x2e, 0x73, 0x69, 0x7a, 0x65, 0x29, 0x7d, 0x20, 0x6f, 0x66, 0x20, 0x73,
+					0x6f, 0x75, 0x72, 0x63, 0x65, 0x60, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x7d, 0x0d, 0x0a, 0x7d, 0x0d, 0x0a, 0x66, 0x75, 0x6e, 0x63, 0x74,
+					0x69, 0x6f, 0x6e, 0x20, 0x72, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x42, 0x72,
+					0x65, 0x61, 0x64, 0x63, 0x72, 0x75, 0x6d, 0x62, 0x73, 0x28, 0x29, 0x20,
+					0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x62, 0x72, 0x65, 0x61, 0x64,
+					0x63, 0x72, 0x75, 0x6d, 0x62, 0x73, 0x2e, 0x69, 0x6e, 0x6e, 0x65, 0x72,
+					0x48, 0x54, 0x4d, 0x4c, 0x20, 0x3d, 0x20, 0x22, 0x22, 0x3b, 0x0d, 0x0a,
+					0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x74, 0x72,
+					0x61, 0x69, 0x6c, 0x20, 0x3d, 0x20, 0x5b, 0x5d, 0x3b, 0x0d, 0x0a, 0x20,
+					0x20, 0x20, 0x20, 0x66, 0x6f, 0x72, 0x20, 0x28, 0x6c, 0x65, 0x74, 0x20,
+					0x6e, 0x6f, 0x64, 0x65, 0x20, 0x3d, 0x20, 0x7a, 0x6f, 0x6f, 0x6d, 0x65,
+					0x64, 0x3b, 0x20, 0x6e, 0x6f, 0x64, 0x65, 0x3b, 0x20, 0x6e, 0x6f, 0x64,
+					0x65, 0x20, 0x3d, 0x20, 0x6e, 0x6f, 0x64, 0x65, 0x2e, 0x70, 0x61, 0x72,
+					0x65, 0x6e, 0x74, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x74, 0x72, 0x61, 0x69, 0x6c, 0x2e, 0x75, 0x6e,
+					0x73, 0x68, 0x69, 0x66, 0x74, 0x28, 0x6e, 0x6f, 0x64, 0x65, 0x29, 0x3b,
+					0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x74, 0x72, 0x61, 0x69, 0x6c, 0x2e, 0x66, 0x6f, 0x72, 0x45, 0x61,
+					0x63, 0x68, 0x28, 0x28, 0x6e, 0x6f, 0x64, 0x65, 0x2c, 0x20, 0x69, 0x29,
+					0x20, 0x3d, 0x3e, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x69, 0x66, 0x20, 0x28, 0x69, 0x20, 0x3e, 0x20, 0x30,
+					0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x62, 0x72, 0x65, 0x61, 0x64, 0x63, 0x72,
+					0x75, 0x6d, 0x62, 0x73, 0x2e, 0x61, 0x70, 0x70, 0x65, 0x6e, 0x64, 0x43,
+					0x68, 0x69, 0x6c, 0x64, 0x28, 0x64, 0x6f, 0x63, 0x75, 0x6d, 0x65, 0x6e,
+					0x74, 0x2e, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x54, 0x65, 0x78, 0x74,
+					0x4e, 0x6f, 0x64, 0x65, 0x28, 0x22, 0x20, 0x2f, 0x20, 0x22, 0x29, 0x29,
+					0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x7d,
+					0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f,
+					0x6e, 0x73, 0x74, 0x20, 0x6c, 0x69, 0x6e, 0x6b, 0x20, 0x3d, 0x20, 0x64,
+					0x6f, 0x63, 0x75, 0x6d, 0x65, 0x6e, 0x74, 0x2e, 0x63, 0x72, 0x65, 0x61,
+					0x74, 0x65, 0x45, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x28, 0x22, 0x61,
+					0x22, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x74, 0x65, 0x78, 0x74, 0x43, 0x6f,
+					0x6e, 0x74, 0x65, 0x6e, 0x74, 0x20, 0x3d, 0x20, 0x6e, 0x6f, 0x64, 0x65,
+					0x2e, 0x6e, 0x61, 0x6d, 0x65, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x61, 0x64, 0x64,
+					0x45, 0x76, 0x65, 0x6e, 0x74, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65,
+					0x72, 0x28, 0x22, 0x63, 0x6c, 0x69, 0x63, 0x6b, 0x22, 0x2c, 0x20, 0x28,
+					0x29, 0x20, 0x3d, 0x3e, 0x20, 0x7a, 0x6f, 0x6f, 0x6d, 0x28, 0x6e, 0x6f,
+					0x64, 0x65, 0x29, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x62, 0x72, 0x65, 0x61, 0x64, 0x63, 0x72, 0x75, 0x6d,
+					0x62, 0x73, 0x2e, 0x61, 0x70, 0x70, 0x65, 0x6e, 0x64, 0x43, 0x68, 0x69,
+					0x6c, 0x64, 0x28, 0x6c, 0x69, 0x6e, 0x6b, 0x29, 0x3b, 0x0d, 0x0a, 0x20,
+					0x20, 0x20, 0x20, 0x7d, 0x29, 0x3b, 0x0d, 0x0a, 0x7d, 0x0d, 0x0a, 0x66,
+					0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x20, 0x72, 0x65, 0x6e, 0x64,
+					0x65, 0x72, 0x42, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x4c, 0x69, 0x73, 0x74,
+					0x28, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x62, 0x75,
+					0x6e, 0x64, 0x6c, 0x65, 0x4c, 0x69, 0x73, 0x74, 0x2e, 0x69, 0x6e, 0x6e,
+					0x65, 0x72, 0x48, 0x54, 0x4d, 0x4c, 0x20, 0x3d, 0x20, 0x22, 0x22, 0x3b,
+					0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x72, 0x6f, 0x6f, 0x74, 0x2e, 0x63,
+					0x68, 0x69, 0x6c, 0x64, 0x72, 0x65, 0x6e, 0x2e, 0x66, 0x6f, 0x72, 0x45,
+					0x61, 0x63, 0x68, 0x28, 0x6e, 0x6f, 0x64, 0x65, 0x20, 0x3d, 0x3e, 0x20,
+					0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x63,
+					0x6f, 0x6e, 0x73, 0x74, 0x20, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x20,
+					0x3d, 0x20, 0x6e, 0x6f, 0x64, 0x65, 0x2e, 0x62, 0x75, 0x6e, 0x64, 0x6c,
+					0x65, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x69, 0x74, 0x65, 0x6d, 0x20, 0x3d,
+					0x20, 0x64, 0x6f, 0x63, 0x75, 0x6d, 0x65, 0x6e, 0x74, 0x2e, 0x63, 0x72,
+					0x65, 0x61, 0x74, 0x65, 0x45, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x28,
+					0x22, 0x64, 0x69, 0x76, 0x22, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x69, 0x74, 0x65, 0x6d, 0x2e, 0x63, 0x6c,
+					0x61, 0x73, 0x73, 0x4e, 0x61, 0x6d, 0x65, 0x20, 0x3d, 0x20, 0x22, 0x62,
+					0x75, 0x6e, 0x64, 0x6c, 0x65, 0x22, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x6e,
+					0x61, 0x6d, 0x65, 0x20, 0x3d, 0x20, 0x64, 0x6f, 0x63, 0x75, 0x6d, 0x65,
+					0x6e, 0x74, 0x2e, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x45, 0x6c, 0x65,
+					0x6d, 0x65, 0x6e, 0x74, 0x28, 0x22, 0x64, 0x69, 0x76, 0x22, 0x29, 0x3b,
+					0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x6e, 0x61,
+					0x6d, 0x65, 0x2e, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x4e, 0x61, 0x6d, 0x65,
+					0x20, 0x3d, 0x20, 0x22, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x3b, 0x0d, 0x0a,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x6e, 0x61, 0x6d, 0x65,
+					0x2e, 0x74, 0x65, 0x78, 0x74, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74,
+					0x20, 0x3d, 0x20, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x2e, 0x6d, 0x6f,
+					0x64, 0x75, 0x6c, 0x65, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x6e, 0x61, 0x6d, 0x65, 0x2e, 0x61, 0x64, 0x64, 0x45,
+					0x76, 0x65, 0x6e, 0x74, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72,
+					0x28, 0x22, 0x63, 0x6c, 0x69, 0x63, 0x6b, 0x22, 0x2c, 0x20, 0x28, 0x29,
+					0x20, 0x3d, 0x3e, 0x20, 0x7a, 0x6f, 0x6f, 0x6d, 0x28, 0x6e, 0x6f, 0x64,
+					0x65, 0x29, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x69, 0x74, 0x65, 0x6d, 0x2e, 0x61, 0x70, 0x70, 0x65, 0x6e,
+					0x64, 0x43, 0x68, 0x69, 0x6c, 0x64, 0x28, 0x6e, 0x61, 0x6d, 0x65, 0x29,
+					0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x63,
+					0x6f, 0x6e, 0x73, 0x74, 0x20, 0x73, 0x69, 0x7a, 0x65, 0x20, 0x3d, 0x20,
+					0x64, 0x6f, 0x63, 0x75, 0x6d, 0x65, 0x6e, 0x74, 0x2e, 0x63, 0x72, 0x65,
+					0x61, 0x74, 0x65, 0x45, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x28, 0x22,
+					0x64, 0x69, 0x76, 0x22, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x73, 0x69, 0x7a, 0x65, 0x2e, 0x74, 0x65, 0x78,
+					0x74, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x20, 0x3d, 0x20, 0x60,
+					0x24, 0x7b, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x42, 0x79, 0x74, 0x65,
+					0x53, 0x69, 0x7a, 0x65, 0x28, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x2e,
+					0x72, 0x61, 0x77, 0x29, 0x7d, 0x2c, 0x20, 0x24, 0x7b, 0x66, 0x6f, 0x72,
+					0x6d, 0x61, 0x74, 0x42, 0x79, 0x74, 0x65, 0x53, 0x69, 0x7a, 0x65, 0x28,
+					0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x2e, 0x67, 0x7a, 0x69, 0x70, 0x29,
+					0x7d, 0x20, 0x67, 0x7a, 0x69, 0x70, 0x70, 0x65, 0x64, 0x2c, 0x20, 0x24,
+					0x7b, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x2e, 0x66, 0x69, 0x6c, 0x65,
+					0x73, 0x2e, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68, 0x7d, 0x20, 0x66, 0x69,
+					0x6c, 0x65, 0x73, 0x60, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x69, 0x66, 0x20, 0x28, 0x6f, 0x76, 0x65, 0x72, 0x42,
+					0x75, 0x64, 0x67, 0x65, 0x74, 0x28, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65,
+					0x29, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x73, 0x69, 0x7a, 0x65, 0x2e, 0x63,
+					0x6c, 0x61, 0x73, 0x73, 0x4e, 0x61, 0x6d, 0x65, 0x20, 0x3d, 0x20, 0x22,
+					0x6f, 0x76, 0x65, 0x72, 0x2d, 0x62, 0x75, 0x64, 0x67, 0x65, 0x74, 0x22,
+					0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x73, 0x69, 0x7a, 0x65, 0x2e, 0x74, 0x65, 0x78, 0x74,
+					0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x20, 0x2b, 0x3d, 0x20, 0x22,
+					0x20, 0x28, 0x6f, 0x76, 0x65, 0x72, 0x20, 0x62, 0x75, 0x64, 0x67, 0x65,
+					0x74, 0x29, 0x22, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x69, 0x74, 0x65, 0x6d, 0x2e, 0x61, 0x70, 0x70, 0x65, 0x6e, 0x64,
+					0x43, 0x68, 0x69, 0x6c, 0x64, 0x28, 0x73, 0x69, 0x7a, 0x65, 0x29, 0x3b,
+					0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x69, 0x66,
+					0x20, 0x28, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x2e, 0x65, 0x78, 0x63,
+					0x6c, 0x75, 0x64, 0x65, 0x73, 0x2e, 0x6c, 0x65, 0x6e, 0x67, 0x74, 0x68,
+					0x20, 0x3e, 0x20, 0x30, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e,
+					0x73, 0x74, 0x20, 0x65, 0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x73, 0x20,
+					0x3d, 0x20, 0x64, 0x6f, 0x63, 0x75, 0x6d, 0x65, 0x6e, 0x74, 0x2e, 0x63,
+					0x72, 0x65, 0x61, 0x74, 0x65, 0x45, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74,
+					0x28, 0x22, 0x64, 0x69, 0x76, 0x22, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x65, 0x78,
+					0x63, 0x6c, 0x75, 0x64, 0x65, 0x73, 0x2e, 0x63, 0x6c, 0x61, 0x73, 0x73,
+					0x4e, 0x61, 0x6d, 0x65, 0x20, 0x3d, 0x20, 0x22, 0x65, 0x78, 0x63, 0x6c,
+					0x75, 0x64, 0x65, 0x73, 0x22, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x65, 0x78, 0x63, 0x6c,
+					0x75, 0x64, 0x65, 0x73, 0x2e, 0x61, 0x70, 0x70, 0x65, 0x6e, 0x64, 0x43,
+					0x68, 0x69, 0x6c, 0x64, 0x28, 0x64, 0x6f, 0x63, 0x75, 0x6d, 0x65, 0x6e,
+					0x74, 0x2e, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x54, 0x65, 0x78, 0x74,
+					0x4e, 0x6f, 0x64, 0x65, 0x28, 0x22, 0x65, 0x78, 0x63, 0x6c, 0x75, 0x64,
+					0x65, 0x73, 0x20, 0x22, 0x29, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x62, 0x75, 0x6e,
+					0x64, 0x6c, 0x65, 0x2e, 0x65, 0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x73,
+					0x2e, 0x66, 0x6f, 0x72, 0x45, 0x61, 0x63, 0x68, 0x28, 0x28, 0x6d, 0x6f,
+					0x64, 0x75, 0x6c, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x2c, 0x20, 0x69, 0x29,
+					0x20, 0x3d, 0x3e, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x69,
+					0x66, 0x20, 0x28, 0x69, 0x20, 0x3e, 0x20, 0x30, 0x29, 0x20, 0x7b, 0x0d,
+					0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x65, 0x78, 0x63,
+					0x6c, 0x75, 0x64, 0x65, 0x73, 0x2e, 0x61, 0x70, 0x70, 0x65, 0x6e, 0x64,
+					0x43, 0x68, 0x69, 0x6c, 0x64, 0x28, 0x64, 0x6f, 0x63, 0x75, 0x6d, 0x65,
+					0x6e, 0x74, 0x2e, 0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x54, 0x65, 0x78,
+					0x74, 0x4e, 0x6f, 0x64, 0x65, 0x28, 0x22, 0x2c, 0x20, 0x22, 0x29, 0x29,
+					0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x6c, 0x69, 0x6e, 0x6b,
+					0x20, 0x3d, 0x20, 0x64, 0x6f, 0x63, 0x75, 0x6d, 0x65, 0x6e, 0x74, 0x2e,
+					0x63, 0x72, 0x65, 0x61, 0x74, 0x65, 0x45, 0x6c, 0x65, 0x6d, 0x65, 0x6e,
+					0x74, 0x28, 0x22, 0x61, 0x22, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x74, 0x65, 0x78, 0x74, 0x43, 0x6f,
+					0x6e, 0x74, 0x65, 0x6e, 0x74, 0x20, 0x3d, 0x20, 0x6d, 0x6f, 0x64, 0x75,
+					0x6c, 0x65, 0x4e, 0x61, 0x6d, 0x65, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x6c, 0x69, 0x6e, 0x6b, 0x2e, 0x61, 0x64, 0x64, 0x45, 0x76, 0x65,
+					0x6e, 0x74, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x28, 0x22,
+					0x63, 0x6c, 0x69, 0x63, 0x6b, 0x22, 0x2c, 0x20, 0x28, 0x29, 0x20, 0x3d,
+					0x3e, 0x20, 0x7a, 0x6f, 0x6f, 0x6d, 0x28, 0x66, 0x69, 0x6e, 0x64, 0x4e,
+					0x6f, 0x64, 0x65, 0x28, 0x72, 0x6f, 0x6f, 0x74, 0x2c, 0x20, 0x62, 0x75,
+					0x6e, 0x64, 0x6c, 0x65, 0x2e, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x20, 0x2b,
+					0x20, 0x22, 0x3a, 0x22, 0x20, 0x2b, 0x20, 0x6d, 0x6f, 0x64, 0x75, 0x6c,
+					0x65, 0x4e, 0x61, 0x6d, 0x65, 0x29, 0x29, 0x29, 0x3b, 0x0d, 0x0a, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x65, 0x78, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x73, 0x2e,
+					0x61, 0x70, 0x70, 0x65, 0x6e, 0x64, 0x43, 0x68, 0x69, 0x6c, 0x64, 0x28,
+					0x6c, 0x69, 0x6e, 0x6b, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x29, 0x3b, 0x0d,
+					0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x69, 0x74, 0x65, 0x6d, 0x2e, 0x61, 0x70, 0x70, 0x65, 0x6e, 0x64,
+					0x43, 0x68, 0x69, 0x6c, 0x64, 0x28, 0x65, 0x78, 0x63, 0x6c, 0x75, 0x64,
+					0x65, 0x73, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x4c, 0x69, 0x73, 0x74, 0x2e,
+					0x61, 0x70, 0x70, 0x65, 0x6e, 0x64, 0x43, 0x68, 0x69, 0x6c, 0x64, 0x28,
+					0x69, 0x74, 0x65, 0x6d, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x7d, 0x29, 0x3b, 0x0d, 0x0a, 0x7d, 0x0d, 0x0a, 0x66, 0x75, 0x6e, 0x63,
+					0x74, 0x69, 0x6f, 0x6e, 0x20, 0x7a, 0x6f, 0x6f, 0x6d, 0x28, 0x6e, 0x6f,
+					0x64, 0x65, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x69,
+					0x66, 0x20, 0x28, 0x21, 0x6e, 0x6f, 0x64, 0x65, 0x29, 0x20, 0x7b, 0x0d,
+					0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x72, 0x65, 0x74,
+					0x75, 0x72, 0x6e, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d,
+					0x0a, 0x20, 0x20, 0x20, 0x20, 0x7a, 0x6f, 0x6f, 0x6d, 0x65, 0x64, 0x20,
+					0x3d, 0x20, 0x6e, 0x6f, 0x64, 0x65, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x72, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x42, 0x72, 0x65, 0x61, 0x64,
+					0x63, 0x72, 0x75, 0x6d, 0x62, 0x73, 0x28, 0x29, 0x3b, 0x0d, 0x0a, 0x20,
+					0x20, 0x20, 0x20, 0x72, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x54, 0x72, 0x65,
+					0x65, 0x6d, 0x61, 0x70, 0x28, 0x29, 0x3b, 0x0d, 0x0a, 0x7d, 0x0d, 0x0a,
+					0x2f, 0x2a, 0x2a, 0x20, 0x46, 0x65, 0x74, 0x63, 0x68, 0x65, 0x73, 0x20,
+					0x74, 0x68, 0x65, 0x20, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x73, 0x2c,
+					0x20, 0x6b, 0x65, 0x65, 0x70, 0x69, 0x6e, 0x67, 0x20, 0x74, 0x68, 0x65,
+					0x20, 0x7a, 0x6f, 0x6f, 0x6d, 0x65, 0x64, 0x20, 0x62, 0x6f, 0x78, 0x20,
+					0x69, 0x66, 0x20, 0x69, 0x74, 0x20, 0x73, 0x74, 0x69, 0x6c, 0x6c, 0x20,
+					0x65, 0x78, 0x69, 0x73, 0x74, 0x73, 0x20, 0x61, 0x66, 0x74, 0x65, 0x72,
+					0x20, 0x61, 0x20, 0x72, 0x65, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x20, 0x2a,
+					0x2f, 0x0d, 0x0a, 0x66, 0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x20,
+					0x72, 0x65, 0x66, 0x72, 0x65, 0x73, 0x68, 0x28, 0x29, 0x20, 0x7b, 0x0d,
+					0x0a, 0x20, 0x20, 0x20, 0x20, 0x66, 0x65, 0x74, 0x63, 0x68, 0x28, 0x64,
+					0x61, 0x74, 0x61, 0x55, 0x52, 0x4c, 0x29, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x2e, 0x74, 0x68, 0x65, 0x6e, 0x28, 0x72,
+					0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x20, 0x3d, 0x3e, 0x20, 0x72,
+					0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2e, 0x6a, 0x73, 0x6f, 0x6e,
+					0x28, 0x29, 0x29, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x2e, 0x74, 0x68, 0x65, 0x6e, 0x28, 0x28, 0x64, 0x61, 0x74, 0x61,
+					0x29, 0x20, 0x3d, 0x3e, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e, 0x73,
+					0x74, 0x20, 0x7a, 0x6f, 0x6f, 0x6d, 0x65, 0x64, 0x50, 0x61, 0x74, 0x68,
+					0x20, 0x3d, 0x20, 0x7a, 0x6f, 0x6f, 0x6d, 0x65, 0x64, 0x20, 0x3f, 0x20,
+					0x7a, 0x6f, 0x6f, 0x6d, 0x65, 0x64, 0x2e, 0x70, 0x61, 0x74, 0x68, 0x20,
+					0x3a, 0x20, 0x22, 0x22, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x72, 0x6f, 0x6f, 0x74, 0x20,
+					0x3d, 0x20, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x54, 0x72, 0x65, 0x65, 0x28,
+					0x64, 0x61, 0x74, 0x61, 0x2e, 0x62, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x73,
+					0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x6c, 0x65, 0x74, 0x20, 0x6e, 0x6f, 0x64, 0x65,
+					0x20, 0x3d, 0x20, 0x6e, 0x75, 0x6c, 0x6c, 0x3b, 0x0d, 0x0a, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x66, 0x6f,
+					0x72, 0x20, 0x28, 0x6c, 0x65, 0x74, 0x20, 0x70, 0x61, 0x74, 0x68, 0x20,
+					0x3d, 0x20, 0x7a, 0x6f, 0x6f, 0x6d, 0x65, 0x64, 0x50, 0x61, 0x74, 0x68,
+					0x3b, 0x20, 0x21, 0x6e, 0x6f, 0x64, 0x65, 0x20, 0x26, 0x26, 0x20, 0x70,
+					0x61, 0x74, 0x68, 0x3b, 0x20, 0x70, 0x61, 0x74, 0x68, 0x20, 0x3d, 0x20,
+					0x70, 0x61, 0x74, 0x68, 0x2e, 0x73, 0x75, 0x62, 0x73, 0x74, 0x72, 0x69,
+					0x6e, 0x67, 0x28, 0x30, 0x2c, 0x20, 0x4d, 0x61, 0x74, 0x68, 0x2e, 0x6d,
+					0x61, 0x78, 0x28, 0x30, 0x2c, 0x20, 0x70, 0x61, 0x74, 0x68, 0x2e, 0x6c,
+					0x61, 0x73, 0x74, 0x49, 0x6e, 0x64, 0x65, 0x78, 0x4f, 0x66, 0x28, 0x22,
+					0x2f, 0x22, 0x29, 0x29, 0x29, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x6e, 0x6f, 0x64, 0x65, 0x20, 0x3d, 0x20, 0x66, 0x69, 0x6e,
+					0x64, 0x4e, 0x6f, 0x64, 0x65, 0x28, 0x72, 0x6f, 0x6f, 0x74, 0x2c, 0x20,
+					0x70, 0x61, 0x74, 0x68, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x72,
+					0x65, 0x6e, 0x64, 0x65, 0x72, 0x42, 0x75, 0x6e, 0x64, 0x6c, 0x65, 0x4c,
+					0x69, 0x73, 0x74, 0x28, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x7a, 0x6f, 0x6f, 0x6d,
+					0x28, 0x6e, 0x6f, 0x64, 0x65, 0x20, 0x7c, 0x7c, 0x20, 0x72, 0x6f, 0x6f,
+					0x74, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x69, 0x66, 0x20, 0x28, 0x64, 0x61, 0x74,
+					0x61, 0x2e, 0x6c, 0x69, 0x76, 0x65, 0x20, 0x26, 0x26, 0x20, 0x21, 0x73,
+					0x6f, 0x63, 0x6b, 0x65, 0x74, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x73, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x20, 0x3d, 0x20, 0x6e,
+					0x65, 0x77, 0x20, 0x53, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x43, 0x6c, 0x69,
+					0x65, 0x6e, 0x74, 0x28, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x73, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x2e, 0x6f, 0x6e, 0x28, 0x65, 0x20,
+					0x3d, 0x3e, 0x20, 0x65, 0x2e, 0x74, 0x79, 0x70, 0x65, 0x20, 0x3d, 0x3d,
+					0x20, 0x22, 0x61, 0x6e, 0x61, 0x6c, 0x79, 0x7a, 0x65, 0x22, 0x20, 0x26,
+					0x26, 0x20, 0x72, 0x65, 0x66, 0x72, 0x65, 0x73, 0x68, 0x28, 0x29, 0x29,
+					0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x73, 0x6f, 0x63, 0x6b, 0x65,
+					0x74, 0x2e, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x28, 0x29, 0x3b,
+					0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x7d, 0x29, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x2e, 0x63, 0x61, 0x74, 0x63, 0x68, 0x28, 0x65, 0x72, 0x72, 0x20,
+					0x3d, 0x3e, 0x20, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x2e, 0x74, 0x65,
+					0x78, 0x74, 0x43, 0x6f, 0x6e, 0x74, 0x65, 0x6e, 0x74, 0x20, 0x3d, 0x20,
+					0x22, 0x46, 0x61, 0x69, 0x6c, 0x65, 0x64, 0x20, 0x74, 0x6f, 0x20, 0x6c,
+					0x6f, 0x61, 0x64, 0x20, 0x22, 0x20, 0x2b, 0x20, 0x64, 0x61, 0x74, 0x61,
+					0x55, 0x52, 0x4c, 0x20, 0x2b, 0x20, 0x22, 0x3a, 0x20, 0x22, 0x20, 0x2b,
+					0x20, 0x65, 0x72, 0x72, 0x29, 0x3b, 0x0d, 0x0a, 0x7d, 0x0d, 0x0a, 0x73,
+					0x65, 0x61, 0x72, 0x63, 0x68, 0x2e, 0x61, 0x64, 0x64, 0x45, 0x76, 0x65,
+					0x6e, 0x74, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x28, 0x22,
+					0x69, 0x6e, 0x70, 0x75, 0x74, 0x22, 0x2c, 0x20, 0x28, 0x29, 0x20, 0x3d,
+					0x3e, 0x20, 0x72, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x54, 0x72, 0x65, 0x65,
+					0x6d, 0x61, 0x70, 0x28, 0x29, 0x29, 0x3b, 0x0d, 0x0a, 0x77, 0x69, 0x6e,
+					0x64, 0x6f, 0x77, 0x2e, 0x61, 0x64, 0x64, 0x45, 0x76, 0x65, 0x6e, 0x74,
+					0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x28, 0x22, 0x72, 0x65,
+					0x73, 0x69, 0x7a, 0x65, 0x22, 0x2c, 0x20, 0x28, 0x29, 0x20, 0x3d, 0x3e,
+					0x20, 0x72, 0x65, 0x6e, 0x64, 0x65, 0x72, 0x54, 0x72, 0x65, 0x65, 0x6d,
+					0x61, 0x70, 0x28, 0x29, 0x29, 0x3b, 0x0d, 0x0a, 0x72, 0x65, 0x66, 0x72,
+					0x65, 0x73, 0x68, 0x28, 0x29, 0x3b, 0x0d, 0x0a, 
+				},
+				fi: FileInfo{
+					name:    "Analyze.js",
+					size:    11372,
+					modTime: time.Unix(0, 1792431609314759951),
+					isDir:   false,
+				},
+			},"/assets/static/HotReload.js": File{
 				data: []byte{
 					0x69, 0x6d, 0x70, 0x6f, 0x72, 0x74, 0x20, 0x7b, 0x20, 0x53, 0x6f, 0x63,
 					0x6b, 0x65, 0x74, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x20, 0x7d, 0x20,
@@ -191,25 +1328,152 @@ func init() {
 					0x65, 0x73, 0x5b, 0x30, 0x5d, 0x2e, 0x74, 0x65, 0x78, 0x74, 0x43, 0x6f,
 					0x6e, 0x74, 0x65, 0x6e, 0x74, 0x20, 0x3d, 0x20, 0x63, 0x73, 0x73, 0x3b,
 					0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x7d, 0x0d, 0x0a,
-					0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x73, 0x63, 0x20, 0x3d, 0x20, 0x6e,
-					0x65, 0x77, 0x20, 0x53, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x43, 0x6c, 0x69,
-					0x65, 0x6e, 0x74, 0x28, 0x29, 0x3b, 0x0d, 0x0a, 0x73, 0x63, 0x2e, 0x6f,
-					0x6e, 0x28, 0x65, 0x20, 0x3d, 0x3e, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20,
-					0x20, 0x20, 0x65, 0x2e, 0x74, 0x79, 0x70, 0x65, 0x20, 0x3d, 0x3d, 0x20,
-					0x22, 0x72, 0x65, 0x6c, 0x6f, 0x61, 0x64, 0x2d, 0x63, 0x73, 0x73, 0x22,
-					0x20, 0x26, 0x26, 0x20, 0x72, 0x65, 0x6c, 0x6f, 0x61, 0x64, 0x43, 0x53,
-					0x53, 0x28, 0x65, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x65,
-					0x2e, 0x74, 0x79, 0x70, 0x65, 0x20, 0x3d, 0x3d, 0x20, 0x22, 0x72, 0x65,
-					0x6c, 0x6f, 0x61, 0x64, 0x22, 0x20, 0x26, 0x26, 0x20, 0x77, 0x69, 0x6e,
-					0x64, 0x6f, 0x77, 0x2e, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e,
-					0x2e, 0x72, 0x65, 0x6c, 0x6f, 0x61, 0x64, 0x28, 0x29, 0x3b, 0x0d, 0x0a,
-					0x7d, 0x29, 0x3b, 0x0d, 0x0a, 0x73, 0x63, 0x2e, 0x63, 0x6f, 0x6e, 0x6e,
-					0x65, 0x63, 0x74, 0x28, 0x29, 0x3b, 0x0d, 0x0a, 
+					0x66, 0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x20, 0x64, 0x65, 0x73,
+					0x63, 0x72, 0x69, 0x62, 0x65, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x28, 0x65,
+					0x72, 0x72, 0x6f, 0x72, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x73, 0x74, 0x61, 0x63, 0x6b,
+					0x20, 0x3d, 0x20, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x2e, 0x73, 0x74, 0x61,
+					0x63, 0x6b, 0x20, 0x3f, 0x20, 0x53, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x28,
+					0x65, 0x72, 0x72, 0x6f, 0x72, 0x2e, 0x73, 0x74, 0x61, 0x63, 0x6b, 0x29,
+					0x20, 0x3a, 0x20, 0x22, 0x22, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69,
+					0x70, 0x74, 0x69, 0x6f, 0x6e, 0x20, 0x3d, 0x20, 0x53, 0x74, 0x72, 0x69,
+					0x6e, 0x67, 0x28, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x29, 0x3b, 0x0d, 0x0a,
+					0x20, 0x20, 0x20, 0x20, 0x69, 0x66, 0x20, 0x28, 0x21, 0x73, 0x74, 0x61,
+					0x63, 0x6b, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x72, 0x65, 0x74, 0x75, 0x72, 0x6e, 0x20, 0x64, 0x65,
+					0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x3b, 0x0d, 0x0a,
+					0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x72,
+					0x65, 0x74, 0x75, 0x72, 0x6e, 0x20, 0x73, 0x74, 0x61, 0x63, 0x6b, 0x2e,
+					0x69, 0x6e, 0x64, 0x65, 0x78, 0x4f, 0x66, 0x28, 0x64, 0x65, 0x73, 0x63,
+					0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x29, 0x20, 0x3d, 0x3d, 0x3d,
+					0x20, 0x30, 0x20, 0x3f, 0x20, 0x73, 0x74, 0x61, 0x63, 0x6b, 0x20, 0x3a,
+					0x20, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e,
+					0x20, 0x2b, 0x20, 0x22, 0x5c, 0x6e, 0x22, 0x20, 0x2b, 0x20, 0x73, 0x74,
+					0x61, 0x63, 0x6b, 0x3b, 0x0d, 0x0a, 0x7d, 0x0d, 0x0a, 0x66, 0x75, 0x6e,
+					0x63, 0x74, 0x69, 0x6f, 0x6e, 0x20, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69,
+					0x62, 0x65, 0x28, 0x61, 0x72, 0x67, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20,
+					0x20, 0x20, 0x20, 0x69, 0x66, 0x20, 0x28, 0x61, 0x72, 0x67, 0x20, 0x69,
+					0x6e, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65, 0x6f, 0x66, 0x20, 0x45, 0x72,
+					0x72, 0x6f, 0x72, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x72, 0x65, 0x74, 0x75, 0x72, 0x6e, 0x20, 0x64,
+					0x65, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x45, 0x72, 0x72, 0x6f, 0x72,
+					0x28, 0x61, 0x72, 0x67, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x69, 0x66, 0x20, 0x28, 0x74,
+					0x79, 0x70, 0x65, 0x6f, 0x66, 0x20, 0x61, 0x72, 0x67, 0x20, 0x3d, 0x3d,
+					0x3d, 0x20, 0x22, 0x73, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x22, 0x29, 0x20,
+					0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x72,
+					0x65, 0x74, 0x75, 0x72, 0x6e, 0x20, 0x61, 0x72, 0x67, 0x3b, 0x0d, 0x0a,
+					0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x74,
+					0x72, 0x79, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x6a, 0x73, 0x6f, 0x6e,
+					0x20, 0x3d, 0x20, 0x4a, 0x53, 0x4f, 0x4e, 0x2e, 0x73, 0x74, 0x72, 0x69,
+					0x6e, 0x67, 0x69, 0x66, 0x79, 0x28, 0x61, 0x72, 0x67, 0x29, 0x3b, 0x0d,
+					0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x72, 0x65, 0x74,
+					0x75, 0x72, 0x6e, 0x20, 0x6a, 0x73, 0x6f, 0x6e, 0x20, 0x3d, 0x3d, 0x3d,
+					0x20, 0x75, 0x6e, 0x64, 0x65, 0x66, 0x69, 0x6e, 0x65, 0x64, 0x20, 0x3f,
+					0x20, 0x53, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x28, 0x61, 0x72, 0x67, 0x29,
+					0x20, 0x3a, 0x20, 0x6a, 0x73, 0x6f, 0x6e, 0x3b, 0x0d, 0x0a, 0x20, 0x20,
+					0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x63, 0x61, 0x74,
+					0x63, 0x68, 0x20, 0x28, 0x65, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x72, 0x65, 0x74, 0x75, 0x72, 0x6e,
+					0x20, 0x53, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x28, 0x61, 0x72, 0x67, 0x29,
+					0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x7d, 0x0d,
+					0x0a, 0x66, 0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x20, 0x73, 0x65,
+					0x6e, 0x64, 0x43, 0x6f, 0x6e, 0x73, 0x6f, 0x6c, 0x65, 0x28, 0x6c, 0x65,
+					0x76, 0x65, 0x6c, 0x2c, 0x20, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+					0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x2f, 0x2f, 0x20,
+					0x73, 0x77, 0x61, 0x72, 0x6d, 0x20, 0x70, 0x72, 0x69, 0x6e, 0x74, 0x73,
+					0x20, 0x74, 0x68, 0x65, 0x73, 0x65, 0x20, 0x69, 0x6e, 0x20, 0x74, 0x68,
+					0x65, 0x20, 0x74, 0x65, 0x72, 0x6d, 0x69, 0x6e, 0x61, 0x6c, 0x2c, 0x20,
+					0x6d, 0x61, 0x70, 0x70, 0x65, 0x64, 0x20, 0x62, 0x61, 0x63, 0x6b, 0x20,
+					0x74, 0x6f, 0x20, 0x74, 0x68, 0x65, 0x20, 0x6f, 0x72, 0x69, 0x67, 0x69,
+					0x6e, 0x61, 0x6c, 0x20, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x73, 0x0d,
+					0x0a, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x64,
+					0x61, 0x74, 0x61, 0x20, 0x3d, 0x20, 0x7b, 0x20, 0x6c, 0x65, 0x76, 0x65,
+					0x6c, 0x2c, 0x20, 0x75, 0x72, 0x6c, 0x3a, 0x20, 0x77, 0x69, 0x6e, 0x64,
+					0x6f, 0x77, 0x2e, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e,
+					0x68, 0x72, 0x65, 0x66, 0x2c, 0x20, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
+					0x65, 0x20, 0x7d, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x73, 0x63,
+					0x2e, 0x73, 0x65, 0x6e, 0x64, 0x28, 0x22, 0x63, 0x6f, 0x6e, 0x73, 0x6f,
+					0x6c, 0x65, 0x22, 0x2c, 0x20, 0x4a, 0x53, 0x4f, 0x4e, 0x2e, 0x73, 0x74,
+					0x72, 0x69, 0x6e, 0x67, 0x69, 0x66, 0x79, 0x28, 0x64, 0x61, 0x74, 0x61,
+					0x29, 0x29, 0x3b, 0x0d, 0x0a, 0x7d, 0x0d, 0x0a, 0x66, 0x75, 0x6e, 0x63,
+					0x74, 0x69, 0x6f, 0x6e, 0x20, 0x66, 0x6f, 0x72, 0x77, 0x61, 0x72, 0x64,
+					0x43, 0x6f, 0x6e, 0x73, 0x6f, 0x6c, 0x65, 0x28, 0x6c, 0x65, 0x76, 0x65,
+					0x6c, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f,
+					0x6e, 0x73, 0x74, 0x20, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x61, 0x6c,
+					0x20, 0x3d, 0x20, 0x63, 0x6f, 0x6e, 0x73, 0x6f, 0x6c, 0x65, 0x5b, 0x6c,
+					0x65, 0x76, 0x65, 0x6c, 0x5d, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x63, 0x6f, 0x6e, 0x73, 0x6f, 0x6c, 0x65, 0x5b, 0x6c, 0x65, 0x76, 0x65,
+					0x6c, 0x5d, 0x20, 0x3d, 0x20, 0x28, 0x2e, 0x2e, 0x2e, 0x61, 0x72, 0x67,
+					0x73, 0x29, 0x20, 0x3d, 0x3e, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x73, 0x65, 0x6e, 0x64, 0x43, 0x6f, 0x6e,
+					0x73, 0x6f, 0x6c, 0x65, 0x28, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x2c, 0x20,
+					0x61, 0x72, 0x67, 0x73, 0x2e, 0x6d, 0x61, 0x70, 0x28, 0x64, 0x65, 0x73,
+					0x63, 0x72, 0x69, 0x62, 0x65, 0x29, 0x2e, 0x6a, 0x6f, 0x69, 0x6e, 0x28,
+					0x22, 0x20, 0x22, 0x29, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x6f, 0x72, 0x69, 0x67, 0x69, 0x6e, 0x61, 0x6c,
+					0x2e, 0x61, 0x70, 0x70, 0x6c, 0x79, 0x28, 0x63, 0x6f, 0x6e, 0x73, 0x6f,
+					0x6c, 0x65, 0x2c, 0x20, 0x61, 0x72, 0x67, 0x73, 0x29, 0x3b, 0x0d, 0x0a,
+					0x20, 0x20, 0x20, 0x20, 0x7d, 0x3b, 0x0d, 0x0a, 0x7d, 0x0d, 0x0a, 0x66,
+					0x75, 0x6e, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x20, 0x66, 0x6f, 0x72, 0x77,
+					0x61, 0x72, 0x64, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x28, 0x65, 0x29, 0x20,
+					0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e, 0x73, 0x74,
+					0x20, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x20, 0x3d, 0x20, 0x65,
+					0x2e, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x20, 0x3f, 0x20, 0x64, 0x65, 0x73,
+					0x63, 0x72, 0x69, 0x62, 0x65, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x28, 0x65,
+					0x2e, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x29, 0x20, 0x3a, 0x20, 0x60, 0x24,
+					0x7b, 0x65, 0x2e, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x7d, 0x5c,
+					0x6e, 0x20, 0x20, 0x20, 0x20, 0x61, 0x74, 0x20, 0x24, 0x7b, 0x65, 0x2e,
+					0x66, 0x69, 0x6c, 0x65, 0x6e, 0x61, 0x6d, 0x65, 0x7d, 0x3a, 0x24, 0x7b,
+					0x65, 0x2e, 0x6c, 0x69, 0x6e, 0x65, 0x6e, 0x6f, 0x7d, 0x3a, 0x24, 0x7b,
+					0x65, 0x2e, 0x63, 0x6f, 0x6c, 0x6e, 0x6f, 0x7d, 0x60, 0x3b, 0x0d, 0x0a,
+					0x20, 0x20, 0x20, 0x20, 0x73, 0x65, 0x6e, 0x64, 0x43, 0x6f, 0x6e, 0x73,
+					0x6f, 0x6c, 0x65, 0x28, 0x22, 0x75, 0x6e, 0x63, 0x61, 0x75, 0x67, 0x68,
+					0x74, 0x22, 0x2c, 0x20, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x29,
+					0x3b, 0x0d, 0x0a, 0x7d, 0x0d, 0x0a, 0x66, 0x75, 0x6e, 0x63, 0x74, 0x69,
+					0x6f, 0x6e, 0x20, 0x66, 0x6f, 0x72, 0x77, 0x61, 0x72, 0x64, 0x52, 0x65,
+					0x6a, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x28, 0x65, 0x29, 0x20, 0x7b,
+					0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x73, 0x65, 0x6e, 0x64, 0x43, 0x6f,
+					0x6e, 0x73, 0x6f, 0x6c, 0x65, 0x28, 0x22, 0x75, 0x6e, 0x63, 0x61, 0x75,
+					0x67, 0x68, 0x74, 0x22, 0x2c, 0x20, 0x22, 0x28, 0x69, 0x6e, 0x20, 0x70,
+					0x72, 0x6f, 0x6d, 0x69, 0x73, 0x65, 0x29, 0x20, 0x22, 0x20, 0x2b, 0x20,
+					0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x62, 0x65, 0x28, 0x65, 0x2e, 0x72,
+					0x65, 0x61, 0x73, 0x6f, 0x6e, 0x29, 0x29, 0x3b, 0x0d, 0x0a, 0x7d, 0x0d,
+					0x0a, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x73, 0x63, 0x20, 0x3d, 0x20,
+					0x6e, 0x65, 0x77, 0x20, 0x53, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x43, 0x6c,
+					0x69, 0x65, 0x6e, 0x74, 0x28, 0x29, 0x3b, 0x0d, 0x0a, 0x73, 0x63, 0x2e,
+					0x6f, 0x6e, 0x28, 0x65, 0x20, 0x3d, 0x3e, 0x20, 0x7b, 0x0d, 0x0a, 0x20,
+					0x20, 0x20, 0x20, 0x65, 0x2e, 0x74, 0x79, 0x70, 0x65, 0x20, 0x3d, 0x3d,
+					0x20, 0x22, 0x72, 0x65, 0x6c, 0x6f, 0x61, 0x64, 0x2d, 0x63, 0x73, 0x73,
+					0x22, 0x20, 0x26, 0x26, 0x20, 0x72, 0x65, 0x6c, 0x6f, 0x61, 0x64, 0x43,
+					0x53, 0x53, 0x28, 0x65, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x65, 0x2e, 0x74, 0x79, 0x70, 0x65, 0x20, 0x3d, 0x3d, 0x20, 0x22, 0x72,
+					0x65, 0x6c, 0x6f, 0x61, 0x64, 0x22, 0x20, 0x26, 0x26, 0x20, 0x77, 0x69,
+					0x6e, 0x64, 0x6f, 0x77, 0x2e, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f,
+					0x6e, 0x2e, 0x72, 0x65, 0x6c, 0x6f, 0x61, 0x64, 0x28, 0x29, 0x3b, 0x0d,
+					0x0a, 0x7d, 0x29, 0x3b, 0x0d, 0x0a, 0x73, 0x63, 0x2e, 0x63, 0x6f, 0x6e,
+					0x6e, 0x65, 0x63, 0x74, 0x28, 0x29, 0x3b, 0x0d, 0x0a, 0x66, 0x6f, 0x72,
+					0x77, 0x61, 0x72, 0x64, 0x43, 0x6f, 0x6e, 0x73, 0x6f, 0x6c, 0x65, 0x28,
+					0x22, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x29, 0x3b, 0x0d, 0x0a, 0x66,
+					0x6f, 0x72, 0x77, 0x61, 0x72, 0x64, 0x43, 0x6f, 0x6e, 0x73, 0x6f, 0x6c,
+					0x65, 0x28, 0x22, 0x77, 0x61, 0x72, 0x6e, 0x22, 0x29, 0x3b, 0x0d, 0x0a,
+					0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x2e, 0x61, 0x64, 0x64, 0x45, 0x76,
+					0x65, 0x6e, 0x74, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x28,
+					0x22, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x2c, 0x20, 0x66, 0x6f, 0x72,
+					0x77, 0x61, 0x72, 0x64, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x29, 0x3b, 0x0d,
+					0x0a, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x2e, 0x61, 0x64, 0x64, 0x45,
+					0x76, 0x65, 0x6e, 0x74, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72,
+					0x28, 0x22, 0x75, 0x6e, 0x68, 0x61, 0x6e, 0x64, 0x6c, 0x65, 0x64, 0x72,
+					0x65, 0x6a, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x2c, 0x20, 0x66,
+					0x6f, 0x72, 0x77, 0x61, 0x72, 0x64, 0x52, 0x65, 0x6a, 0x65, 0x63, 0x74,
+					0x69, 0x6f, 0x6e, 0x29, 0x3b, 0x0d, 0x0a, 
 				},
 				fi: FileInfo{
 					name:    "HotReload.js",
-					size:    764,
-					modTime: time.Unix(0, 1574441789262962500),
+					size:    2287,
+					modTime: time.Unix(0, 1792428896590648851),
 					isDir:   false,
 				},
 			},"/assets/static/SocketClient.js": File{
@@ -233,121 +1497,187 @@ func init() {
 					0x72, 0x45, 0x61, 0x63, 0x68, 0x28, 0x6c, 0x69, 0x73, 0x74, 0x65, 0x6e,
 					0x65, 0x72, 0x20, 0x3d, 0x3e, 0x20, 0x6c, 0x69, 0x73, 0x74, 0x65, 0x6e,
 					0x65, 0x72, 0x28, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x29, 0x29, 0x3b, 0x0d,
-					0x0a, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x7d, 0x0d, 0x0a, 0x65,
-					0x78, 0x70, 0x6f, 0x72, 0x74, 0x20, 0x63, 0x6c, 0x61, 0x73, 0x73, 0x20,
-					0x53, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74,
-					0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e, 0x73,
-					0x74, 0x72, 0x75, 0x63, 0x74, 0x6f, 0x72, 0x28, 0x29, 0x20, 0x7b, 0x0d,
-					0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e,
-					0x73, 0x74, 0x20, 0x70, 0x6f, 0x72, 0x74, 0x20, 0x3d, 0x20, 0x77, 0x69,
-					0x6e, 0x64, 0x6f, 0x77, 0x2e, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f,
-					0x6e, 0x2e, 0x70, 0x6f, 0x72, 0x74, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20,
-					0x20, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x70,
-					0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x20, 0x3d, 0x20, 0x6c, 0x6f,
-					0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-					0x63, 0x6f, 0x6c, 0x20, 0x3d, 0x3d, 0x3d, 0x20, 0x22, 0x68, 0x74, 0x74,
-					0x70, 0x73, 0x3a, 0x22, 0x20, 0x3f, 0x20, 0x22, 0x77, 0x73, 0x73, 0x3a,
-					0x2f, 0x2f, 0x22, 0x20, 0x3a, 0x20, 0x22, 0x77, 0x73, 0x3a, 0x2f, 0x2f,
-					0x22, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
-					0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x64, 0x6f, 0x6d, 0x61, 0x69, 0x6e,
-					0x20, 0x3d, 0x20, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e,
-					0x68, 0x6f, 0x73, 0x74, 0x6e, 0x61, 0x6d, 0x65, 0x20, 0x7c, 0x7c, 0x20,
-					0x22, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x68, 0x6f, 0x73, 0x74, 0x22, 0x3b,
-					0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x74, 0x68,
-					0x69, 0x73, 0x2e, 0x75, 0x72, 0x6c, 0x20, 0x3d, 0x20, 0x60, 0x24, 0x7b,
-					0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x7d, 0x24, 0x7b, 0x64,
-					0x6f, 0x6d, 0x61, 0x69, 0x6e, 0x7d, 0x3a, 0x24, 0x7b, 0x70, 0x6f, 0x72,
-					0x74, 0x7d, 0x2f, 0x5f, 0x5f, 0x73, 0x77, 0x61, 0x72, 0x6d, 0x5f, 0x5f,
-					0x2f, 0x77, 0x73, 0x60, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20,
-					0x20, 0x20, 0x20, 0x74, 0x68, 0x69, 0x73, 0x2e, 0x65, 0x6d, 0x69, 0x74,
-					0x74, 0x65, 0x72, 0x20, 0x3d, 0x20, 0x6e, 0x65, 0x77, 0x20, 0x45, 0x76,
-					0x65, 0x6e, 0x74, 0x45, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x72, 0x28, 0x29,
-					0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20,
-					0x20, 0x20, 0x72, 0x65, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x28,
+					0x0a, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x7d, 0x0d, 0x0a, 0x2f,
+					0x2a, 0x2a, 0x20, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x20,
+					0x73, 0x65, 0x6e, 0x74, 0x20, 0x62, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x20,
+					0x74, 0x68, 0x65, 0x20, 0x73, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x20, 0x69,
+					0x73, 0x20, 0x6f, 0x70, 0x65, 0x6e, 0x20, 0x61, 0x72, 0x65, 0x20, 0x71,
+					0x75, 0x65, 0x75, 0x65, 0x64, 0x2c, 0x20, 0x75, 0x70, 0x20, 0x74, 0x6f,
+					0x20, 0x74, 0x68, 0x69, 0x73, 0x20, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x20,
+					0x2a, 0x2f, 0x0d, 0x0a, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x6d, 0x61,
+					0x78, 0x50, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x4d, 0x65, 0x73, 0x73,
+					0x61, 0x67, 0x65, 0x73, 0x20, 0x3d, 0x20, 0x31, 0x30, 0x30, 0x3b, 0x0d,
+					0x0a, 0x2f, 0x2a, 0x2a, 0x20, 0x54, 0x68, 0x65, 0x20, 0x6f, 0x72, 0x69,
+					0x67, 0x69, 0x6e, 0x61, 0x6c, 0x20, 0x63, 0x6f, 0x6e, 0x73, 0x6f, 0x6c,
+					0x65, 0x2e, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x2c, 0x20, 0x73, 0x6f, 0x20,
+					0x74, 0x68, 0x61, 0x74, 0x20, 0x73, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x20,
+					0x65, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x20, 0x61, 0x72, 0x65, 0x6e, 0x27,
+					0x74, 0x20, 0x66, 0x6f, 0x72, 0x77, 0x61, 0x72, 0x64, 0x65, 0x64, 0x20,
+					0x62, 0x61, 0x63, 0x6b, 0x20, 0x74, 0x68, 0x72, 0x6f, 0x75, 0x67, 0x68,
+					0x20, 0x74, 0x68, 0x65, 0x20, 0x73, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x20,
+					0x2a, 0x2f, 0x0d, 0x0a, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x6c, 0x6f,
+					0x67, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x20, 0x3d, 0x20, 0x63, 0x6f, 0x6e,
+					0x73, 0x6f, 0x6c, 0x65, 0x2e, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x2e, 0x62,
+					0x69, 0x6e, 0x64, 0x28, 0x63, 0x6f, 0x6e, 0x73, 0x6f, 0x6c, 0x65, 0x29,
+					0x3b, 0x0d, 0x0a, 0x65, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x20, 0x63, 0x6c,
+					0x61, 0x73, 0x73, 0x20, 0x53, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x43, 0x6c,
+					0x69, 0x65, 0x6e, 0x74, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x63, 0x6f, 0x6e, 0x73, 0x74, 0x72, 0x75, 0x63, 0x74, 0x6f, 0x72, 0x28,
 					0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
-					0x20, 0x73, 0x65, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x28,
-					0x28, 0x29, 0x20, 0x3d, 0x3e, 0x20, 0x74, 0x68, 0x69, 0x73, 0x2e, 0x63,
-					0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x28, 0x29, 0x2c, 0x20, 0x35, 0x30,
-					0x30, 0x30, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d,
-					0x0a, 0x20, 0x20, 0x20, 0x20, 0x6f, 0x6e, 0x28, 0x66, 0x6e, 0x29, 0x20,
-					0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x74,
-					0x68, 0x69, 0x73, 0x2e, 0x65, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x72, 0x2e,
-					0x6f, 0x6e, 0x28, 0x66, 0x6e, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20,
-					0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e, 0x6e,
+					0x20, 0x74, 0x68, 0x69, 0x73, 0x2e, 0x70, 0x65, 0x6e, 0x64, 0x69, 0x6e,
+					0x67, 0x20, 0x3d, 0x20, 0x5b, 0x5d, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x2f, 0x2f, 0x20, 0x77, 0x73, 0x73, 0x20,
+					0x77, 0x68, 0x65, 0x6e, 0x20, 0x74, 0x68, 0x65, 0x20, 0x70, 0x61, 0x67,
+					0x65, 0x20, 0x69, 0x73, 0x20, 0x73, 0x65, 0x72, 0x76, 0x65, 0x64, 0x20,
+					0x6f, 0x76, 0x65, 0x72, 0x20, 0x68, 0x74, 0x74, 0x70, 0x73, 0x3b, 0x20,
+					0x68, 0x6f, 0x73, 0x74, 0x20, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65,
+					0x73, 0x20, 0x74, 0x68, 0x65, 0x20, 0x70, 0x6f, 0x72, 0x74, 0x2c, 0x20,
+					0x75, 0x6e, 0x6c, 0x65, 0x73, 0x73, 0x20, 0x69, 0x74, 0x27, 0x73, 0x20,
+					0x74, 0x68, 0x65, 0x20, 0x64, 0x65, 0x66, 0x61, 0x75, 0x6c, 0x74, 0x0d,
+					0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e,
+					0x73, 0x74, 0x20, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x20,
+					0x3d, 0x20, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70,
+					0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x20, 0x3d, 0x3d, 0x3d, 0x20,
+					0x22, 0x68, 0x74, 0x74, 0x70, 0x73, 0x3a, 0x22, 0x20, 0x3f, 0x20, 0x22,
+					0x77, 0x73, 0x73, 0x3a, 0x2f, 0x2f, 0x22, 0x20, 0x3a, 0x20, 0x22, 0x77,
+					0x73, 0x3a, 0x2f, 0x2f, 0x22, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x68, 0x6f,
+					0x73, 0x74, 0x20, 0x3d, 0x20, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f,
+					0x6e, 0x2e, 0x68, 0x6f, 0x73, 0x74, 0x20, 0x7c, 0x7c, 0x20, 0x22, 0x6c,
+					0x6f, 0x63, 0x61, 0x6c, 0x68, 0x6f, 0x73, 0x74, 0x22, 0x3b, 0x0d, 0x0a,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x2f, 0x2f, 0x20, 0x74,
+					0x68, 0x65, 0x20, 0x70, 0x61, 0x67, 0x65, 0x20, 0x70, 0x61, 0x74, 0x68,
+					0x20, 0x74, 0x65, 0x6c, 0x6c, 0x73, 0x20, 0x74, 0x68, 0x65, 0x20, 0x73,
+					0x65, 0x72, 0x76, 0x65, 0x72, 0x20, 0x77, 0x68, 0x69, 0x63, 0x68, 0x20,
+					0x62, 0x75, 0x69, 0x6c, 0x64, 0x20, 0x74, 0x68, 0x69, 0x73, 0x20, 0x70,
+					0x61, 0x67, 0x65, 0x20, 0x62, 0x65, 0x6c, 0x6f, 0x6e, 0x67, 0x73, 0x20,
+					0x74, 0x6f, 0x2c, 0x20, 0x73, 0x6f, 0x20, 0x69, 0x74, 0x20, 0x6f, 0x6e,
+					0x6c, 0x79, 0x20, 0x67, 0x65, 0x74, 0x73, 0x20, 0x74, 0x68, 0x61, 0x74,
+					0x20, 0x62, 0x75, 0x69, 0x6c, 0x64, 0x27, 0x73, 0x20, 0x72, 0x65, 0x6c,
+					0x6f, 0x61, 0x64, 0x73, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x74, 0x68, 0x69, 0x73, 0x2e, 0x75, 0x72, 0x6c, 0x20, 0x3d,
+					0x20, 0x60, 0x24, 0x7b, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c,
+					0x7d, 0x24, 0x7b, 0x68, 0x6f, 0x73, 0x74, 0x7d, 0x2f, 0x5f, 0x5f, 0x73,
+					0x77, 0x61, 0x72, 0x6d, 0x5f, 0x5f, 0x2f, 0x77, 0x73, 0x3f, 0x70, 0x61,
+					0x67, 0x65, 0x3d, 0x24, 0x7b, 0x65, 0x6e, 0x63, 0x6f, 0x64, 0x65, 0x55,
+					0x52, 0x49, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x28,
+					0x6c, 0x6f, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x61, 0x74,
+					0x68, 0x6e, 0x61, 0x6d, 0x65, 0x29, 0x7d, 0x60, 0x3b, 0x0d, 0x0a, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x74, 0x68, 0x69, 0x73, 0x2e,
+					0x65, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x72, 0x20, 0x3d, 0x20, 0x6e, 0x65,
+					0x77, 0x20, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x45, 0x6d, 0x69, 0x74, 0x74,
+					0x65, 0x72, 0x28, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x7d,
+					0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x72, 0x65, 0x63, 0x6f, 0x6e, 0x6e,
 					0x65, 0x63, 0x74, 0x28, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20,
-					0x20, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e, 0x73, 0x6f, 0x6c, 0x65,
-					0x2e, 0x6c, 0x6f, 0x67, 0x28, 0x22, 0x25, 0x63, 0x43, 0x6f, 0x6e, 0x6e,
-					0x65, 0x63, 0x74, 0x69, 0x6e, 0x67, 0x20, 0x74, 0x6f, 0x20, 0x77, 0x65,
-					0x62, 0x73, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x20, 0x61, 0x74, 0x20, 0x22,
-					0x20, 0x2b, 0x20, 0x74, 0x68, 0x69, 0x73, 0x2e, 0x75, 0x72, 0x6c, 0x2c,
-					0x20, 0x22, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x3a, 0x20, 0x23, 0x32, 0x33,
-					0x37, 0x61, 0x62, 0x65, 0x22, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20,
 					0x20, 0x20, 0x20, 0x20, 0x20, 0x73, 0x65, 0x74, 0x54, 0x69, 0x6d, 0x65,
-					0x6f, 0x75, 0x74, 0x28, 0x28, 0x29, 0x20, 0x3d, 0x3e, 0x20, 0x7b, 0x0d,
-					0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
-					0x20, 0x74, 0x68, 0x69, 0x73, 0x2e, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74,
-					0x20, 0x3d, 0x20, 0x6e, 0x65, 0x77, 0x20, 0x57, 0x65, 0x62, 0x53, 0x6f,
-					0x63, 0x6b, 0x65, 0x74, 0x28, 0x74, 0x68, 0x69, 0x73, 0x2e, 0x75, 0x72,
-					0x6c, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
-					0x20, 0x20, 0x20, 0x20, 0x20, 0x74, 0x68, 0x69, 0x73, 0x2e, 0x62, 0x69,
-					0x6e, 0x64, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x28, 0x29, 0x3b, 0x0d,
-					0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x2c, 0x20,
-					0x30, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d, 0x0a,
-					0x20, 0x20, 0x20, 0x20, 0x73, 0x65, 0x6e, 0x64, 0x28, 0x65, 0x76, 0x65,
-					0x6e, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x2c, 0x20, 0x64, 0x61, 0x74, 0x61,
-					0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
-					0x20, 0x69, 0x66, 0x20, 0x28, 0x74, 0x68, 0x69, 0x73, 0x2e, 0x63, 0x6c,
-					0x69, 0x65, 0x6e, 0x74, 0x2e, 0x72, 0x65, 0x61, 0x64, 0x79, 0x53, 0x74,
-					0x61, 0x74, 0x65, 0x20, 0x3d, 0x3d, 0x3d, 0x20, 0x31, 0x29, 0x20, 0x7b,
+					0x6f, 0x75, 0x74, 0x28, 0x28, 0x29, 0x20, 0x3d, 0x3e, 0x20, 0x74, 0x68,
+					0x69, 0x73, 0x2e, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x28, 0x29,
+					0x2c, 0x20, 0x35, 0x30, 0x30, 0x30, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20,
+					0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x6f, 0x6e, 0x28,
+					0x66, 0x6e, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x74, 0x68, 0x69, 0x73, 0x2e, 0x65, 0x6d, 0x69, 0x74,
+					0x74, 0x65, 0x72, 0x2e, 0x6f, 0x6e, 0x28, 0x66, 0x6e, 0x29, 0x3b, 0x0d,
+					0x0a, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x28, 0x29, 0x20, 0x7b, 0x0d,
+					0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e,
+					0x73, 0x6f, 0x6c, 0x65, 0x2e, 0x6c, 0x6f, 0x67, 0x28, 0x22, 0x25, 0x63,
+					0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6e, 0x67, 0x20, 0x74,
+					0x6f, 0x20, 0x77, 0x65, 0x62, 0x73, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x20,
+					0x61, 0x74, 0x20, 0x22, 0x20, 0x2b, 0x20, 0x74, 0x68, 0x69, 0x73, 0x2e,
+					0x75, 0x72, 0x6c, 0x2c, 0x20, 0x22, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x3a,
+					0x20, 0x23, 0x32, 0x33, 0x37, 0x61, 0x62, 0x65, 0x22, 0x29, 0x3b, 0x0d,
+					0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x73, 0x65, 0x74,
+					0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x28, 0x28, 0x29, 0x20, 0x3d,
+					0x3e, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x74, 0x68, 0x69, 0x73, 0x2e, 0x63, 0x6c,
+					0x69, 0x65, 0x6e, 0x74, 0x20, 0x3d, 0x20, 0x6e, 0x65, 0x77, 0x20, 0x57,
+					0x65, 0x62, 0x53, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x28, 0x74, 0x68, 0x69,
+					0x73, 0x2e, 0x75, 0x72, 0x6c, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x74, 0x68, 0x69,
+					0x73, 0x2e, 0x62, 0x69, 0x6e, 0x64, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x73,
+					0x28, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x7d, 0x2c, 0x20, 0x30, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x73, 0x65, 0x6e, 0x64,
+					0x28, 0x74, 0x79, 0x70, 0x65, 0x2c, 0x20, 0x64, 0x61, 0x74, 0x61, 0x29,
+					0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
+					0x65, 0x20, 0x3d, 0x20, 0x4a, 0x53, 0x4f, 0x4e, 0x2e, 0x73, 0x74, 0x72,
+					0x69, 0x6e, 0x67, 0x69, 0x66, 0x79, 0x28, 0x7b, 0x20, 0x74, 0x79, 0x70,
+					0x65, 0x2c, 0x20, 0x64, 0x61, 0x74, 0x61, 0x20, 0x7d, 0x29, 0x3b, 0x0d,
+					0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x69, 0x66, 0x20,
+					0x28, 0x74, 0x68, 0x69, 0x73, 0x2e, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74,
+					0x20, 0x26, 0x26, 0x20, 0x74, 0x68, 0x69, 0x73, 0x2e, 0x63, 0x6c, 0x69,
+					0x65, 0x6e, 0x74, 0x2e, 0x72, 0x65, 0x61, 0x64, 0x79, 0x53, 0x74, 0x61,
+					0x74, 0x65, 0x20, 0x3d, 0x3d, 0x3d, 0x20, 0x57, 0x65, 0x62, 0x53, 0x6f,
+					0x63, 0x6b, 0x65, 0x74, 0x2e, 0x4f, 0x50, 0x45, 0x4e, 0x29, 0x20, 0x7b,
 					0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
 					0x20, 0x20, 0x74, 0x68, 0x69, 0x73, 0x2e, 0x63, 0x6c, 0x69, 0x65, 0x6e,
-					0x74, 0x2e, 0x73, 0x65, 0x6e, 0x64, 0x28, 0x4a, 0x53, 0x4f, 0x4e, 0x2e,
-					0x73, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x69, 0x66, 0x79, 0x28, 0x7b, 0x20,
-					0x65, 0x76, 0x65, 0x6e, 0x74, 0x3a, 0x20, 0x65, 0x76, 0x65, 0x6e, 0x74,
-					0x4e, 0x61, 0x6d, 0x65, 0x2c, 0x20, 0x64, 0x61, 0x74, 0x61, 0x3a, 0x20,
-					0x64, 0x61, 0x74, 0x61, 0x20, 0x7c, 0x7c, 0x20, 0x7b, 0x7d, 0x20, 0x7d,
-					0x29, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
-					0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x20,
-					0x20, 0x20, 0x20, 0x2f, 0x2a, 0x2a, 0x20, 0x57, 0x69, 0x72, 0x65, 0x73,
-					0x20, 0x75, 0x70, 0x20, 0x74, 0x68, 0x65, 0x20, 0x73, 0x6f, 0x63, 0x6b,
-					0x65, 0x74, 0x20, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x20, 0x6d, 0x65,
-					0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x20, 0x74, 0x6f, 0x20, 0x62, 0x65,
-					0x20, 0x65, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x64, 0x20, 0x6f, 0x6e, 0x20,
-					0x6f, 0x75, 0x72, 0x20, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x20, 0x65, 0x6d,
-					0x69, 0x74, 0x74, 0x65, 0x72, 0x20, 0x2a, 0x2f, 0x0d, 0x0a, 0x20, 0x20,
-					0x20, 0x20, 0x62, 0x69, 0x6e, 0x64, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x73,
-					0x28, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
-					0x20, 0x20, 0x74, 0x68, 0x69, 0x73, 0x2e, 0x63, 0x6c, 0x69, 0x65, 0x6e,
-					0x74, 0x2e, 0x6f, 0x6e, 0x6f, 0x70, 0x65, 0x6e, 0x20, 0x3d, 0x20, 0x65,
-					0x76, 0x65, 0x6e, 0x74, 0x20, 0x3d, 0x3e, 0x20, 0x7b, 0x20, 0x63, 0x6f,
-					0x6e, 0x73, 0x6f, 0x6c, 0x65, 0x2e, 0x6c, 0x6f, 0x67, 0x28, 0x22, 0x25,
-					0x63, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x65, 0x64, 0x22, 0x2c,
-					0x20, 0x22, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x3a, 0x20, 0x23, 0x32, 0x33,
-					0x37, 0x61, 0x62, 0x65, 0x22, 0x29, 0x3b, 0x20, 0x74, 0x68, 0x69, 0x73,
-					0x2e, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x2e, 0x6f, 0x6e, 0x63, 0x6c,
-					0x6f, 0x73, 0x65, 0x20, 0x3d, 0x20, 0x28, 0x65, 0x76, 0x65, 0x6e, 0x74,
-					0x29, 0x20, 0x3d, 0x3e, 0x20, 0x74, 0x68, 0x69, 0x73, 0x2e, 0x72, 0x65,
-					0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x28, 0x29, 0x3b, 0x20, 0x7d,
-					0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x74,
-					0x68, 0x69, 0x73, 0x2e, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x2e, 0x6f,
-					0x6e, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x20, 0x3d, 0x20, 0x28, 0x65, 0x76,
-					0x65, 0x6e, 0x74, 0x29, 0x20, 0x3d, 0x3e, 0x20, 0x63, 0x6f, 0x6e, 0x73,
-					0x6f, 0x6c, 0x65, 0x2e, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x28, 0x65, 0x76,
-					0x65, 0x6e, 0x74, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20,
-					0x20, 0x20, 0x20, 0x74, 0x68, 0x69, 0x73, 0x2e, 0x63, 0x6c, 0x69, 0x65,
-					0x6e, 0x74, 0x2e, 0x6f, 0x6e, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+					0x74, 0x2e, 0x73, 0x65, 0x6e, 0x64, 0x28, 0x6d, 0x65, 0x73, 0x73, 0x61,
+					0x67, 0x65, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x65, 0x6c, 0x73, 0x65, 0x20, 0x69, 0x66, 0x20, 0x28, 0x74, 0x68,
+					0x69, 0x73, 0x2e, 0x70, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x6c,
+					0x65, 0x6e, 0x67, 0x74, 0x68, 0x20, 0x3c, 0x20, 0x6d, 0x61, 0x78, 0x50,
+					0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67,
+					0x65, 0x73, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x74, 0x68, 0x69, 0x73, 0x2e,
+					0x70, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x70, 0x75, 0x73, 0x68,
+					0x28, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x29, 0x3b, 0x0d, 0x0a,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x20,
+					0x20, 0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x66, 0x6c,
+					0x75, 0x73, 0x68, 0x28, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x63, 0x6f, 0x6e, 0x73, 0x74, 0x20, 0x70,
+					0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x20, 0x3d, 0x20, 0x74, 0x68, 0x69,
+					0x73, 0x2e, 0x70, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x3b, 0x0d, 0x0a,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x74, 0x68, 0x69, 0x73,
+					0x2e, 0x70, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x20, 0x3d, 0x20, 0x5b,
+					0x5d, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x20,
+					0x70, 0x65, 0x6e, 0x64, 0x69, 0x6e, 0x67, 0x2e, 0x66, 0x6f, 0x72, 0x45,
+					0x61, 0x63, 0x68, 0x28, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x20,
+					0x3d, 0x3e, 0x20, 0x74, 0x68, 0x69, 0x73, 0x2e, 0x63, 0x6c, 0x69, 0x65,
+					0x6e, 0x74, 0x2e, 0x73, 0x65, 0x6e, 0x64, 0x28, 0x6d, 0x65, 0x73, 0x73,
+					0x61, 0x67, 0x65, 0x29, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x7d, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x2f, 0x2a, 0x2a, 0x20, 0x57,
+					0x69, 0x72, 0x65, 0x73, 0x20, 0x75, 0x70, 0x20, 0x74, 0x68, 0x65, 0x20,
+					0x73, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x20, 0x63, 0x6c, 0x69, 0x65, 0x6e,
+					0x74, 0x20, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x73, 0x20, 0x74,
+					0x6f, 0x20, 0x62, 0x65, 0x20, 0x65, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x64,
+					0x20, 0x6f, 0x6e, 0x20, 0x6f, 0x75, 0x72, 0x20, 0x65, 0x76, 0x65, 0x6e,
+					0x74, 0x20, 0x65, 0x6d, 0x69, 0x74, 0x74, 0x65, 0x72, 0x20, 0x2a, 0x2f,
+					0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20, 0x62, 0x69, 0x6e, 0x64, 0x45, 0x76,
+					0x65, 0x6e, 0x74, 0x73, 0x28, 0x29, 0x20, 0x7b, 0x0d, 0x0a, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x20, 0x74, 0x68, 0x69, 0x73, 0x2e, 0x63,
+					0x6c, 0x69, 0x65, 0x6e, 0x74, 0x2e, 0x6f, 0x6e, 0x6f, 0x70, 0x65, 0x6e,
+					0x20, 0x3d, 0x20, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x20, 0x3d, 0x3e, 0x20,
+					0x7b, 0x20, 0x63, 0x6f, 0x6e, 0x73, 0x6f, 0x6c, 0x65, 0x2e, 0x6c, 0x6f,
+					0x67, 0x28, 0x22, 0x25, 0x63, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74,
+					0x65, 0x64, 0x22, 0x2c, 0x20, 0x22, 0x63, 0x6f, 0x6c, 0x6f, 0x72, 0x3a,
+					0x20, 0x23, 0x32, 0x33, 0x37, 0x61, 0x62, 0x65, 0x22, 0x29, 0x3b, 0x20,
+					0x74, 0x68, 0x69, 0x73, 0x2e, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x2e,
+					0x6f, 0x6e, 0x63, 0x6c, 0x6f, 0x73, 0x65, 0x20, 0x3d, 0x20, 0x28, 0x65,
+					0x76, 0x65, 0x6e, 0x74, 0x29, 0x20, 0x3d, 0x3e, 0x20, 0x74, 0x68, 0x69,
+					0x73, 0x2e, 0x72, 0x65, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x28,
+					0x29, 0x3b, 0x20, 0x74, 0x68, 0x69, 0x73, 0x2e, 0x66, 0x6c, 0x75, 0x73,
+					0x68, 0x28, 0x29, 0x3b, 0x20, 0x7d, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x20, 0x74, 0x68, 0x69, 0x73, 0x2e, 0x63, 0x6c,
+					0x69, 0x65, 0x6e, 0x74, 0x2e, 0x6f, 0x6e, 0x65, 0x72, 0x72, 0x6f, 0x72,
 					0x20, 0x3d, 0x20, 0x28, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x29, 0x20, 0x3d,
-					0x3e, 0x20, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x2e, 0x64, 0x61, 0x74, 0x61,
-					0x20, 0x26, 0x26, 0x20, 0x74, 0x68, 0x69, 0x73, 0x2e, 0x65, 0x6d, 0x69,
-					0x74, 0x74, 0x65, 0x72, 0x2e, 0x65, 0x6d, 0x69, 0x74, 0x28, 0x4a, 0x53,
-					0x4f, 0x4e, 0x2e, 0x70, 0x61, 0x72, 0x73, 0x65, 0x28, 0x65, 0x76, 0x65,
-					0x6e, 0x74, 0x2e, 0x64, 0x61, 0x74, 0x61, 0x29, 0x29, 0x3b, 0x0d, 0x0a,
-					0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x7d, 0x0d, 0x0a, 
+					0x3e, 0x20, 0x6c, 0x6f, 0x67, 0x45, 0x72, 0x72, 0x6f, 0x72, 0x28, 0x65,
+					0x76, 0x65, 0x6e, 0x74, 0x29, 0x3b, 0x0d, 0x0a, 0x20, 0x20, 0x20, 0x20,
+					0x20, 0x20, 0x20, 0x20, 0x74, 0x68, 0x69, 0x73, 0x2e, 0x63, 0x6c, 0x69,
+					0x65, 0x6e, 0x74, 0x2e, 0x6f, 0x6e, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
+					0x65, 0x20, 0x3d, 0x20, 0x28, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x29, 0x20,
+					0x3d, 0x3e, 0x20, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x2e, 0x64, 0x61, 0x74,
+					0x61, 0x20, 0x26, 0x26, 0x20, 0x74, 0x68, 0x69, 0x73, 0x2e, 0x65, 0x6d,
+					0x69, 0x74, 0x74, 0x65, 0x72, 0x2e, 0x65, 0x6d, 0x69, 0x74, 0x28, 0x4a,
+					0x53, 0x4f, 0x4e, 0x2e, 0x70, 0x61, 0x72, 0x73, 0x65, 0x28, 0x65, 0x76,
+					0x65, 0x6e, 0x74, 0x2e, 0x64, 0x61, 0x74, 0x61, 0x29, 0x29, 0x3b, 0x0d,
+					0x0a, 0x20, 0x20, 0x20, 0x20, 0x7d, 0x0d, 0x0a, 0x7d, 0x0d, 0x0a, 
 				},
 				fi: FileInfo{
 					name:    "SocketClient.js",
-					size:    1546,
-					modTime: time.Unix(0, 1574441789259011400),
+					size:    2339,
+					modTime: time.Unix(0, 1792429521145192510),
 					isDir:   false,
 				},
 			},"/assets/static/css.escape.js": File{
@@ -0,0 +1,358 @@
+import { SocketClient } from "./SocketClient.js";
+
+interface FileSize {
+    id: string;
+    size: number;
+}
+
+interface SizeBudget {
+    raw: number;
+    gzip: number;
+}
+
+interface BundleSize {
+    build: string;
+    module: string;
+    url: string;
+    raw: number;
+    gzip: number;
+    budget?: SizeBudget;
+    excludes: string[];
+    files: FileSize[];
+}
+
+interface AnalyzeData {
+    live: boolean;
+    bundles: BundleSize[];
+}
+
+/** A box of the treemap: a bundle, a directory or a file */
+interface TreeNode {
+    name: string;
+    path: string;
+    size: number;
+    children: TreeNode[];
+    parent?: TreeNode;
+    bundle?: BundleSize;
+    hue: number;
+}
+
+interface Rect {
+    x: number;
+    y: number;
+    w: number;
+    h: number;
+}
+
+const dataURL = "/__swarm__/analyze.json";
+
+/** Height of the label at the top of a box that contains smaller boxes */
+const labelHeight = 18;
+/** Boxes are nested this many levels beneath the zoomed box */
+const maxDepth = 4;
+/** Boxes narrower or shorter than this aren't labelled, or divided into smaller boxes */
+const minSide = 24;
+
+const treemap = document.getElementById("treemap");
+const breadcrumbs = document.getElementById("breadcrumbs");
+const bundleList = document.getElementById("bundles");
+const search = <HTMLInputElement>document.getElementById("search");
+const status = document.getElementById("status");
+
+let root: TreeNode = null;
+let zoomed: TreeNode = null;
+let socket: SocketClient = null;
+
+function formatByteSize(size: number): string {
+    if (size < 1024) {
+        return size + " B";
+    }
+    if (size < 1024 * 1024) {
+        return (size / 1024).toFixed(1) + " KB";
+    }
+    return (size / (1024 * 1024)).toFixed(1) + " MB";
+}
+
+function bundleKey(bundle: BundleSize): string {
+    return bundle.build + ":" + bundle.module;
+}
+
+function overBudget(bundle: BundleSize): boolean {
+    const budget = bundle.budget;
+    return !!budget && ((budget.raw > 0 && bundle.raw > budget.raw) || (budget.gzip > 0 && bundle.gzip > budget.gzip));
+}
+
+/** Builds a tree of bundles, then the directories and files of each, merging directories with a single subdirectory */
+function buildTree(bundles: BundleSize[]): TreeNode {
+    const tree: TreeNode = { name: "All bundles", path: "", size: 0, children: [], hue: 0 };
+    bundles.forEach((bundle, i) => {
+        const bundleNode: TreeNode = { name: bundle.module, path: bundleKey(bundle), size: 0, children: [], parent: tree, bundle, hue: (i * 67) % 360 };
+        bundle.files.forEach(file => {
+            let node = bundleNode;
+            file.id.split("/").forEach(name => {
+                let child = node.children.find(c => c.name === name);
+                if (!child) {
+                    child = { name, path: node.path + "/" + name, size: 0, children: [], parent: node, hue: bundleNode.hue };
+                    node.children.push(child);
+                }
+                child.size += file.size;
+                node = child;
+            });
+            bundleNode.size += file.size;
+        });
+        bundleNode.children.forEach(mergeSingleChildren);
+        tree.children.push(bundleNode);
+        tree.size += bundleNode.size;
+    });
+    sortBySize(tree);
+    return tree;
+}
+
+function mergeSingleChildren(node: TreeNode) {
+    while (node.children.length === 1 && node.children[0].children.length > 0) {
+        const child = node.children[0];
+        node.name += "/" + child.name;
+        node.path = child.path;
+        node.children = child.children;
+        node.children.forEach(c => c.parent = node);
+    }
+    node.children.forEach(mergeSingleChildren);
+}
+
+function sortBySize(node: TreeNode) {
+    node.children.sort((a, b) => b.size - a.size || (a.name < b.name ? -1 : 1));
+    node.children.forEach(sortBySize);
+}
+
+function findNode(node: TreeNode, path: string): TreeNode {
+    if (node.path === path) {
+        return node;
+    }
+    for (const child of node.children) {
+        const found = findNode(child, path);
+        if (found) {
+            return found;
+        }
+    }
+    return null;
+}
+
+/** The worst aspect ratio of a row of boxes with these areas, laid along a side */
+function worstAspectRatio(areas: number[], side: number): number {
+    const total = areas.reduce((sum, area) => sum + area, 0);
+    const largest = Math.max(...areas);
+    const smallest = Math.min(...areas);
+    return Math.max(side * side * largest / (total * total), (total * total) / (side * side * smallest));
+}
+
+/** Lays out boxes with areas proportional to their sizes, keeping them as square as possible (squarified treemap) */
+function squarify(nodes: TreeNode[], rect: Rect): [TreeNode, Rect][] {
+    const layout: [TreeNode, Rect][] = [];
+    const sized = nodes.filter(node => node.size > 0);
+    const total = sized.reduce((sum, node) => sum + node.size, 0);
+    if (total === 0 || rect.w <= 0 || rect.h <= 0) {
+        return layout;
+    }
+
+    const scale = rect.w * rect.h / total;
+    let { x, y, w, h } = rect;
+    let i = 0;
+    while (i < sized.length) {
+        const side = Math.min(w, h);
+        const row = [sized[i]];
+        const areas = [sized[i].size * scale];
+        for (i++; i < sized.length; i++) {
+            const area = sized[i].size * scale;
+            if (worstAspectRatio(areas.concat(area), side) > worstAspectRatio(areas, side)) {
+                break;
+            }
+            row.push(sized[i]);
+            areas.push(area);
+        }
+
+        const thickness = areas.reduce((sum, area) => sum + area, 0) / side;
+        let offset = 0;
+        row.forEach((node, j) => {
+            const length = areas[j] / thickness;
+            layout.push([node, w >= h ? { x, y: y + offset, w: thickness, h: length } : { x: x + offset, y, w: length, h: thickness }]);
+            offset += length;
+        });
+        if (w >= h) {
+            x += thickness;
+            w -= thickness;
+        } else {
+            y += thickness;
+            h -= thickness;
+        }
+    }
+    return layout;
+}
+
+/** The path of a file or directory, without the bundle that it's in */
+function relativePath(node: TreeNode): string {
+    let bundleNode = node;
+    while (bundleNode.parent && !bundleNode.bundle) {
+        bundleNode = bundleNode.parent;
+    }
+    return node.path.substring(bundleNode.path.length + 1);
+}
+
+function matches(node: TreeNode, query: string): boolean {
+    if (node.children.length === 0) {
+        return relativePath(node).toLowerCase().indexOf(query) >= 0;
+    }
+    return node.children.some(child => matches(child, query));
+}
+
+function describe(node: TreeNode): string {
+    if (node.bundle) {
+        const bundle = node.bundle;
+        return `${bundle.module} (${bundle.url}): ${formatByteSize(bundle.raw)}, ${formatByteSize(bundle.gzip)} gzipped`;
+    }
+    return `${relativePath(node)}: ${formatByteSize(node.size)}`;
+}
+
+function renderBox(node: TreeNode, rect: Rect, depth: number, container: HTMLElement, query: string) {
+    const box = document.createElement("div");
+    box.className = "box";
+    box.style.left = rect.x + "px";
+    box.style.top = rect.y + "px";
+    box.style.width = rect.w + "px";
+    box.style.height = rect.h + "px";
+    box.style.background = `hsl(${node.hue}, 60%, ${Math.min(90, 55 + depth * 8)}%)`;
+    box.title = describe(node);
+    if (query && !matches(node, query)) {
+        box.classList.add("dimmed");
+    } else if (query && node.children.length === 0) {
+        box.classList.add("match");
+    }
+    box.addEventListener("click", e => {
+        e.stopPropagation();
+        zoom(node.children.length > 0 ? node : node.parent);
+    });
+
+    if (rect.w >= minSide && rect.h >= minSide) {
+        const label = document.createElement("div");
+        label.className = "label";
+        label.textContent = `${node.name} ${formatByteSize(node.size)}`;
+        box.appendChild(label);
+
+        const inner = { x: 2, y: labelHeight, w: rect.w - 6, h: rect.h - labelHeight - 4 };
+        if (depth < maxDepth && node.children.length > 0) {
+            squarify(node.children, inner).forEach(([child, childRect]) => renderBox(child, childRect, depth + 1, box, query));
+        }
+    }
+    container.appendChild(box);
+}
+
+function renderTreemap() {
+    treemap.innerHTML = "";
+    if (!zoomed) {
+        return;
+    }
+    const query = search.value.trim().toLowerCase();
+    const rect = { x: 0, y: 0, w: treemap.clientWidth, h: treemap.clientHeight };
+    squarify(zoomed.children, rect).forEach(([node, nodeRect]) => renderBox(node, nodeRect, 0, treemap, query));
+
+    if (query) {
+        const matchingFiles: TreeNode[] = [];
+        const collect = (node: TreeNode) => node.children.length === 0 ? matches(node, query) && matchingFiles.push(node) : node.children.forEach(collect);
+        collect(zoomed);
+        const total = matchingFiles.reduce((sum, node) => sum + node.size, 0);
+        status.textContent = `${matchingFiles.length} matching files, ${formatByteSize(total)}`;
+    } else {
+        status.textContent = `${root.children.length} bundles, ${formatByteSize(root.size)} of source`;
+    }
+}
+
+function renderBreadcrumbs() {
+    breadcrumbs.innerHTML = "";
+    const trail: TreeNode[] = [];
+    for (let node = zoomed; node; node = node.parent) {
+        trail.unshift(node);
+    }
+    trail.forEach((node, i) => {
+        if (i > 0) {
+            breadcrumbs.appendChild(document.createTextNode(" / "));
+        }
+        const link = document.createElement("a");
+        link.textContent = node.name;
+        link.addEventListener("click", () => zoom(node));
+        breadcrumbs.appendChild(link);
+    });
+}
+
+function renderBundleList() {
+    bundleList.innerHTML = "";
+    root.children.forEach(node => {
+        const bundle = node.bundle;
+        const item = document.createElement("div");
+        item.className = "bundle";
+
+        const name = document.createElement("div");
+        name.className = "name";
+        name.textContent = bundle.module;
+        name.addEventListener("click", () => zoom(node));
+        item.appendChild(name);
+
+        const size = document.createElement("div");
+        size.textContent = `${formatByteSize(bundle.raw)}, ${formatByteSize(bundle.gzip)} gzipped, ${bundle.files.length} files`;
+        if (overBudget(bundle)) {
+            size.className = "over-budget";
+            size.textContent += " (over budget)";
+        }
+        item.appendChild(size);
+
+        if (bundle.excludes.length > 0) {
+            const excludes = document.createElement("div");
+            excludes.className = "excludes";
+            excludes.appendChild(document.createTextNode("excludes "));
+            bundle.excludes.forEach((moduleName, i) => {
+                if (i > 0) {
+                    excludes.appendChild(document.createTextNode(", "));
+                }
+                const link = document.createElement("a");
+                link.textContent = moduleName;
+                link.addEventListener("click", () => zoom(findNode(root, bundle.build + ":" + moduleName)));
+                excludes.appendChild(link);
+            });
+            item.appendChild(excludes);
+        }
+        bundleList.appendChild(item);
+    });
+}
+
+function zoom(node: TreeNode) {
+    if (!node) {
+        return;
+    }
+    zoomed = node;
+    renderBreadcrumbs();
+    renderTreemap();
+}
+
+/** Fetches the bundles, keeping the zoomed box if it still exists after a rebuild */
+function refresh() {
+    fetch(dataURL)
+        .then(response => response.json())
+        .then((data: AnalyzeData) => {
+            const zoomedPath = zoomed ? zoomed.path : "";
+            root = buildTree(data.bundles);
+            let node: TreeNode = null;
+            for (let path = zoomedPath; !node && path; path = path.substring(0, Math.max(0, path.lastIndexOf("/")))) {
+                node = findNode(root, path);
+            }
+            renderBundleList();
+            zoom(node || root);
+            if (data.live && !socket) {
+                socket = new SocketClient();
+                socket.on(e => e.type == "analyze" && refresh());
+                socket.connect();
+            }
+        })
+        .catch(err => status.textContent = "Failed to load " + dataURL + ": " + err);
+}
+
+search.addEventListener("input", () => renderTreemap());
+window.addEventListener("resize", () => renderTreemap());
+refresh();
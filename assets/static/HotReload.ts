@@ -23,9 +23,68 @@ function reloadCSS(e: SocketPayload) {
     }
 }
 
+interface ConsolePayloadData {
+    level: string;
+    url: string;
+    message: string;
+}
+
+function describeError(error: Error): string {
+    const stack = error.stack ? String(error.stack) : "";
+    const description = String(error);
+    if (!stack) {
+        return description;
+    }
+    return stack.indexOf(description) === 0 ? stack : description + "\n" + stack;
+}
+
+function describe(arg: any): string {
+    if (arg instanceof Error) {
+        return describeError(arg);
+    }
+    if (typeof arg === "string") {
+        return arg;
+    }
+    try {
+        const json = JSON.stringify(arg);
+        return json === undefined ? String(arg) : json;
+    }
+    catch (e) {
+        return String(arg);
+    }
+}
+
+function sendConsole(level: string, message: string) {
+    // swarm prints these in the terminal, mapped back to the original sources
+    const data: ConsolePayloadData = { level, url: window.location.href, message };
+    sc.send("console", JSON.stringify(data));
+}
+
+function forwardConsole(level: "error" | "warn") {
+    const original = console[level];
+    console[level] = (...args: any[]) => {
+        sendConsole(level, args.map(describe).join(" "));
+        original.apply(console, args);
+    };
+}
+
+function forwardError(e: ErrorEvent) {
+    const message = e.error ? describeError(e.error) : `${e.message}\n    at ${e.filename}:${e.lineno}:${e.colno}`;
+    sendConsole("uncaught", message);
+}
+
+function forwardRejection(e: PromiseRejectionEvent) {
+    sendConsole("uncaught", "(in promise) " + describe(e.reason));
+}
+
 const sc = new SocketClient();
 sc.on(e => {
     e.type == "reload-css" && reloadCSS(e);
     e.type == "reload" && window.location.reload();
 });
-sc.connect();
\ No newline at end of file
+sc.connect();
+
+forwardConsole("error");
+forwardConsole("warn");
+window.addEventListener("error", forwardError);
+window.addEventListener("unhandledrejection", forwardRejection);
\ No newline at end of file
@@ -14,18 +14,26 @@ export interface SocketPayload {
     data: string
 }
 
+/** Messages sent before the socket is open are queued, up to this limit */
+const maxPendingMessages = 100;
+
+/** The original console.error, so that socket errors aren't forwarded back through the socket */
+const logError = console.error.bind(console);
+
 export type OnOpenFn = (client: SocketClient) => void;
 
 export class SocketClient {
 	url: string;
 	emitter: EventEmitter<SocketPayload>;
 	client: WebSocket;
+	pending: string[] = [];
 
 	constructor() {
-		const port = window.location.port;
+		// wss when the page is served over https; host includes the port, unless it's the default
 		const protocol = location.protocol === "https:" ? "wss://" : "ws://";
-		const domain = location.hostname || "localhost";
-		this.url = `${protocol}${domain}:${port}/__swarm__/ws`;
+		const host = location.host || "localhost";
+		// the page path tells the server which build this page belongs to, so it only gets that build's reloads
+		this.url = `${protocol}${host}/__swarm__/ws?page=${encodeURIComponent(location.pathname)}`;
 		this.emitter = new EventEmitter();
 	}
 	reconnect() {
@@ -44,16 +52,25 @@ export class SocketClient {
 		}, 0);
     }
     
-	send(eventName, data) {
-		if (this.client.readyState === 1) {
-			this.client.send(JSON.stringify({ event: eventName, data: data || {} }));
+	send(type: string, data: string) {
+		const message = JSON.stringify(<SocketPayload>{ type, data });
+		if (this.client && this.client.readyState === WebSocket.OPEN) {
+			this.client.send(message);
+		} else if (this.pending.length < maxPendingMessages) {
+			this.pending.push(message);
 		}
 	}
 
+	private flush() {
+		const pending = this.pending;
+		this.pending = [];
+		pending.forEach(message => this.client.send(message));
+	}
+
 	/** Wires up the socket client messages to be emitted on our event emitter */
 	private bindEvents() {
-		this.client.onopen = event => { console.log("%cConnected", "color: #237abe"); this.client.onclose = (event: CloseEvent) => this.reconnect(); };
-		this.client.onerror = (event: any) => console.error(event);
+		this.client.onopen = event => { console.log("%cConnected", "color: #237abe"); this.client.onclose = (event: CloseEvent) => this.reconnect(); this.flush(); };
+		this.client.onerror = (event: any) => logError(event);
 		this.client.onmessage = (event: MessageEvent) => event.data && this.emitter.emit(<SocketPayload>JSON.parse(event.data));
 	}
 }
\ No newline at end of file
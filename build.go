@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"github.com/mrcrowl/swarm/bundle"
+	"github.com/mrcrowl/swarm/config"
+)
+
+// runBuild bundles the modules of a build once, reporting any files bundled by more than one module and any circular
+// dependencies.  It fails if a module exceeds its size budget or, with --strict, if there are cycles that aren't
+// listed in the knownCycles of the build description.
+func runBuild(cmd *command, args []string) int {
+	if len(args) != 0 {
+		cmd.printUsage()
+		return 2
+	}
+
+	_, runtimeConfig, _, moduleSet := loadBuild(buildArgs())
+	fmt.Println("Building...")
+	moduleSet.NotifyChanges(nil)
+
+	if duplicates := moduleSet.Duplicates(); len(duplicates) > 0 {
+		fmt.Println("WARNING: Some files are bundled by more than one module (check the excludes of the build):")
+		printDuplicates(duplicates)
+	}
+
+	exitCode := 0
+	overBudget := 0
+	for _, size := range moduleSet.Sizes() {
+		if len(size.OverBudget()) > 0 {
+			overBudget++
+		}
+	}
+	if overBudget > 0 {
+		fmt.Printf("Build failed: %d bundles exceed their module's size budget\n", overBudget)
+		exitCode = 1
+	}
+
+	newCycles := printCycles(moduleSet.Cycles(), loadBuildDescription(runtimeConfig))
+	if *strictFlag && newCycles > 0 {
+		fmt.Printf("Build failed: %d new circular dependencies (list them in the knownCycles of the build description to allow them)\n", newCycles)
+		exitCode = 1
+	}
+	return exitCode
+}
+
+// printCycles lists each circular dependency, with the edges of the new ones, returning how many are new
+func printCycles(cycles []*bundle.Cycle, build *config.BuildDescription) int {
+	newCycles := 0
+	for _, cycle := range cycles {
+		where := "between modules"
+		if cycle.Module != "" {
+			where = fmt.Sprintf("in %s between files", cycle.Module)
+		}
+		if build.IsKnownCycle(cycle.IDs) {
+			fmt.Printf("Known circular dependency %s: %s\n", where, cycle)
+			continue
+		}
+		newCycles++
+		fmt.Printf("WARNING: Circular dependency %s: %s\n", where, cycle)
+		for _, edge := range cycle.Edges {
+			fmt.Printf("   %s > %s\n", edge.From, edge.To)
+		}
+	}
+	return newCycles
+}
@@ -30,10 +30,14 @@ func (nf ByFilepath) Less(i, j int) bool {
 }
 
 // Bundle concatenates files in a FileSet into a single file
-func (b *Bundler) Bundle(fileset *source.FileSet, runtimeConfig *config.RuntimeConfig, entryPointPath string) (javascript string, sourcemap string) {
+func (b *Bundler) Bundle(fileset *source.FileSet, runtimeConfig *config.RuntimeConfig, entryPointPath string) (javascript string, sourcemap string, regions []*devtools.SourceMapRegion) {
 	var jsBuilder strings.Builder
 	entryPointFilename := path.Base(entryPointPath)
 	mapBuilder := devtools.NewSourceMapBuilder(entryPointFilename, fileset.Count())
+	if runtimeConfig != nil {
+		mapBuilder.SetSourceRoot(runtimeConfig.SourceRoot)
+		mapBuilder.SetPathRewriter(runtimeConfig.RewriteSourcePath)
+	}
 
 	// sort by filepath
 	files := fileset.Files()
@@ -59,6 +63,7 @@ func (b *Bundler) Bundle(fileset *source.FileSet, runtimeConfig *config.RuntimeC
 	}
 	javascript = jsBuilder.String()
 	sourcemap = mapBuilder.String()
+	regions = mapBuilder.Regions()
 	return
 }
 
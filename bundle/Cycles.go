@@ -0,0 +1,73 @@
+package bundle
+
+import (
+	"strings"
+	"github.com/mrcrowl/swarm/graph"
+	"github.com/mrcrowl/swarm/source"
+)
+
+// Cycle is a circular dependency, either between modules (via their excludes), or between the files of a bundle
+type Cycle struct {
+	Module string        // the bundle with the circular imports, or empty for a cycle between modules
+	IDs    []string      // sorted
+	Edges  []*graph.Edge // the dependencies between the IDs, which form one or more cycles
+}
+
+func (cycle *Cycle) String() string {
+	return strings.Join(cycle.IDs, ", ")
+}
+
+// Cycles finds the circular dependencies between the modules of the build, then between the files of each bundle.
+// Modules are built in an order that breaks their cycles, and the browser tolerates circular imports, so neither is
+// fatal, but a cycle between modules makes that order arbitrary, and a cycle between files makes the order in which
+// they're executed depend on which one is imported first.
+func (set *ModuleSet) Cycles() []*Cycle {
+	set.mutex.Lock()
+	defer set.mutex.Unlock()
+
+	cycles := findCycles("", set.linksMap(), nil)
+	for _, mod := range set.bundles() {
+		cycles = append(cycles, findCycles(mod.Name(), mod.fileset.Links(), mod.fileset.Contains)...)
+	}
+	return cycles
+}
+
+// findCycles finds the cycles in a graph, ignoring any links to IDs that aren't included (if given)
+func findCycles(moduleName string, links map[string][]string, included func(id string) bool) []*Cycle {
+	if included != nil {
+		includedLinks := make(map[string][]string, len(links))
+		for from, tos := range links {
+			if !included(from) {
+				continue
+			}
+			for _, to := range tos {
+				if included(to) {
+					includedLinks[from] = append(includedLinks[from], to)
+				}
+			}
+		}
+		links = includedLinks
+	}
+
+	var cycles []*Cycle
+	for _, ids := range source.NewIDGraph(links).Cycles() {
+		members := make(map[string]bool, len(ids))
+		for _, id := range ids {
+			members[id] = true
+		}
+		cycleLinks := map[string][]string{}
+		for _, from := range ids {
+			for _, to := range links[from] {
+				if members[to] {
+					cycleLinks[from] = append(cycleLinks[from], to)
+				}
+			}
+		}
+		cycles = append(cycles, &Cycle{
+			Module: moduleName,
+			IDs:    ids,
+			Edges:  graph.NewGraph(moduleName, ids, cycleLinks, nil).Edges,
+		})
+	}
+	return cycles
+}
@@ -0,0 +1,83 @@
+package bundle
+
+import (
+	"os"
+	"strings"
+)
+
+// Duplicate is a file that's bundled by more than one module, so each extra copy costs its size
+type Duplicate struct {
+	ID     string
+	Size   int
+	Chains []*ImportChain // one per module, in the order the modules are built
+}
+
+// ImportChain is the chain of imports that pulled a file into a module, starting at one of its entry points
+type ImportChain struct {
+	Module string
+	IDs    []string // empty if the chain couldn't be found
+}
+
+func (chain *ImportChain) String() string {
+	if len(chain.IDs) == 0 {
+		return "(import chain not found)"
+	}
+	return strings.Join(chain.IDs, " > ")
+}
+
+// Cost gets the bytes wasted by the extra copies of the file
+func (duplicate *Duplicate) Cost() int {
+	return duplicate.Size * (len(duplicate.Chains) - 1)
+}
+
+// Duplicates finds the files that are bundled by more than one module, e.g. because of a missing exclude
+func (set *ModuleSet) Duplicates() []*Duplicate {
+	set.mutex.Lock()
+	defer set.mutex.Unlock()
+
+	duplicates := []*Duplicate{}
+	for _, shared := range findSharedFiles(set.modules) {
+		duplicate := &Duplicate{ID: shared.ID}
+		for _, name := range shared.Modules {
+			mod := set.getModule(name)
+			if duplicate.Size == 0 {
+				duplicate.Size = fileSize(mod.GetFileByPath(shared.ID).Filepath)
+			}
+			duplicate.Chains = append(duplicate.Chains, &ImportChain{Module: name, IDs: mod.importChain(shared.ID)})
+		}
+		duplicates = append(duplicates, duplicate)
+	}
+	return duplicates
+}
+
+// importChain finds the chain of imports from the module's entry points to a file
+func (mod *Module) importChain(id string) []string {
+	if chains := mod.importChains(id); len(chains) > 0 {
+		return chains[0]
+	}
+	return nil
+}
+
+// importChains finds the shortest chain of imports from each of the module's entry points to a file
+func (mod *Module) importChains(id string) [][]string {
+	var chains [][]string
+	seen := map[string]bool{}
+	for _, entryPoint := range append([]string{mod.PrimaryEntryPoint()}, mod.entryPoints...) {
+		if seen[entryPoint] {
+			continue
+		}
+		seen[entryPoint] = true
+		if chain := mod.fileset.ImportChain(entryPoint, id); chain != nil {
+			chains = append(chains, chain)
+		}
+	}
+	return chains
+}
+
+func fileSize(filepath string) int {
+	info, err := os.Stat(filepath)
+	if err != nil {
+		return 0
+	}
+	return int(info.Size())
+}
@@ -4,10 +4,16 @@ import (
 	"fmt"
 	"log"
 	"path"
+	"path/filepath"
+	"sort"
+	"strings"
 	"github.com/mrcrowl/swarm/config"
 	"github.com/mrcrowl/swarm/dep"
+	"github.com/mrcrowl/swarm/devtools"
+	"github.com/mrcrowl/swarm/graph"
 	"github.com/mrcrowl/swarm/monitor"
 	"github.com/mrcrowl/swarm/source"
+	"github.com/mrcrowl/swarm/util"
 )
 
 // Module is a container for managing part of a build
@@ -18,8 +24,17 @@ type Module struct {
 	excludedModules   []*Module
 	bundledJavascript string
 	bundledSourcemap  string
+	bundledRegions    []*devtools.SourceMapRegion
+	bundledConsumer   *devtools.SourceMapConsumer
+	generation        int
+	javascript        *artefact
+	sourcemap         *artefact
 	bundler           *Bundler
 	runtimeConfig     *config.RuntimeConfig
+	resolver          *source.ImportResolver
+	set               *ModuleSet         // the set the module (or the module it's a chunk of) belongs to
+	parent            *Module            // set for chunks
+	chunks            map[string]*Module // bundles of files imported on demand, keyed by the imported file's ID
 }
 
 // NewModule creates a new Module from a NormalisedModuleDescripion
@@ -33,6 +48,7 @@ func NewModule(ws *source.Workspace, descr *config.NormalisedModuleDescription,
 		bundledJavascript: "",
 		bundler:           NewBundler(),
 		runtimeConfig:     runtimeConfig,
+		chunks:            map[string]*Module{},
 	}
 }
 
@@ -75,11 +91,20 @@ func (mod *Module) excludedFilesets() []*source.FileSet {
 
 func (mod *Module) buildInitialFileSet() {
 	excludedFilesets := mod.excludedFilesets()
-	fileset := dep.BuildFileSet(mod.fileset.Workspace(), mod.PrimaryEntryPoint(), excludedFilesets, mod.runtimeConfig.ImportPathInterpolationValues())
+	fileset := dep.BuildFileSet(mod.fileset.Workspace(), mod.PrimaryEntryPoint(), excludedFilesets, mod.runtimeConfig.ImportPathInterpolationValues(), mod.resolver)
 	for _, entryPoint := range mod.entryPoints {
-		dep.UpdateFileset(fileset, entryPoint, excludedFilesets, mod.runtimeConfig.ImportPathInterpolationValues())
+		dep.UpdateFileset(fileset, entryPoint, excludedFilesets, mod.runtimeConfig.ImportPathInterpolationValues(), mod.resolver)
 	}
 	mod.fileset = fileset
+	mod.reportExternals(fileset.Externals())
+	mod.followLazyImports()
+}
+
+// reportExternals lists imports that are left for SystemJS to load, since they aren't in the workspace
+func (mod *Module) reportExternals(externals []string) {
+	if len(externals) > 0 {
+		fmt.Printf("  External: /%s.js imports %s (not bundled)\n", mod.PrimaryEntryPoint(), strings.Join(externals, ", "))
+	}
 }
 
 // absorbChanges absorbs an EventChangeset, triggering artefacts to be recompiled, when necessary
@@ -89,15 +114,135 @@ func (mod *Module) absorbChanges(changes *monitor.EventChangeset) {
 	for _, entryPoint := range changes.Changes() {
 		entryPointRelativePath, ok := ws.ToRelativePath(entryPoint.AbsoluteFilepath())
 		if ok {
-			dep.UpdateFileset(mod.fileset, entryPointRelativePath, excludedFilesets, mod.runtimeConfig.ImportPathInterpolationValues())
+			externals := dep.UpdateFileset(mod.fileset, entryPointRelativePath, excludedFilesets, mod.runtimeConfig.ImportPathInterpolationValues(), mod.resolver)
+			mod.reportExternals(externals)
+		}
+	}
+	mod.followLazyImports()
+	for _, chunk := range mod.chunks {
+		chunk.absorbChanges(changes)
+	}
+}
+
+// followLazyImports bundles the files that are imported on demand in chunks, if the module opts in to them.
+// Otherwise, they're only recorded as lazy edges, and SystemJS loads them on demand, as it would without swarm.
+func (mod *Module) followLazyImports() {
+	if mod.description.Chunks {
+		mod.updateChunks()
+	}
+}
+
+// updateChunks creates a chunk for each file imported on demand that the module (or the modules it excludes)
+// doesn't already bundle.  Each chunk is served in place of the imported file, so SystemJS loads it on demand.
+func (mod *Module) updateChunks() {
+	var created []*Module
+	for _, id := range mod.fileset.LazyImports() {
+		if mod.hasChunk(id) || mod.fileset.Contains(id) || mod.isExcluded(id) || mod.isBundledElsewhere(id) {
+			continue
 		}
+		chunk := mod.newChunk(id)
+		mod.chunks[id] = chunk
+		created = append(created, chunk)
+	}
+
+	// chunks are built once they've all been created, so they don't duplicate each other
+	for _, chunk := range created {
+		chunk.buildInitialFileSet()
 	}
 }
 
+// newChunk creates a chunk for a file imported on demand, which excludes the files already bundled by its parent
+func (mod *Module) newChunk(id string) *Module {
+	descr := &config.NormalisedModuleDescription{
+		ModuleDescription: config.ModuleDescription{Name: id, Chunks: true},
+		RelativePath:      id,
+		AbsoluteFilepath:  filepath.Join(mod.fileset.Workspace().RootPath(), filepath.FromSlash(id)),
+	}
+	chunk := NewModule(mod.fileset.Workspace(), descr, mod.runtimeConfig)
+	chunk.excludedModules = append([]*Module{mod}, mod.excludedModules...)
+	chunk.resolver = mod.resolver
+	chunk.parent = mod
+	chunk.set = mod.set
+	return chunk
+}
+
+// hasChunk gets whether a chunk for a file already exists, in this module or any it's a chunk of
+func (mod *Module) hasChunk(id string) bool {
+	for m := mod; m != nil; m = m.parent {
+		if m.chunks[id] != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// isExcluded gets whether a file is bundled by one of the modules this module excludes
+func (mod *Module) isExcluded(id string) bool {
+	for _, excl := range mod.excludedModules {
+		if excl.fileset.Contains(id) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBundledElsewhere gets whether a file is the entry point of one of the set's modules, or is bundled by a module
+// other than the one this module is (or is a chunk of)
+func (mod *Module) isBundledElsewhere(id string) bool {
+	if mod.set == nil {
+		return false
+	}
+	root := mod
+	for root.parent != nil {
+		root = root.parent
+	}
+	for _, other := range mod.set.modules {
+		if other.PrimaryEntryPoint() == id || (other != root && other.fileset.Contains(id)) {
+			return true
+		}
+	}
+	return false
+}
+
+// withChunks lists the module followed by its chunks (and theirs), i.e. every bundle that the module produces
+func (mod *Module) withChunks() []*Module {
+	bundles := []*Module{mod}
+	ids := make([]string, 0, len(mod.chunks))
+	for id := range mod.chunks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		bundles = append(bundles, mod.chunks[id].withChunks()...)
+	}
+	return bundles
+}
+
 func (mod *Module) generateBundle() {
-	mod.bundledJavascript, mod.bundledSourcemap = mod.bundler.Bundle(mod.fileset, mod.runtimeConfig, mod.PrimaryEntryPoint())
+	mod.bundledJavascript, mod.bundledSourcemap, mod.bundledRegions = mod.bundler.Bundle(mod.fileset, mod.runtimeConfig, mod.PrimaryEntryPoint())
+	mod.bundledConsumer = nil
+	mod.generation++
+	mod.createArtefacts()
 	mod.fileset.ClearDirty()
-	fmt.Printf("   Bundled: /%s.js (%d files)\n", mod.PrimaryEntryPoint(), mod.fileset.Count())
+	fmt.Printf("   Bundled: /%s.js (%d files, %s, %s gzipped, %s brotli)\n", mod.PrimaryEntryPoint(), mod.fileset.Count(),
+		util.FormatByteSize(len(mod.javascript.contents)), util.FormatByteSize(len(mod.javascript.gzipped)),
+		util.FormatByteSize(len(mod.javascript.brotli)))
+	for _, exceeded := range mod.description.Budget.Exceeded(len(mod.javascript.contents), len(mod.javascript.gzipped)) {
+		fmt.Printf("   WARNING: /%s.js is over budget: %s\n", mod.PrimaryEntryPoint(), exceeded)
+	}
+}
+
+// createArtefacts prepares the bundle & source map for serving, with the bundle's sourceMappingURL appended
+func (mod *Module) createArtefacts() {
+	filename := path.Base(mod.PrimaryEntryPoint()) + ".js"
+	var sourceMappingURL string
+	if mod.runtimeConfig.InlineSourceMapsEnabled() {
+		sourceMappingURL = source.EncodeSourceMapDataURI(mod.bundledSourcemap)
+	} else {
+		sourceMappingURL = mod.SourceMapName()
+	}
+	mod.javascript = newArtefact(filename, mod.bundledJavascript+"//# sourceMappingURL="+sourceMappingURL, mod.generation)
+	mod.sourcemap = newArtefact(filename+".map", mod.bundledSourcemap, mod.generation)
 }
 
 func (mod *Module) links() []string {
@@ -117,3 +262,50 @@ func (mod *Module) attachExcludedModules(set *ModuleSet) {
 		mod.excludedModules = append(mod.excludedModules, excludedModule)
 	}
 }
+
+// FileGraph creates a graph of the files in the module, including the imports made on demand
+func (mod *Module) FileGraph() *graph.Graph {
+	ids := make([]string, 0, mod.fileset.Count())
+	for _, file := range mod.fileset.Files() {
+		ids = append(ids, file.ID)
+	}
+	return graph.NewGraph(mod.Name(), ids, mod.fileset.Links(), mod.fileset.LazyLinks())
+}
+
+// VerifySourceMap checks a sample of mappings per file in the module's compiled source map
+func (mod *Module) VerifySourceMap(samplesPerFile int) ([]*devtools.SourceMapReport, error) {
+	return devtools.VerifySourceMap(mod.bundledSourcemap, mod.bundledRegions, mod.readOriginalSource, samplesPerFile)
+}
+
+// readOriginalSource reads a source listed in the module's compiled source map, resolving it the way a browser
+// would: relative to the bundle's URL (and sourceRoot, if configured), with the workspace root served at "/"
+func (mod *Module) readOriginalSource(sourcePath string) ([]string, bool) {
+	if strings.Contains(sourcePath, "://") {
+		return nil, false
+	}
+	urlPath := sourcePath
+	if !strings.HasPrefix(sourcePath, "/") {
+		urlPath = path.Join(path.Dir("/"+mod.PrimaryEntryPoint()), mod.runtimeConfig.SourceRoot, sourcePath)
+		if strings.HasPrefix(mod.runtimeConfig.SourceRoot, "/") {
+			urlPath = path.Join(mod.runtimeConfig.SourceRoot, sourcePath)
+		}
+	}
+	absoluteFilepath := filepath.Join(mod.fileset.Workspace().RootPath(), filepath.FromSlash(urlPath))
+	contents, err := util.ReadContents(absoluteFilepath)
+	if err != nil {
+		return nil, false
+	}
+	return util.StringToLines(contents), true
+}
+
+// sourceMapConsumer decodes the module's compiled source map for lookups, caching it until the next bundle
+func (mod *Module) sourceMapConsumer() *devtools.SourceMapConsumer {
+	if mod.bundledConsumer == nil && mod.bundledSourcemap != "" {
+		config, err := source.ParseSourceMapConfig(mod.bundledSourcemap)
+		if err != nil {
+			return nil
+		}
+		mod.bundledConsumer = devtools.NewSourceMapConsumer(config, "/"+mod.PrimaryEntryPoint()+".js.map")
+	}
+	return mod.bundledConsumer
+}
@@ -2,19 +2,28 @@ package bundle
 
 import (
 	"fmt"
-	"io"
 	"log"
 	"net/http"
+	"path"
+	"path/filepath"
+	"sort"
 	"strings"
 	"github.com/mrcrowl/swarm/config"
+	"github.com/mrcrowl/swarm/devtools"
+	"github.com/mrcrowl/swarm/graph"
 	"github.com/mrcrowl/swarm/monitor"
 	"github.com/mrcrowl/swarm/source"
+	"github.com/mrcrowl/swarm/util"
 	"sync"
 )
 
+const systemJSConfigFilename = "systemjs.config.js"
+
 // ModuleSet is
 type ModuleSet struct {
 	modules       []*Module
+	sharedChunks  []*Module
+	bundleURLs    map[string]*Module // the bundles' javascript & source maps, keyed by URL
 	mutex         *sync.Mutex
 	runtimeConfig *config.RuntimeConfig
 }
@@ -28,19 +37,27 @@ func CreateModuleSet(ws *source.Workspace, moduleDescriptions []*config.Normalis
 	}
 
 	set := &ModuleSet{
-		modules: modules,
-		mutex:   &sync.Mutex{},
+		modules:       modules,
+		mutex:         &sync.Mutex{},
+		runtimeConfig: runtimeConfig,
 	}
 
 	for _, mod := range set.modules {
+		mod.set = set
 		mod.attachExcludedModules(set)
 	}
 
 	set.sort()
 
+	systemJSResolver := set.loadSystemJSResolver(ws)
 	for _, mod := range set.modules {
+		mod.resolver = source.NewImportResolver(systemJSResolver, mod.description.Packages, mod.description.Externals)
 		mod.buildInitialFileSet()
 	}
+	set.hoistSharedChunks(ws)
+	if err := set.indexBundleURLs(); err != nil {
+		log.Panicf("CreateModuleSet: %s", err)
+	}
 
 	return set
 }
@@ -52,23 +69,34 @@ func (set *ModuleSet) NotifyChanges(changes *monitor.EventChangeset) {
 		for _, mod := range set.modules {
 			mod.absorbChanges(changes)
 		}
+		for _, chunk := range set.sharedChunks {
+			chunk.absorbChanges(changes)
+		}
+		if err := set.indexBundleURLs(); err != nil {
+			log.Printf("ERROR: %s", err)
+		}
 	}
 
 	// TODO: could this be parallelised?
-	for _, mod := range set.modules {
+	for _, mod := range set.bundles() {
 		if mod.dirty() {
 			mod.generateBundle()
 			if changes != nil {
-				changes.FlagDidBundle()
+				changes.FlagDidBundle(set.BaseHref())
 			}
 		}
 	}
 	set.mutex.Unlock()
 }
 
+// BaseHref gets the base href of the build that the modules belong to
+func (set *ModuleSet) BaseHref() string {
+	return set.runtimeConfig.BaseHref
+}
+
 // FindFileByPath finds and returns a file by path name
 func (set *ModuleSet) FindFileByPath(path string) *source.File {
-	for _, mod := range set.modules {
+	for _, mod := range set.bundles() {
 		if file := mod.GetFileByPath(path); file != nil {
 			return file
 		}
@@ -76,6 +104,73 @@ func (set *ModuleSet) FindFileByPath(path string) *source.File {
 	return nil
 }
 
+// FindModule finds a module by name, returning nil if it doesn't exist
+func (set *ModuleSet) FindModule(name string) *Module {
+	for _, mod := range set.modules {
+		if mod.Name() == name {
+			return mod
+		}
+	}
+	return nil
+}
+
+// ModuleNames gets the names of all modules, in the order they are built
+func (set *ModuleSet) ModuleNames() []string {
+	return set.names()
+}
+
+// ModuleGraph creates a graph of the modules (and shared chunks), where each module links to those it excludes
+func (set *ModuleSet) ModuleGraph() *graph.Graph {
+	set.mutex.Lock()
+	defer set.mutex.Unlock()
+	var names []string
+	for _, mod := range set.bundles() {
+		if mod.parent == nil {
+			names = append(names, mod.Name())
+		}
+	}
+	return graph.NewGraph(set.BaseHref(), names, set.linksMap(), nil)
+}
+
+// SymbolicateStackTrace maps the positions in a browser stack trace back to their original sources, using the
+// compiled source maps of the bundles, or the upstream source maps of individual files
+func (set *ModuleSet) SymbolicateStackTrace(stackTrace string) string {
+	return ModuleSets{set}.SymbolicateStackTrace(stackTrace)
+}
+
+func (set *ModuleSet) sourceMapConsumer(scriptURLPath string) *devtools.SourceMapConsumer {
+	for _, mod := range set.bundles() {
+		if scriptURLPath == "/"+mod.PrimaryEntryPoint()+".js" {
+			return mod.sourceMapConsumer()
+		}
+	}
+
+	if path.Ext(scriptURLPath) != ".js" {
+		return nil
+	}
+	file := set.FindFileByPath(strings.TrimPrefix(strings.TrimSuffix(scriptURLPath, ".js"), "/"))
+	if file == nil {
+		return nil
+	}
+	file.EnsureLoaded(set.runtimeConfig)
+	sourceMappingURL := file.RawContents().SourceMappingURL()
+	if sourceMappingURL == "" {
+		return nil
+	}
+
+	// a fresh mapping, so the file's own (bundle-relative) mapping isn't disturbed
+	mapping := source.NewMapping(sourceMappingURL, file.ID, filepath.Join(filepath.Dir(file.Filepath), filepath.FromSlash(sourceMappingURL)))
+	mapping.EnsureLoaded()
+	if mapping.Config() == nil {
+		return nil
+	}
+	sourceMapURLPath := scriptURLPath
+	if !mapping.IsInline() {
+		sourceMapURLPath = path.Join(path.Dir(scriptURLPath), sourceMappingURL)
+	}
+	return devtools.NewSourceMapConsumer(mapping.Config(), sourceMapURLPath)
+}
+
 func (set *ModuleSet) getModule(name string) *Module {
 	for _, mod := range set.modules {
 		if mod.description.Name == name {
@@ -86,6 +181,15 @@ func (set *ModuleSet) getModule(name string) *Module {
 	return nil
 }
 
+// bundles lists the shared chunks, then every module, each followed by its chunks
+func (set *ModuleSet) bundles() []*Module {
+	bundles := append([]*Module(nil), set.sharedChunks...)
+	for _, mod := range set.modules {
+		bundles = append(bundles, mod.withChunks()...)
+	}
+	return bundles
+}
+
 // names gets the module names (sorted topographical, assuming CreateModuleSet has finished!)
 func (set *ModuleSet) names() []string {
 	names := make([]string, len(set.modules))
@@ -113,30 +217,166 @@ func (set *ModuleSet) sort() {
 	set.modules = sortedModules
 }
 
-// GenerateHTTPHandlers creates http.HandlerFunc's that will return the bundled javascript
-func (set *ModuleSet) GenerateHTTPHandlers() map[string]http.HandlerFunc {
-	createJSHandler := func(module *Module) http.HandlerFunc {
-		return func(w http.ResponseWriter, r *http.Request) {
-			io.WriteString(w, module.bundledJavascript)
-			io.WriteString(w, fmt.Sprintf("//# sourceMappingURL=%s", module.SourceMapName()))
+// loadSystemJSResolver parses the build's systemjs.config.js, as the browser will see it, for resolving bare and
+// aliased imports.  Builds without one don't resolve them.
+func (set *ModuleSet) loadSystemJSResolver(ws *source.Workspace) *source.SystemJSResolver {
+	configFilepath := filepath.Join(ws.RootPath(), set.BaseHref(), systemJSConfigFilename)
+	configJS, err := util.ReadContents(configFilepath)
+	if err != nil {
+		return nil
+	}
+	systemJSConfig, ok := source.ParseSystemJSConfig(set.RewriteSystemJSConfig(configJS))
+	if !ok {
+		log.Printf("ERROR: Failed to parse System.config({...}) in: %s", configFilepath)
+		return nil
+	}
+	return source.NewSystemJSResolver(systemJSConfig, set.BaseHref())
+}
+
+// RewriteSystemJSConfig adapts the build's systemjs.config.js to the bundles: the build's text rewrites are applied,
+// each module's name is mapped to its bundle and each bundled package import (e.g. "lodash") to the file it resolved
+// to (unless the config already maps them), the shared chunks are listed as bundles, then the build's own map and
+// paths overrides are applied.  Modules whose names prefix other imports (e.g. "common" of "common/util") aren't
+// mapped, as SystemJS would send those imports to the module's bundle too.  If the config's sections can't be edited,
+// only the text rewrites are applied.
+func (set *ModuleSet) RewriteSystemJSConfig(configJS string) string {
+	configJS, err := set.runtimeConfig.RewriteSystemJSConfigText(configJS)
+	if err != nil {
+		log.Printf("ERROR: %s", err)
+	}
+
+	systemJSConfig, ok := source.ParseSystemJSConfig(configJS)
+	if !ok {
+		return configJS
+	}
+	set.mutex.Lock()
+	prefixed := set.importPrefixes(systemJSConfig)
+	for _, mod := range set.modules {
+		if _, mapped := systemJSConfig.Get("map", mod.Name()); !mapped && !prefixed[mod.Name()] {
+			systemJSConfig.Set("map", mod.Name(), "/"+mod.PrimaryEntryPoint()+".js")
+		}
+		for _, bundled := range mod.withChunks() {
+			resolvedImports := bundled.fileset.ResolvedImports()
+			for _, specifier := range sortedKeys(resolvedImports) {
+				if _, mapped := systemJSConfig.Get("map", specifier); !mapped {
+					systemJSConfig.Set("map", specifier, "/"+resolvedImports[specifier]+".js")
+				}
+			}
 		}
 	}
+	for _, chunk := range set.sharedChunks {
+		systemJSConfig.SetList("bundles", "/"+chunk.PrimaryEntryPoint()+".js", chunk.sharedChunkContents())
+	}
+	set.mutex.Unlock()
+	for _, key := range sortedKeys(set.runtimeConfig.SystemJSMap) {
+		systemJSConfig.Set("map", key, set.runtimeConfig.SystemJSMap[key])
+	}
+	for _, key := range sortedKeys(set.runtimeConfig.SystemJSPaths) {
+		systemJSConfig.Set("paths", key, set.runtimeConfig.SystemJSPaths[key])
+	}
+	if err := systemJSConfig.CheckOverrides(); err != nil {
+		log.Printf("ERROR: %s", err)
+		return configJS
+	}
+	return systemJSConfig.String()
+}
 
-	createMapHandler := func(module *Module) http.HandlerFunc {
-		return func(w http.ResponseWriter, r *http.Request) {
-			sourceMap := module.bundledSourcemap
-			sourceMap = strings.Replace(sourceMap, `["BaseController.ts"]`, `["ui/base/BaseController.ts"]`, 1)
-			io.WriteString(w, sourceMap)
+// importPrefixes finds the path prefixes (e.g. "common" and "common/ui" of "common/ui/Button") of the config's map
+// and paths keys, the build's own overrides and the bundled package imports
+func (set *ModuleSet) importPrefixes(systemJSConfig *source.SystemJSConfig) map[string]bool {
+	keys := []string{}
+	for _, entries := range []map[string]string{systemJSConfig.Entries("map"), systemJSConfig.Entries("paths"),
+		set.runtimeConfig.SystemJSMap, set.runtimeConfig.SystemJSPaths} {
+		keys = append(keys, sortedKeys(entries)...)
+	}
+	for _, mod := range set.modules {
+		for _, bundled := range mod.withChunks() {
+			keys = append(keys, sortedKeys(bundled.fileset.ResolvedImports())...)
 		}
 	}
 
-	handlers := map[string]http.HandlerFunc{}
-	for _, module := range set.modules {
-		entryPoint := module.PrimaryEntryPoint()
-		handlers["/"+entryPoint+".js"] = createJSHandler(module)
-		if set.runtimeConfig.SourceMapsEnabled() {
-			handlers["/"+entryPoint+".js.map"] = createMapHandler(module)
+	prefixes := map[string]bool{}
+	for _, key := range keys {
+		for i, c := range key {
+			if c == '/' && i > 0 {
+				prefixes[key[:i]] = true
+			}
 		}
 	}
+	return prefixes
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// GenerateHTTPHandlers creates http.HandlerFunc's that will return the bundled javascript, for the bundles that
+// exist at startup.  Chunks created later are served by ServeBundle.
+func (set *ModuleSet) GenerateHTTPHandlers() map[string]http.HandlerFunc {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if !set.ServeBundle(w, r) {
+			http.NotFound(w, r)
+		}
+	}
+
+	handlers := map[string]http.HandlerFunc{}
+	set.mutex.Lock()
+	for url := range set.bundleURLs {
+		handlers[url] = handler
+	}
+	set.mutex.Unlock()
 	return handlers
 }
+
+// ServeBundle serves the bundle (or source map) at the request's URL, if one of the modules, chunks or shared chunks
+// produces it.  Bundles are looked up per request, so chunks created after startup are served, too.
+func (set *ModuleSet) ServeBundle(w http.ResponseWriter, r *http.Request) bool {
+	set.mutex.Lock()
+	bundled, found := set.bundleURLs[r.URL.Path]
+	var served *artefact
+	if found {
+		served = bundled.javascript
+		if strings.HasSuffix(r.URL.Path, ".map") {
+			served = bundled.sourcemap
+		}
+	}
+	set.mutex.Unlock()
+	if !found {
+		return false
+	}
+
+	// artefacts are immutable, so they can be served without holding the lock
+	if served == nil {
+		http.Error(w, "Not built yet", http.StatusServiceUnavailable)
+		return true
+	}
+	served.ServeHTTP(w, r)
+	return true
+}
+
+// indexBundleURLs maps the URLs of the bundles (and their source maps, unless they're inlined) to the bundles, which
+// must be repeated whenever chunks may have been created.  If two bundles claim the same URL, the first keeps it.
+func (set *ModuleSet) indexBundleURLs() error {
+	serveSourceMaps := set.runtimeConfig.SourceMapsEnabled() && !set.runtimeConfig.InlineSourceMapsEnabled()
+	var err error
+	set.bundleURLs = map[string]*Module{}
+	for _, bundled := range set.bundles() {
+		url := "/" + bundled.PrimaryEntryPoint() + ".js"
+		if claimant := set.bundleURLs[url]; claimant != nil {
+			if err == nil {
+				err = fmt.Errorf("%s is produced by both %s and %s", url, claimant.Name(), bundled.Name())
+			}
+			continue
+		}
+		set.bundleURLs[url] = bundled
+		if serveSourceMaps {
+			set.bundleURLs[url+".map"] = bundled
+		}
+	}
+	return err
+}
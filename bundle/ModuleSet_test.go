@@ -1,12 +1,18 @@
 package bundle
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"testing"
 
 	"github.com/mrcrowl/swarm/config"
+	"github.com/mrcrowl/swarm/graph"
+	"github.com/mrcrowl/swarm/monitor"
 	"github.com/mrcrowl/swarm/source"
 	"github.com/mrcrowl/swarm/testutil"
 
+	"github.com/rjeczalik/notify"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -62,3 +68,461 @@ func TestCreateModuleSet(t *testing.T) {
 // 	set := CreateModuleSet(createWorkspace(), descr.NormaliseModules("c:\\wf\\lp\\web\\App"), nil)
 // 	assert.Equal(t, "controlPanel/ControlPanel", set.names()[0], "controlPanel/ControlPanel should be the first module")
 // }
+
+func TestRewriteSystemJSConfig(t *testing.T) {
+	descr, _ := config.LoadBuildDescriptionString(`{"modules": [{"name": "ep/App"}, {"name": "ep/Admin"}], "base": "app/src/"}`)
+	workspacePath := testutil.CreateTempDir()
+	defer testutil.RemoveTempDir(workspacePath)
+	testutil.WriteTextFile(workspacePath, "Config.js", "")
+	runtimeConfig := config.NewRuntimeConfig("", "app")
+	runtimeConfig.SystemJSMap = map[string]string{"services": "/services", "common": "/common"}
+	runtimeConfig.SystemJSPaths = map[string]string{"npm:": "/node_modules/"}
+	set := CreateModuleSet(source.NewWorkspace(workspacePath), descr.NormaliseModules(workspacePath), runtimeConfig)
+
+	configJS := `System.config({
+	map: {
+		"ep/Admin": "./src/ep/Admin.js",
+		common: "./common",
+	}
+});`
+	expected := `System.config({
+	paths: {
+		"npm:": "/node_modules/",
+	}, /* <-- ADDED BY SWARM */
+	map: {
+		"ep/App": "/app/src/ep/App.js", /* <-- ADDED BY SWARM */
+		"services": "/services", /* <-- ADDED BY SWARM */
+		"ep/Admin": "./src/ep/Admin.js",
+		common: "/common", /* <-- REWRITTEN BY SWARM */
+	}
+});`
+	assert.Equal(t, expected, set.RewriteSystemJSConfig(configJS))
+}
+
+func TestRewriteSystemJSConfigKeepsPrefixMappings(t *testing.T) {
+	descr, _ := config.LoadBuildDescriptionString(`{"modules": [{"name": "common"}, {"name": "App"}], "base": "app/src/"}`)
+	workspacePath := testutil.CreateTempDir()
+	defer testutil.RemoveTempDir(workspacePath)
+	testutil.WriteTextFile(workspacePath, "Config.js", "")
+	set := CreateModuleSet(source.NewWorkspace(workspacePath), descr.NormaliseModules(workspacePath), config.NewRuntimeConfig("", "app"))
+
+	configJS := `System.config({ paths: { "common/*": "./lib/common/*" } });`
+	expected := `System.config({
+map: {
+	"App": "/app/src/App.js",
+}, /* <-- ADDED BY SWARM */ paths: { "common/*": "./lib/common/*" } });`
+	assert.Equal(t, expected, set.RewriteSystemJSConfig(configJS))
+}
+
+func TestRewriteSystemJSConfigLeavesUneditableSections(t *testing.T) {
+	descr, _ := config.LoadBuildDescriptionString(`{"modules": [{"name": "App"}], "base": "app/src/"}`)
+	workspacePath := testutil.CreateTempDir()
+	defer testutil.RemoveTempDir(workspacePath)
+	testutil.WriteTextFile(workspacePath, "Config.js", "")
+	runtimeConfig := config.NewRuntimeConfig("", "app")
+	runtimeConfig.SystemJSPaths = map[string]string{"npm:": "/node_modules/"}
+	set := CreateModuleSet(source.NewWorkspace(workspacePath), descr.NormaliseModules(workspacePath), runtimeConfig)
+
+	configJS := `System.config({ map: appMap });`
+	assert.Equal(t, configJS, set.RewriteSystemJSConfig(configJS))
+}
+
+func TestNestedNodeModulesAreRequiredByURL(t *testing.T) {
+	workspacePath := testutil.CreateTempDir()
+	defer testutil.RemoveTempDir(workspacePath)
+	testutil.WriteTextFile(workspacePath, "Config.js", "")
+	testutil.WriteTextFile(testutil.MakeSubdirectoryTree(workspacePath, "app/src"), "App.js", "System.register([\"lodash\", \"a\"], function (exports_1, context_1) {\n});")
+	testutil.WriteTextFile(testutil.MakeSubdirectoryTree(workspacePath, "node_modules/lodash"), "index.js", "module.exports = 4;")
+	testutil.WriteTextFile(testutil.MakeSubdirectoryTree(workspacePath, "node_modules/a"), "index.js", "module.exports = require('lodash');")
+	testutil.WriteTextFile(testutil.MakeSubdirectoryTree(workspacePath, "node_modules/a/node_modules/lodash"), "index.js", "module.exports = 3;")
+	descr, _ := config.LoadBuildDescriptionString(`{"modules": [{"name": "App", "packages": true}], "base": "app/src/"}`)
+	set := CreateModuleSet(source.NewWorkspace(workspacePath), descr.NormaliseModules(workspacePath), config.NewRuntimeConfig("", "app"))
+	set.NotifyChanges(nil)
+
+	assert.Contains(t, set.FindModule("App").javascript.contents,
+		`System.register("node_modules/a/index.js", ["/node_modules/a/node_modules/lodash/index.js"],`)
+	expected := `System.config({
+map: {
+	"App": "/app/src/App.js",
+	"a": "/node_modules/a/index.js",
+	"lodash": "/node_modules/lodash/index.js",
+}, /* <-- ADDED BY SWARM */ });`
+	assert.Equal(t, expected, set.RewriteSystemJSConfig(`System.config({ });`))
+}
+
+func createLazyLoadingWorkspace(chunks bool) (*ModuleSet, func()) {
+	workspacePath := testutil.CreateTempDir()
+	testutil.WriteTextFile(workspacePath, "Config.js", "")
+	srcPath := testutil.MakeSubdirectoryTree(workspacePath, "app/src")
+	testutil.WriteTextFile(srcPath, "App.js", "System.register([\"./Shared\"], function (exports_1, context_1) {\n"+
+		"    var load = function () { return context_1.import(\"./admin/Admin\"); };\n});")
+	testutil.WriteTextFile(srcPath, "Shared.js", "System.register([], function (exports_1, context_1) {\n});")
+	adminPath := testutil.MakeSubdirectoryTree(srcPath, "admin")
+	testutil.WriteTextFile(adminPath, "Admin.js", "System.register([\"../Shared\", \"./AdminOnly\"], function (exports_1, context_1) {\n});")
+	testutil.WriteTextFile(adminPath, "AdminOnly.js", "System.register([], function (exports_1, context_1) {\n});")
+
+	descr, _ := config.LoadBuildDescriptionString(`{"modules": [{"name": "App"}], "base": "app/src/"}`)
+	descr.Chunks = chunks
+	set := CreateModuleSet(source.NewWorkspace(workspacePath), descr.NormaliseModules(workspacePath), config.NewRuntimeConfig("", "app"))
+	return set, func() { testutil.RemoveTempDir(workspacePath) }
+}
+
+func TestLazyImportsAreNotBundledWithModule(t *testing.T) {
+	set, cleanup := createLazyLoadingWorkspace(false)
+	defer cleanup()
+
+	mod := set.FindModule("App")
+	assert.True(t, mod.fileset.Contains("app/src/App"))
+	assert.True(t, mod.fileset.Contains("app/src/Shared"))
+	assert.False(t, mod.fileset.Contains("app/src/admin/Admin"), "loaded on demand, as it would be without swarm")
+	assert.False(t, mod.fileset.Contains("app/src/admin/AdminOnly"))
+	assert.Equal(t, []string{"app/src/admin/Admin"}, mod.fileset.LazyImports())
+	assert.Empty(t, mod.chunks)
+}
+
+func TestLazyImportsOfOtherModules(t *testing.T) {
+	for _, chunks := range []bool{false, true} {
+		workspacePath := testutil.CreateTempDir()
+		defer testutil.RemoveTempDir(workspacePath)
+		testutil.WriteTextFile(workspacePath, "Config.js", "")
+		srcPath := testutil.MakeSubdirectoryTree(workspacePath, "app/src")
+		testutil.WriteTextFile(srcPath, "App.js", "System.register([\"./Shared\"], function (exports_1, context_1) {\n"+
+			"    var load = function () { return context_1.import(\"./admin/Admin\"); };\n});")
+		testutil.WriteTextFile(srcPath, "Shared.js", "System.register([], function (exports_1, context_1) {\n});")
+		adminPath := testutil.MakeSubdirectoryTree(srcPath, "admin")
+		testutil.WriteTextFile(adminPath, "Admin.js", "System.register([\"../Shared\", \"./AdminOnly\"], function (exports_1, context_1) {\n});")
+		testutil.WriteTextFile(adminPath, "AdminOnly.js", "System.register([], function (exports_1, context_1) {\n});")
+
+		descr, _ := config.LoadBuildDescriptionString(`{"modules": [{"name": "App"}, {"name": "admin/Admin", "exclude": ["App"]}], "base": "app/src/"}`)
+		descr.Chunks = chunks
+		set := CreateModuleSet(source.NewWorkspace(workspacePath), descr.NormaliseModules(workspacePath), config.NewRuntimeConfig("", "app"))
+
+		// the lazily imported file is the Admin module's entry point, so it's bundled by that module alone
+		app := set.FindModule("App")
+		admin := set.FindModule("admin/Admin")
+		assert.False(t, app.fileset.Contains("app/src/admin/Admin"))
+		assert.False(t, app.fileset.Contains("app/src/admin/AdminOnly"))
+		assert.Empty(t, app.chunks)
+		assert.True(t, admin.fileset.Contains("app/src/admin/AdminOnly"))
+		assert.Empty(t, set.Duplicates())
+		assert.Len(t, set.GenerateHTTPHandlers(), 4)
+	}
+}
+
+func TestIndexBundleURLsRejectsClashes(t *testing.T) {
+	set, cleanup := createLazyLoadingWorkspace(true)
+	defer cleanup()
+
+	app := set.FindModule("App")
+	chunk := app.chunks["app/src/admin/Admin"]
+	clash := app.newChunk("app/src/admin/Admin")
+	chunk.chunks["app/src/admin/Admin"] = clash
+	assert.EqualError(t, set.indexBundleURLs(), "/app/src/admin/Admin.js is produced by both app/src/admin/Admin and app/src/admin/Admin")
+	assert.Equal(t, chunk, set.bundleURLs["/app/src/admin/Admin.js"], "the first bundle keeps the URL")
+}
+
+func TestLazyImportsAreChunked(t *testing.T) {
+	set, cleanup := createLazyLoadingWorkspace(true)
+	defer cleanup()
+
+	mod := set.FindModule("App")
+	assert.False(t, mod.fileset.Contains("app/src/admin/Admin"))
+	chunk := mod.chunks["app/src/admin/Admin"]
+	if assert.NotNil(t, chunk) {
+		assert.True(t, chunk.fileset.Contains("app/src/admin/Admin"))
+		assert.True(t, chunk.fileset.Contains("app/src/admin/AdminOnly"))
+		assert.False(t, chunk.fileset.Contains("app/src/Shared"), "shared with the module, so not in the chunk")
+	}
+
+	set.NotifyChanges(nil)
+	handlers := set.GenerateHTTPHandlers()
+	assert.Contains(t, handlers, "/app/src/App.js")
+	assert.Contains(t, handlers, "/app/src/admin/Admin.js")
+	assert.Contains(t, chunk.javascript.contents, `System.register("app/src/admin/AdminOnly.js"`)
+}
+
+func TestLazyImportAddedAfterStartupIsServed(t *testing.T) {
+	set, cleanup := createLazyLoadingWorkspace(true)
+	defer cleanup()
+	set.NotifyChanges(nil)
+	handlers := set.GenerateHTTPHandlers()
+
+	srcPath := filepath.Join(set.FindModule("App").fileset.Workspace().RootPath(), "app", "src")
+	reportsPath := testutil.MakeSubdirectoryTree(srcPath, "reports")
+	testutil.WriteTextFile(reportsPath, "Reports.js", "System.register([\"../Shared\"], function (exports_1, context_1) {\n});")
+	testutil.WriteTextFile(srcPath, "App.js", "System.register([\"./Shared\"], function (exports_1, context_1) {\n"+
+		"    var load = function () { return context_1.import(\"./admin/Admin\"); };\n"+
+		"    var report = function () { return context_1.import(\"./reports/Reports\"); };\n});")
+	changes := monitor.NewEventChangeset()
+	changes.Add(notify.Write, filepath.Join(srcPath, "App.js"))
+	set.NotifyChanges(changes)
+
+	assert.NotContains(t, handlers, "/app/src/reports/Reports.js")
+	recorder := httptest.NewRecorder()
+	assert.True(t, set.ServeBundle(recorder, httptest.NewRequest("GET", "/app/src/reports/Reports.js", nil)))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `System.register("app/src/reports/Reports.js"`)
+	assert.NotContains(t, recorder.Body.String(), `System.register("app/src/Shared.js"`)
+
+	assert.False(t, set.ServeBundle(httptest.NewRecorder(), httptest.NewRequest("GET", "/app/src/Shared.js", nil)))
+}
+
+func createSharingWorkspace(sharedChunks bool) (*ModuleSet, func()) {
+	workspacePath := writeSharingWorkspace()
+	descr, _ := config.LoadBuildDescriptionString(`{"modules": [{"name": "App"}, {"name": "Admin"}], "base": "app/src/"}`)
+	descr.SharedChunks = sharedChunks
+	set := CreateModuleSet(source.NewWorkspace(workspacePath), descr.NormaliseModules(workspacePath), config.NewRuntimeConfig("", "app"))
+	return set, func() { testutil.RemoveTempDir(workspacePath) }
+}
+
+func writeSharingWorkspace() string {
+	workspacePath := testutil.CreateTempDir()
+	testutil.WriteTextFile(workspacePath, "Config.js", "")
+	srcPath := testutil.MakeSubdirectoryTree(workspacePath, "app/src")
+	testutil.WriteTextFile(srcPath, "App.js", "System.register([\"./Shared\"], function (exports_1, context_1) {\n});")
+	testutil.WriteTextFile(srcPath, "Admin.js", "System.register([\"./Shared\", \"./AdminOnly\"], function (exports_1, context_1) {\n});")
+	testutil.WriteTextFile(srcPath, "AdminOnly.js", "System.register([], function (exports_1, context_1) {\n});")
+	testutil.WriteTextFile(srcPath, "Shared.js", "System.register([\"./Util\"], function (exports_1, context_1) {\n});")
+	testutil.WriteTextFile(srcPath, "Util.js", "System.register([], function (exports_1, context_1) {\n});")
+	return workspacePath
+}
+
+func TestSharedFiles(t *testing.T) {
+	set, cleanup := createSharingWorkspace(false)
+	defer cleanup()
+
+	shared := set.SharedFiles()
+	if assert.Len(t, shared, 2) {
+		assert.Equal(t, "app/src/Shared", shared[0].ID)
+		assert.ElementsMatch(t, []string{"App", "Admin"}, shared[0].Modules)
+		assert.Equal(t, "app/src/Util", shared[1].ID)
+	}
+	assert.Empty(t, set.sharedChunks)
+}
+
+func TestHoistSharedChunks(t *testing.T) {
+	set, cleanup := createSharingWorkspace(true)
+	defer cleanup()
+
+	assert.Empty(t, set.SharedFiles())
+	if !assert.Len(t, set.sharedChunks, 1) {
+		return
+	}
+	chunk := set.sharedChunks[0]
+	assert.Equal(t, "app/__shared__/Admin+App", chunk.PrimaryEntryPoint())
+	assert.Equal(t, []string{"app/src/Shared.js", "app/src/Util.js"}, chunk.sharedChunkContents())
+	assert.False(t, set.FindModule("App").fileset.Contains("app/src/Shared"))
+	assert.True(t, set.FindModule("Admin").fileset.Contains("app/src/AdminOnly"))
+
+	set.NotifyChanges(nil)
+	assert.Contains(t, set.GenerateHTTPHandlers(), "/app/__shared__/Admin+App.js")
+	expected := `System.config({
+bundles: {
+	"/app/__shared__/Admin+App.js": ["app/src/Shared.js", "app/src/Util.js"],
+}, /* <-- ADDED BY SWARM */
+map: {
+	"Admin": "/app/src/Admin.js",
+	"App": "/app/src/App.js",
+}, /* <-- ADDED BY SWARM */ });`
+	assert.Equal(t, expected, set.RewriteSystemJSConfig(`System.config({ });`))
+}
+
+func TestHoistSharedChunksForExcludingModules(t *testing.T) {
+	workspacePath := writeSharingWorkspace()
+	defer testutil.RemoveTempDir(workspacePath)
+	srcPath := filepath.Join(workspacePath, "app", "src")
+	testutil.WriteTextFile(srcPath, "Reports.js", "System.register([\"./Shared\"], function (exports_1, context_1) {\n});")
+	descr, _ := config.LoadBuildDescriptionString(`{"modules": [
+		{"name": "App", "sharedChunks": true},
+		{"name": "Admin", "sharedChunks": true},
+		{"name": "Reports", "exclude": ["App"]}
+	], "base": "app/src/"}`)
+	set := CreateModuleSet(source.NewWorkspace(workspacePath), descr.NormaliseModules(workspacePath), config.NewRuntimeConfig("", "app"))
+
+	// Reports excludes App, so its import of ./Shared is served by the shared chunk, even once it's been edited
+	reports := set.FindModule("Reports")
+	assert.False(t, reports.fileset.Contains("app/src/Shared"))
+	assert.Contains(t, reports.excludedModules, set.sharedChunks[0])
+
+	testutil.WriteTextFile(srcPath, "Reports.js", "System.register([\"./Shared\", \"./Util\"], function (exports_1, context_1) {\n});")
+	changes := monitor.NewEventChangeset()
+	changes.Add(notify.Write, filepath.Join(srcPath, "Reports.js"))
+	set.NotifyChanges(changes)
+	assert.True(t, reports.fileset.Contains("app/src/Reports"))
+	assert.False(t, reports.fileset.Contains("app/src/Shared"))
+	assert.False(t, reports.fileset.Contains("app/src/Util"))
+}
+
+func TestDuplicates(t *testing.T) {
+	set, cleanup := createSharingWorkspace(false)
+	defer cleanup()
+
+	duplicates := set.Duplicates()
+	if !assert.Len(t, duplicates, 2) {
+		return
+	}
+	util := duplicates[1]
+	assert.Equal(t, "app/src/Util", util.ID)
+	assert.Equal(t, 57, util.Size)
+	assert.Equal(t, 57, util.Cost())
+	assert.ElementsMatch(t, []*ImportChain{
+		{Module: "App", IDs: []string{"app/src/App", "app/src/Shared", "app/src/Util"}},
+		{Module: "Admin", IDs: []string{"app/src/Admin", "app/src/Shared", "app/src/Util"}},
+	}, util.Chains)
+
+	hoisted, cleanupHoisted := createSharingWorkspace(true)
+	defer cleanupHoisted()
+	assert.Empty(t, hoisted.Duplicates())
+}
+
+func TestGraphs(t *testing.T) {
+	set, cleanup := createSharingWorkspace(true)
+	defer cleanup()
+
+	moduleGraph := set.ModuleGraph()
+	assert.Len(t, moduleGraph.Nodes, 3)
+	assert.Equal(t, []*graph.Edge{
+		{From: "Admin", To: "app/__shared__/Admin+App"},
+		{From: "App", To: "app/__shared__/Admin+App"},
+	}, moduleGraph.Edges)
+
+	fileGraph := set.FindModule("Admin").FileGraph()
+	assert.Equal(t, "Admin", fileGraph.Name)
+	assert.Equal(t, []*graph.Edge{
+		{From: "app/src/Admin", To: "app/src/AdminOnly"},
+		{From: "app/src/Admin", To: "app/src/Shared"}, // imported from the shared chunk
+	}, fileGraph.Edges)
+}
+
+func TestWhy(t *testing.T) {
+	set, cleanup := createSharingWorkspace(false)
+	defer cleanup()
+
+	assert.ElementsMatch(t, []*ImportChain{
+		{Module: "App", IDs: []string{"app/src/App", "app/src/Shared", "app/src/Util"}},
+		{Module: "Admin", IDs: []string{"app/src/Admin", "app/src/Shared", "app/src/Util"}},
+	}, set.Why("/app/src/Util.js", ""))
+	assert.Equal(t, []*ImportChain{
+		{Module: "Admin", IDs: []string{"app/src/Admin", "app/src/AdminOnly"}},
+	}, set.Why("app/src/AdminOnly.ts", ""))
+	assert.Empty(t, set.Why("app/src/AdminOnly", "App"))
+	assert.Empty(t, set.Why("app/src/Missing.js", ""))
+
+	explanation, found := ModuleSets{set}.ExplainImport("app/src/AdminOnly.js", "Admin")
+	assert.True(t, found)
+	assert.Equal(t, "Admin: app/src/Admin > app/src/AdminOnly\n", explanation)
+}
+
+func TestCycles(t *testing.T) {
+	workspacePath := testutil.CreateTempDir()
+	defer testutil.RemoveTempDir(workspacePath)
+	testutil.WriteTextFile(workspacePath, "Config.js", "")
+	srcPath := testutil.MakeSubdirectoryTree(workspacePath, "app/src")
+	testutil.WriteTextFile(srcPath, "App.js", "System.register([\"./Users\"], function (exports_1, context_1) {\n});")
+	testutil.WriteTextFile(srcPath, "Users.js", "System.register([\"./Groups\"], function (exports_1, context_1) {\n});")
+	testutil.WriteTextFile(srcPath, "Groups.js", "System.register([\"./Users\"], function (exports_1, context_1) {\n});")
+	testutil.WriteTextFile(srcPath, "Admin.js", "System.register([], function (exports_1, context_1) {\n});")
+
+	descr, _ := config.LoadBuildDescriptionString(`{"modules": [
+		{"name": "App", "exclude": ["Admin"]},
+		{"name": "Admin", "exclude": ["App"]}
+	], "base": "app/src/"}`)
+	set := CreateModuleSet(source.NewWorkspace(workspacePath), descr.NormaliseModules(workspacePath), config.NewRuntimeConfig("", "app"))
+
+	cycles := set.Cycles()
+	assert.Len(t, cycles, 2)
+	assert.Equal(t, "", cycles[0].Module)
+	assert.Equal(t, []string{"Admin", "App"}, cycles[0].IDs)
+	assert.Equal(t, []*graph.Edge{{From: "Admin", To: "App"}, {From: "App", To: "Admin"}}, cycles[0].Edges)
+	assert.Equal(t, "App", cycles[1].Module)
+	assert.Equal(t, []string{"app/src/Groups", "app/src/Users"}, cycles[1].IDs)
+	assert.Equal(t, []*graph.Edge{{From: "app/src/Groups", To: "app/src/Users"}, {From: "app/src/Users", To: "app/src/Groups"}}, cycles[1].Edges)
+}
+
+func TestSizes(t *testing.T) {
+	set, cleanup := createSharingWorkspace(false)
+	defer cleanup()
+	set.getModule("App").description.Budget = &config.SizeBudget{Raw: 10}
+	set.NotifyChanges(nil)
+
+	sizes := set.Sizes()
+	assert.Len(t, sizes, 2)
+	for _, size := range sizes {
+		assert.True(t, size.Raw > 0)
+		assert.True(t, size.Gzip > 0)
+		total := 0
+		for _, dir := range size.Directories() {
+			for _, file := range dir.Files {
+				total += file.Size
+			}
+			assert.True(t, dir.Size > 0)
+		}
+		fileTotal := 0
+		for _, file := range size.Files {
+			fileTotal += file.Size
+		}
+		assert.Equal(t, fileTotal, total)
+	}
+
+	for _, size := range sizes {
+		if size.Module == "App" {
+			assert.Len(t, size.OverBudget(), 1)
+		} else {
+			assert.Empty(t, size.OverBudget())
+		}
+	}
+}
+
+func TestUnusedFiles(t *testing.T) {
+	set, cleanup := createSharingWorkspace(false)
+	defer cleanup()
+	rootPath := set.modules[0].fileset.Workspace().RootPath()
+	srcPath := filepath.Join(rootPath, "app", "src")
+	testutil.WriteTextFile(filepath.Join(rootPath, "app"), "index.html", "<html></html>")
+	testutil.WriteTextFile(srcPath, "Dead.js", "System.register([], function (exports_1, context_1) {\n});")
+	testutil.WriteTextFile(srcPath, "Dead.spec.js", "")
+	testutil.WriteTextFile(srcPath, "dead.css", "")
+	testutil.WriteTextFile(srcPath, "notes.txt", "")
+	legacyPath := testutil.MakeSubdirectoryTree(srcPath, "legacy")
+	testutil.WriteTextFile(legacyPath, "Old.js", "")
+	modulesPath := testutil.MakeSubdirectoryTree(rootPath, "node_modules/lib")
+	testutil.WriteTextFile(modulesPath, "index.js", "")
+
+	unused, err := ModuleSets{set}.UnusedFiles(rootPath, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, []*FileSize{
+		{ID: "app/src/Dead.js", Size: 57},
+		{ID: "app/src/Dead.spec.js", Size: 0},
+		{ID: "app/src/dead.css", Size: 0},
+		{ID: "app/src/legacy/Old.js", Size: 0},
+	}, unused)
+
+	unused, err = ModuleSets{set}.UnusedFiles(rootPath, []string{"*.spec.js", "app/src/legacy", "*.css"})
+	assert.Nil(t, err)
+	assert.Equal(t, []*FileSize{{ID: "app/src/Dead.js", Size: 57}}, unused)
+}
+
+func TestIsIgnored(t *testing.T) {
+	cases := map[string]struct {
+		path     string
+		ignore   []string
+		expected bool
+	}{
+		"no globs":        {"app/src/App.js", nil, false},
+		"file name":       {"app/src/App.spec.js", []string{"*.spec.js"}, true},
+		"directory name":  {"app/src/legacy/Old.js", []string{"legacy"}, true},
+		"path":            {"app/src/legacy/Old.js", []string{"app/src/legacy"}, true},
+		"path with slash": {"app/src/legacy/Old.js", []string{"app/src/legacy/"}, true},
+		"path glob":       {"app/src/legacy/Old.js", []string{"app/*/legacy/*.js"}, true},
+		"partial path":    {"app/src/legacy/Old.js", []string{"src/legacy"}, false},
+		"partial name":    {"app/src/legacy/Old.js", []string{"leg"}, false},
+		"one of many":     {"app/src/App.js", []string{"*.css", "App.js"}, true},
+		"invalid glob":    {"app/src/App.js", []string{"[App.js"}, false},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, c.expected, IsIgnored(c.path, c.ignore))
+		})
+	}
+}
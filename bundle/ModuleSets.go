@@ -0,0 +1,35 @@
+package bundle
+
+import (
+	"net/http"
+	"github.com/mrcrowl/swarm/devtools"
+)
+
+// ModuleSets is a group of ModuleSets (one per build) served together
+type ModuleSets []*ModuleSet
+
+// SymbolicateStackTrace maps the positions in a browser stack trace back to their original sources, using
+// the source maps of whichever build serves each script
+func (sets ModuleSets) SymbolicateStackTrace(stackTrace string) string {
+	return devtools.SymbolicateStackTrace(stackTrace, func(scriptURLPath string) *devtools.SourceMapConsumer {
+		for _, set := range sets {
+			set.mutex.Lock()
+			consumer := set.sourceMapConsumer(scriptURLPath)
+			set.mutex.Unlock()
+			if consumer != nil {
+				return consumer
+			}
+		}
+		return nil
+	})
+}
+
+// ServeBundle serves the bundle (or source map) at the request's URL, from whichever build produces it
+func (sets ModuleSets) ServeBundle(w http.ResponseWriter, r *http.Request) bool {
+	for _, set := range sets {
+		if set.ServeBundle(w, r) {
+			return true
+		}
+	}
+	return false
+}
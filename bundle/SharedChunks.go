@@ -0,0 +1,167 @@
+package bundle
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"github.com/mrcrowl/swarm/config"
+	"github.com/mrcrowl/swarm/source"
+)
+
+const sharedChunksDirname = "__shared__"
+
+// SharedFile is a file that's bundled by more than one module
+type SharedFile struct {
+	ID      string
+	Modules []string // names, in the order the modules are built
+}
+
+// SharedFiles finds the files that are bundled by more than one module, ordered by ID
+func (set *ModuleSet) SharedFiles() []*SharedFile {
+	set.mutex.Lock()
+	defer set.mutex.Unlock()
+	return findSharedFiles(set.modules)
+}
+
+func findSharedFiles(modules []*Module) []*SharedFile {
+	sharedBy := map[string][]string{}
+	for _, mod := range modules {
+		for _, file := range mod.fileset.Files() {
+			sharedBy[file.ID] = append(sharedBy[file.ID], mod.Name())
+		}
+	}
+
+	shared := []*SharedFile{}
+	for id, names := range sharedBy {
+		if len(names) > 1 {
+			shared = append(shared, &SharedFile{ID: id, Modules: names})
+		}
+	}
+	sort.Slice(shared, func(i, j int) bool { return shared[i].ID < shared[j].ID })
+	return shared
+}
+
+// hoistSharedChunks moves the files shared by modules that opt in to shared chunks into a bundle per group of
+// modules, e.g. the files shared by ep/App and ep/Admin are served from /app/__shared__/ep-Admin+ep-App.js.  The
+// build's systemjs.config.js lists each shared bundle's files in its bundles section, so SystemJS loads a shared
+// bundle before any module that imports one of its files.
+func (set *ModuleSet) hoistSharedChunks(ws *source.Workspace) {
+	var optedIn []*Module
+	for _, mod := range set.modules {
+		if mod.description.SharedChunks {
+			optedIn = append(optedIn, mod)
+		}
+	}
+
+	groups := map[string][]*SharedFile{}
+	groupModules := map[string][]string{}
+	var groupNames []string
+	for _, shared := range findSharedFiles(optedIn) {
+		groupName := sharedChunkName(shared.Modules)
+		if groups[groupName] == nil {
+			groupNames = append(groupNames, groupName)
+			groupModules[groupName] = shared.Modules
+		}
+		groups[groupName] = append(groups[groupName], shared)
+	}
+	sort.Strings(groupNames)
+
+	chunkModules := map[*Module][]string{}
+	for _, groupName := range groupNames {
+		id := path.Join(strings.TrimPrefix(set.BaseHref(), "/"), sharedChunksDirname, groupName)
+		descr := &config.NormalisedModuleDescription{
+			ModuleDescription: config.ModuleDescription{Name: id},
+			RelativePath:      id,
+		}
+		chunk := NewModule(ws, descr, set.runtimeConfig)
+		chunk.set = set
+		for _, shared := range groups[groupName] {
+			for _, name := range shared.Modules {
+				if file := set.getModule(name).fileset.Remove(shared.ID); file != nil {
+					chunk.fileset.Add(file)
+				}
+			}
+		}
+		set.sharedChunks = append(set.sharedChunks, chunk)
+		chunkModules[chunk] = groupModules[groupName]
+	}
+
+	// shared files are now only in the shared chunks, so updates mustn't pull them back into the modules (or
+	// pull the modules' files into the shared chunks)
+	for _, chunk := range set.sharedChunks {
+		for _, other := range set.sharedChunks {
+			if other != chunk {
+				chunk.excludedModules = append(chunk.excludedModules, other)
+			}
+		}
+		chunk.excludedModules = append(chunk.excludedModules, optedIn...)
+		chunk.resolver = optedIn[0].resolver
+	}
+	for _, mod := range optedIn {
+		mod.excludedModules = append(mod.excludedModules, set.sharedChunks...)
+	}
+
+	// likewise, modules (and their chunks) that exclude an opted-in module, directly or transitively, no longer
+	// find its shared files in its bundle, so they must exclude the shared chunks holding them instead
+	for _, mod := range set.modules {
+		for _, bundled := range mod.withChunks() {
+			excluded := bundled.transitivelyExcludedModules()
+			for _, chunk := range set.sharedChunks {
+				if bundled.excludes(chunk) {
+					continue
+				}
+				for _, name := range chunkModules[chunk] {
+					if excluded[set.getModule(name)] {
+						bundled.excludedModules = append(bundled.excludedModules, chunk)
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+// excludes gets whether a module directly excludes another
+func (mod *Module) excludes(other *Module) bool {
+	for _, excl := range mod.excludedModules {
+		if excl == other {
+			return true
+		}
+	}
+	return false
+}
+
+// transitivelyExcludedModules gets the modules that a module excludes, and the modules that they exclude, and so on
+func (mod *Module) transitivelyExcludedModules() map[*Module]bool {
+	excluded := map[*Module]bool{}
+	pending := append([]*Module(nil), mod.excludedModules...)
+	for len(pending) > 0 {
+		excl := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if !excluded[excl] {
+			excluded[excl] = true
+			pending = append(pending, excl.excludedModules...)
+		}
+	}
+	return excluded
+}
+
+// sharedChunkName names a shared chunk after the modules sharing it, e.g. "ep-Admin+ep-App"
+func sharedChunkName(moduleNames []string) string {
+	names := make([]string, len(moduleNames))
+	for i, name := range moduleNames {
+		names[i] = strings.Replace(name, "/", "-", -1)
+	}
+	sort.Strings(names)
+	return strings.Join(names, "+")
+}
+
+// sharedChunkContents lists the modules registered by a shared chunk, for the bundles section of the SystemJS config
+func (mod *Module) sharedChunkContents() []string {
+	var registered []string
+	for _, file := range mod.fileset.Files() {
+		registered = append(registered, file.ID+".js")
+	}
+	sort.Strings(registered)
+	return registered
+}
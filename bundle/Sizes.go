@@ -0,0 +1,100 @@
+package bundle
+
+import (
+	"path"
+	"sort"
+	"github.com/mrcrowl/swarm/config"
+)
+
+// BundleSize is the size of one of the bundles of a build, with the sizes of the files in it
+type BundleSize struct {
+	Build    string             `json:"build"`  // the base href of the build
+	Module   string             `json:"module"` // the module's name, or the ID of a chunk
+	URL      string             `json:"url"`
+	Raw      int                `json:"raw"`
+	Gzip     int                `json:"gzip"`
+	Budget   *config.SizeBudget `json:"budget,omitempty"` // nil unless the module has a budget
+	Excludes []string           `json:"excludes"`         // the modules whose files aren't bundled with this one
+	Files    []*FileSize        `json:"files"`            // largest first
+}
+
+// FileSize is the size of a file's source, or the total size of the files in a directory
+type FileSize struct {
+	ID    string      `json:"id"` // the file's ID, or the directory's path
+	Size  int         `json:"size"`
+	Files []*FileSize `json:"files,omitempty"` // the files in a directory, largest first
+}
+
+// OverBudget lists the ways in which the bundle exceeds its module's budget, if any
+func (size *BundleSize) OverBudget() []string {
+	return size.Budget.Exceeded(size.Raw, size.Gzip)
+}
+
+// Directories totals the sizes of the files in each directory of the bundle, largest first
+func (size *BundleSize) Directories() []*FileSize {
+	byPath := map[string]*FileSize{}
+	dirs := []*FileSize{}
+	for _, file := range size.Files {
+		dirPath := path.Dir(file.ID)
+		dir := byPath[dirPath]
+		if dir == nil {
+			dir = &FileSize{ID: dirPath}
+			byPath[dirPath] = dir
+			dirs = append(dirs, dir)
+		}
+		dir.Size += file.Size
+		dir.Files = append(dir.Files, file)
+	}
+	sortBySize(dirs)
+	return dirs
+}
+
+// Sizes gets the size of each bundle of the build (as of its last build), in the order the bundles are built
+func (set *ModuleSet) Sizes() []*BundleSize {
+	set.mutex.Lock()
+	defer set.mutex.Unlock()
+
+	sizes := []*BundleSize{}
+	for _, mod := range set.bundles() {
+		sizes = append(sizes, mod.size())
+	}
+	return sizes
+}
+
+// Sizes gets the size of each bundle of every build
+func (sets ModuleSets) Sizes() []*BundleSize {
+	sizes := []*BundleSize{}
+	for _, set := range sets {
+		sizes = append(sizes, set.Sizes()...)
+	}
+	return sizes
+}
+
+func (mod *Module) size() *BundleSize {
+	size := &BundleSize{
+		Build:    mod.runtimeConfig.BaseHref,
+		Module:   mod.Name(),
+		URL:      "/" + mod.PrimaryEntryPoint() + ".js",
+		Budget:   mod.description.Budget,
+		Excludes: mod.links(),
+		Files:    []*FileSize{},
+	}
+	if mod.javascript != nil {
+		size.Raw = len(mod.javascript.contents)
+		size.Gzip = len(mod.javascript.gzipped)
+	}
+	for _, file := range mod.fileset.Files() {
+		size.Files = append(size.Files, &FileSize{ID: file.ID, Size: fileSize(file.Filepath)})
+	}
+	sortBySize(size.Files)
+	return size
+}
+
+func sortBySize(sizes []*FileSize) {
+	sort.Slice(sizes, func(i, j int) bool {
+		if sizes[i].Size != sizes[j].Size {
+			return sizes[i].Size > sizes[j].Size
+		}
+		return sizes[i].ID < sizes[j].ID
+	})
+}
@@ -0,0 +1,77 @@
+package bundle
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"github.com/mrcrowl/swarm/source"
+)
+
+// unusedFileExts are the kinds of file that are reported if no bundle uses them
+var unusedFileExts = map[string]bool{".js": true, ".css": true, ".html": true}
+
+// servedFilenames are the files that swarm serves directly from the base of a build, rather than bundling them
+var servedFilenames = []string{"index.html", systemJSConfigFilename}
+
+// UnusedFiles finds the .js, .css and .html files beneath the workspace root that aren't bundled by any module of
+// the builds, or served directly (e.g. the index.html of each build).  node_modules, hidden directories and any
+// paths that match the ignore globs (see IsIgnored) are skipped.  Paths are relative to the root, and sorted.
+func (sets ModuleSets) UnusedFiles(rootPath string, ignore []string) ([]*FileSize, error) {
+	rootPath = filepath.Clean(rootPath)
+	used := map[string]bool{filepath.Join(rootPath, source.ConfigFilename): true}
+	for _, set := range sets {
+		set.mutex.Lock()
+		for _, mod := range set.bundles() {
+			for _, file := range mod.fileset.Files() {
+				used[filepath.Clean(file.Filepath)] = true
+			}
+		}
+		for _, filename := range servedFilenames {
+			used[filepath.Join(rootPath, filepath.FromSlash(set.BaseHref()), filename)] = true
+		}
+		set.mutex.Unlock()
+	}
+
+	unused := []*FileSize{}
+	err := filepath.Walk(rootPath, func(absoluteFilepath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relativePath, _ := filepath.Rel(rootPath, absoluteFilepath)
+		relativePath = filepath.ToSlash(relativePath)
+		if info.IsDir() {
+			name := info.Name()
+			if relativePath != "." && (name == "node_modules" || strings.HasPrefix(name, ".") || IsIgnored(relativePath, ignore)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if unusedFileExts[strings.ToLower(filepath.Ext(absoluteFilepath))] && !used[absoluteFilepath] && !IsIgnored(relativePath, ignore) {
+			unused = append(unused, &FileSize{ID: relativePath, Size: int(info.Size())})
+		}
+		return nil
+	})
+	sort.Slice(unused, func(i, j int) bool { return unused[i].ID < unused[j].ID })
+	return unused, err
+}
+
+// IsIgnored tests whether a path (relative to the workspace root) matches one of the ignore globs (see path.Match).
+// Like .gitignore, a glob without a slash matches the name of the file or any of its directories, e.g. "*.spec.js"
+// or "legacy", whereas a glob with a slash matches the path or any of its leading directories, e.g. "app/src/old".
+func IsIgnored(relativePath string, ignore []string) bool {
+	for _, glob := range ignore {
+		glob = strings.Trim(glob, "/")
+		for p := relativePath; p != "." && p != "/" && p != ""; p = path.Dir(p) {
+			subject := p
+			if !strings.Contains(glob, "/") {
+				subject = path.Base(p)
+			}
+			if matched, err := path.Match(glob, subject); err == nil && matched {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package bundle
+
+import (
+	"path"
+	"strings"
+)
+
+// Why finds the shortest chain of imports from each entry point of each bundle (or just those of the named module)
+// to a file, to explain why the file is bundled.  A bundle that holds the file without importing it (e.g. a shared
+// chunk) has an empty chain.  The file can be given as an ID, or a URL path or filename, e.g. /app/src/Format.js.
+func (set *ModuleSet) Why(file string, moduleName string) []*ImportChain {
+	set.mutex.Lock()
+	defer set.mutex.Unlock()
+
+	bundles := set.bundles()
+	if moduleName != "" {
+		bundles = nil
+		if mod := set.FindModule(moduleName); mod != nil {
+			bundles = mod.withChunks()
+		}
+	}
+
+	id := set.fileID(file)
+	chains := []*ImportChain{}
+	for _, mod := range bundles {
+		found := mod.importChains(id)
+		for _, ids := range found {
+			chains = append(chains, &ImportChain{Module: mod.Name(), IDs: ids})
+		}
+		if len(found) == 0 && mod.fileset.Contains(id) {
+			chains = append(chains, &ImportChain{Module: mod.Name()})
+		}
+	}
+	return chains
+}
+
+// fileID finds the ID of a bundled file from its ID, URL path or filename, e.g. "/app/src/Format.js" ==> "app/src/Format"
+func (set *ModuleSet) fileID(file string) string {
+	file = strings.TrimPrefix(strings.TrimPrefix(strings.Replace(file, "\\", "/", -1), "./"), "/")
+	withoutExt := strings.TrimSuffix(file, path.Ext(file))
+	for _, candidate := range []string{file, withoutExt} {
+		if set.FindFileByPath(candidate) != nil {
+			return candidate
+		}
+	}
+	if path.Ext(file) == ".js" || path.Ext(file) == ".ts" {
+		return withoutExt
+	}
+	return file
+}
+
+// ExplainImport describes why a file is bundled by the builds, returning false if it isn't
+func (sets ModuleSets) ExplainImport(file string, moduleName string) (string, bool) {
+	var sb strings.Builder
+	for _, set := range sets {
+		for _, chain := range set.Why(file, moduleName) {
+			sb.WriteString(chain.Module + ": " + chain.String() + "\n")
+		}
+	}
+	if sb.Len() == 0 {
+		return file + " isn't bundled\n", false
+	}
+	return sb.String(), true
+}
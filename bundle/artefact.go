@@ -0,0 +1,60 @@
+package bundle
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mrcrowl/swarm/util"
+)
+
+// processID distinguishes the ETags of this swarm process from those of previous runs, whose
+// build generations would otherwise collide
+var processID = strconv.FormatInt(time.Now().UnixNano(), 36)
+
+// artefact is a compiled output of a module build (e.g. its bundle or source map), served over HTTP.
+// Artefacts are immutable: each build generation creates new ones.
+type artefact struct {
+	filename string
+	contents string
+	etag     string
+	gzipped  []byte
+	brotli   []byte
+}
+
+func newArtefact(filename string, contents string, generation int) *artefact {
+	return &artefact{
+		filename: filename,
+		contents: contents,
+		etag:     fmt.Sprintf(`"%s-%d"`, processID, generation),
+		gzipped:  util.GzipString(contents),
+		brotli:   util.BrotliString(contents),
+	}
+}
+
+// encodedETag distinguishes a compressed representation (e.g. gzip) from the uncompressed one
+func (a *artefact) encodedETag(encoding string) string {
+	return strings.TrimSuffix(a.etag, `"`) + "-" + encoding + `"`
+}
+
+// ServeHTTP writes the artefact with headers that make the browser revalidate on every use (Cache-Control: no-cache),
+// so an unchanged bundle costs a 304 rather than a full download.  The precompressed brotli or gzip representation
+// is served to browsers that accept it, preferring brotli, which is smaller.
+func (a *artefact) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	header := w.Header()
+	header.Set("Content-Type", util.MimeTypeFromFilename(a.filename))
+	header.Set("Cache-Control", "no-cache")
+	header.Add("Vary", "Accept-Encoding")
+	encoded := map[string][]byte{"br": a.brotli, "gzip": a.gzipped}
+	if encoding := util.NegotiateEncoding(r.Header.Get("Accept-Encoding"), "br", "gzip"); encoding != "" {
+		header.Set("Content-Encoding", encoding)
+		header.Set("ETag", a.encodedETag(encoding))
+		http.ServeContent(w, r, a.filename, time.Time{}, bytes.NewReader(encoded[encoding]))
+		return
+	}
+	header.Set("ETag", a.etag)
+	http.ServeContent(w, r, a.filename, time.Time{}, strings.NewReader(a.contents))
+}
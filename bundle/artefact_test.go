@@ -0,0 +1,90 @@
+package bundle
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArtefactServeHTTP(t *testing.T) {
+	first := newArtefact("App.js", "console.log(1);", 1)
+	second := newArtefact("App.js", "console.log(2);", 2)
+	assert.NotEqual(t, first.etag, second.etag)
+
+	request := httptest.NewRequest("GET", "/app/App.js", nil)
+	recorder := httptest.NewRecorder()
+	second.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "console.log(2);", recorder.Body.String())
+	assert.Equal(t, "application/javascript", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, "no-cache", recorder.Header().Get("Cache-Control"))
+	assert.Equal(t, second.etag, recorder.Header().Get("ETag"))
+
+	cases := map[string]struct {
+		ifNoneMatch string
+		expected    int
+	}{
+		"current generation":  {second.etag, http.StatusNotModified},
+		"previous generation": {first.etag, http.StatusOK},
+		"any":                 {"*", http.StatusNotModified},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			request := httptest.NewRequest("GET", "/app/App.js", nil)
+			request.Header.Set("If-None-Match", tc.ifNoneMatch)
+			recorder := httptest.NewRecorder()
+			second.ServeHTTP(recorder, request)
+			assert.Equal(t, tc.expected, recorder.Code)
+		})
+	}
+}
+
+func TestSourceMapArtefactContentType(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	newArtefact("App.js.map", "{}", 1).ServeHTTP(recorder, httptest.NewRequest("GET", "/app/App.js.map", nil))
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+}
+
+func TestArtefactServeHTTPGzip(t *testing.T) {
+	served := newArtefact("App.js", "console.log(1);", 1)
+
+	request := httptest.NewRequest("GET", "/app/App.js", nil)
+	request.Header.Set("Accept-Encoding", "gzip, deflate")
+	recorder := httptest.NewRecorder()
+	served.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "gzip", recorder.Header().Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", recorder.Header().Get("Vary"))
+	assert.Equal(t, served.gzipped, recorder.Body.Bytes())
+	assert.NotEqual(t, served.etag, recorder.Header().Get("ETag"))
+
+	request = httptest.NewRequest("GET", "/app/App.js", nil)
+	request.Header.Set("Accept-Encoding", "gzip")
+	request.Header.Set("If-None-Match", recorder.Header().Get("ETag"))
+	recorder = httptest.NewRecorder()
+	served.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+}
+
+func TestArtefactServeHTTPBrotli(t *testing.T) {
+	served := newArtefact("App.js", "console.log(1);", 1)
+
+	request := httptest.NewRequest("GET", "/app/App.js", nil)
+	request.Header.Set("Accept-Encoding", "gzip, deflate, br")
+	recorder := httptest.NewRecorder()
+	served.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "br", recorder.Header().Get("Content-Encoding"))
+	assert.Equal(t, served.brotli, recorder.Body.Bytes())
+	assert.Equal(t, served.encodedETag("br"), recorder.Header().Get("ETag"))
+	assert.NotEqual(t, served.encodedETag("gzip"), recorder.Header().Get("ETag"))
+
+	request = httptest.NewRequest("GET", "/app/App.js", nil)
+	request.Header.Set("Accept-Encoding", "br")
+	request.Header.Set("If-None-Match", recorder.Header().Get("ETag"))
+	recorder = httptest.NewRecorder()
+	served.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusNotModified, recorder.Code)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"github.com/mrcrowl/swarm/bundle"
+)
+
+// command is a sub-command that runs against a build, instead of starting the web server
+type command struct {
+	words []string // e.g. sourcemaps verify
+	usage string
+	run   func(cmd *command, args []string) int // returns the exit code
+}
+
+var commands = []*command{
+	{[]string{"serve"}, "<build>...", runServe},
+	{[]string{"build"}, "[--strict]", runBuild},
+	{[]string{"analyze", "duplicates"}, "", runAnalyzeDuplicates},
+	{[]string{"analyze", "size"}, "[--module <module>]", runAnalyzeSize},
+	{[]string{"analyze", "unused"}, "[--ignore <glob>]...", runAnalyzeUnused},
+	{[]string{"why"}, "<file> [--module <module>]", runWhy},
+	{[]string{"graph"}, "[--module <module>] [--format dot|json|mermaid] [--collapse <depth>] [--out <file>]", runGraph},
+	{[]string{"sourcemaps", "verify"}, "<module>", runSourceMapsVerify},
+}
+
+// findCommand finds the command named by the leading arguments, returning the remaining arguments
+func findCommand(args []string) (*command, []string) {
+	for _, cmd := range commands {
+		if len(args) < len(cmd.words) {
+			continue
+		}
+		matched := true
+		for i, word := range cmd.words {
+			if args[i] != word {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return cmd, args[len(cmd.words):]
+		}
+	}
+	return nil, nil
+}
+
+func (cmd *command) name() string {
+	return strings.Join(cmd.words, " ")
+}
+
+func (cmd *command) printUsage() {
+	words := []string{"swarm", cmd.name()}
+	if cmd.usage != "" {
+		words = append(words, cmd.usage)
+	}
+	if cmd.usesBuildFlag() {
+		words = append(words, "[--build <build>]")
+	}
+	fmt.Printf("Usage: %s\n", strings.Join(words, " "))
+}
+
+// usesBuildFlag indicates whether the command runs against the single build named by --build
+func (cmd *command) usesBuildFlag() bool {
+	return cmd.name() != "serve"
+}
+
+// writesToStdout indicates whether the command's output can be redirected to a file (e.g. swarm graph > graph.dot),
+// so it prints everything else to stderr
+func (cmd *command) writesToStdout() bool {
+	return cmd.name() == "graph"
+}
+
+// findModule finds a module of a build by name, listing the modules to choose from if it doesn't exist
+func findModule(moduleSet *bundle.ModuleSet, moduleName string) *bundle.Module {
+	module := moduleSet.FindModule(moduleName)
+	if module == nil {
+		fmt.Printf("Module '%s' not found.  Choose from:\n", moduleName)
+		for _, name := range moduleSet.ModuleNames() {
+			fmt.Printf("   %s\n", name)
+		}
+	}
+	return module
+}
+
+// buildArgs gets the build named by the --build flag (if any), in the form expected by ui.ChooseBuild
+func buildArgs() []string {
+	if *buildFlag != "" {
+		return []string{*buildFlag}
+	}
+	return nil
+}
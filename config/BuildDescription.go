@@ -10,8 +10,15 @@ import (
 
 // BuildDescription describes a systemjs_build file
 type BuildDescription struct {
-	Modules []*ModuleDescription `json:"modules"`
-	Base    string               `json:"base"`
+	Modules      []*ModuleDescription `json:"modules"`
+	Base         string               `json:"base"`
+	Externals    []string             `json:"externals"`
+	Packages     bool                 `json:"packages"`
+	Chunks       bool                 `json:"chunks"`
+	SharedChunks bool                 `json:"sharedChunks"`
+	// KnownCycles lists the circular dependencies that swarm build --strict accepts, each as the names of the
+	// modules or the IDs of the files involved, e.g. [["app/src/Users", "app/src/Groups"]]
+	KnownCycles [][]string `json:"knownCycles"`
 }
 
 // ModuleDescription describes a single module within a systemjs_build file
@@ -19,6 +26,18 @@ type ModuleDescription struct {
 	Name    string   `json:"name"`
 	Include []string `json:"include"`
 	Exclude []string `json:"exclude"`
+	// Externals are packages (or globs, e.g. "@angular/*") that are loaded separately, rather than bundled
+	Externals []string `json:"externals"`
+	// Packages bundles the node_modules packages that bare imports resolve to (other than the externals), instead of
+	// leaving SystemJS to load them separately
+	Packages bool `json:"packages"`
+	// Chunks splits files that are imported on demand (e.g. lazily loaded routes) into separate bundles, which are
+	// loaded when they're first imported, instead of SystemJS loading each of their files separately
+	Chunks bool `json:"chunks"`
+	// SharedChunks hoists files that are also bundled by other modules (which opt in, too) into shared bundles
+	SharedChunks bool `json:"sharedChunks"`
+	// Budget limits the size of the module's bundle: swarm build fails if it's exceeded, and swarm serve warns
+	Budget *SizeBudget `json:"budget"`
 }
 
 // NormalisedModuleDescription is a module that has paths normalised relative to the root of the workspace
@@ -54,11 +73,37 @@ func LoadBuildDescriptionString(buildFileString string) (*BuildDescription, erro
 	return description, nil
 }
 
+// IsKnownCycle checks whether all of the IDs of a cycle belong to one of the known cycles, so a known cycle that
+// shrinks is still known, but one that grows is new
+func (build *BuildDescription) IsKnownCycle(ids []string) bool {
+	for _, known := range build.KnownCycles {
+		members := make(map[string]bool, len(known))
+		for _, id := range known {
+			members[id] = true
+		}
+		allKnown := true
+		for _, id := range ids {
+			if !members[id] {
+				allKnown = false
+				break
+			}
+		}
+		if allKnown {
+			return true
+		}
+	}
+	return false
+}
+
 // NormaliseModules normalises the paths of modules relative to a root
 func (build *BuildDescription) NormaliseModules(rootPath string) []*NormalisedModuleDescription {
 	normalisedModules := make([]*NormalisedModuleDescription, len(build.Modules))
 	for i, module := range build.Modules {
 		normalisedModules[i] = module.Normalise(build.Base, rootPath)
+		normalisedModules[i].Externals = append(append([]string(nil), build.Externals...), module.Externals...)
+		normalisedModules[i].Packages = build.Packages || module.Packages
+		normalisedModules[i].Chunks = build.Chunks || module.Chunks
+		normalisedModules[i].SharedChunks = build.SharedChunks || module.SharedChunks
 	}
 	return normalisedModules
 }
@@ -81,9 +126,14 @@ func (module *ModuleDescription) Normalise(basePath string, rootPath string) *No
 
 	return &NormalisedModuleDescription{
 		ModuleDescription{
-			Name:    module.Name,
-			Include: includes,
-			Exclude: excludes,
+			Name:         module.Name,
+			Include:      includes,
+			Exclude:      excludes,
+			Externals:    append([]string(nil), module.Externals...),
+			Packages:     module.Packages,
+			Chunks:       module.Chunks,
+			SharedChunks: module.SharedChunks,
+			Budget:       module.Budget,
 		},
 		relativePath,
 		absoluteFilepath,
@@ -1,5 +1,31 @@
 package config
 
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsKnownCycle(t *testing.T) {
+	build := &BuildDescription{KnownCycles: [][]string{{"a", "b", "c"}, {"x", "y"}}}
+	cases := map[string]struct {
+		ids      []string
+		expected bool
+	}{
+		"same":   {[]string{"x", "y"}, true},
+		"shrunk": {[]string{"a", "c"}, true},
+		"grown":  {[]string{"x", "y", "z"}, false},
+		"spans":  {[]string{"a", "x"}, false},
+		"new":    {[]string{"m", "n"}, false},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, c.expected, build.IsKnownCycle(c.ids))
+		})
+	}
+}
+
 // func TestLoadBuildDescription(t *testing.T) {
 // 	descr, err := LoadBuildDescriptionFile("c:\\wf\\lp\\web\\App\\build\\systemjs_build_controlpanel.json")
 // 	assert.Nil(t, err)
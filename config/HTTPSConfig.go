@@ -0,0 +1,13 @@
+package config
+
+// HTTPSConfig enables HTTPS for the built-in web server.  When no certificate and key are provided,
+// a self-signed certificate for localhost is generated and cached.
+type HTTPSConfig struct {
+	Cert string `json:"cert"`
+	Key  string `json:"key"`
+}
+
+// SelfSigned indicates whether a self-signed certificate should be used, rather than a provided cert/key
+func (config *HTTPSConfig) SelfSigned() bool {
+	return config.Cert == "" && config.Key == ""
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"path"
+	"strings"
+)
+
+// HistoryFallbackConfig configures the HTML5 history fallback, which serves the index page for client-side
+// routes (e.g. /app/students/42) beneath the base path.  The fallback is enabled unless disabled here.
+type HistoryFallbackConfig struct {
+	Disabled bool `json:"disabled"`
+	// Exclude lists paths that should never fall back: a prefix ending in "/" (e.g. "/app/api/"),
+	// or otherwise a glob (see path.Match) matched against the whole path
+	Exclude []string `json:"exclude"`
+}
+
+// Enabled indicates whether the history fallback is enabled
+func (config *HistoryFallbackConfig) Enabled() bool {
+	return config == nil || !config.Disabled
+}
+
+// Excludes tests whether a URL path matches one of the exclusions
+func (config *HistoryFallbackConfig) Excludes(urlPath string) bool {
+	if config == nil {
+		return false
+	}
+	for _, exclusion := range config.Exclude {
+		if !strings.HasPrefix(exclusion, "/") {
+			exclusion = "/" + exclusion
+		}
+		if strings.HasSuffix(exclusion, "/") {
+			if strings.HasPrefix(urlPath+"/", exclusion) {
+				return true
+			}
+			continue
+		}
+		if matched, err := path.Match(exclusion, urlPath); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoryFallbackExcludes(t *testing.T) {
+	fallback := &HistoryFallbackConfig{Exclude: []string{"/app/api/", "app/reports/*", "/app/*/raw"}}
+
+	cases := map[string]struct {
+		path     string
+		expected bool
+	}{
+		"prefix":            {"/app/api/students", true},
+		"prefix itself":     {"/app/api", true},
+		"prefix lookalike":  {"/app/apis", false},
+		"glob":              {"/app/reports/42", true},
+		"glob nested":       {"/app/reports/42/detail", false},
+		"glob middle":       {"/app/students/raw", true},
+		"client-side route": {"/app/students/42", false},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, fallback.Excludes(tc.path))
+		})
+	}
+}
+
+func TestHistoryFallbackEnabled(t *testing.T) {
+	assert.True(t, (*HistoryFallbackConfig)(nil).Enabled())
+	assert.False(t, (*HistoryFallbackConfig)(nil).Excludes("/app/api/"))
+	assert.True(t, (&HistoryFallbackConfig{}).Enabled())
+	assert.False(t, (&HistoryFallbackConfig{Disabled: true}).Enabled())
+}
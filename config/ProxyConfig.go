@@ -0,0 +1,53 @@
+package config
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// ProxyConfig describes how requests beneath a path prefix are forwarded to an upstream server
+type ProxyConfig struct {
+	Target      string            `json:"target"`
+	Rewrite     string            `json:"rewrite"`
+	Headers     map[string]string `json:"headers"`
+	RewriteHost bool              `json:"rewriteHost"`
+}
+
+// TargetURL parses the upstream URL that requests are forwarded to
+func (proxy *ProxyConfig) TargetURL() (*url.URL, error) {
+	target, err := url.Parse(proxy.Target)
+	if err != nil || target.Scheme == "" || target.Host == "" {
+		return nil, errors.New("Invalid proxy target (expected e.g. http://localhost:5000): " + proxy.Target)
+	}
+	return target, nil
+}
+
+// RewritePath replaces the path prefix matched by the proxy with the configured rewrite, if any,
+// e.g. prefix "/api/" with rewrite "/v2/" changes "/api/users" to "/v2/users" and the bare "/api" to "/v2"
+func (proxy *ProxyConfig) RewritePath(prefix string, requestPath string) string {
+	if proxy.Rewrite == "" {
+		return requestPath
+	}
+	if requestPath == strings.TrimSuffix(prefix, "/") {
+		if rewritten := strings.TrimSuffix(proxy.Rewrite, "/"); rewritten != "" {
+			return rewritten
+		}
+		return "/"
+	}
+	if !strings.HasPrefix(requestPath, prefix) {
+		return requestPath
+	}
+	return strings.TrimSuffix(proxy.Rewrite, "/") + "/" + strings.TrimPrefix(requestPath, prefix)
+}
+
+// NormaliseProxyPrefix ensures a proxy's path prefix is rooted and ends with a slash, so it matches a whole subtree
+func NormaliseProxyPrefix(prefix string) string {
+	if !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return prefix
+}
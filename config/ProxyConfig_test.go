@@ -0,0 +1,47 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProxyRewritePath(t *testing.T) {
+	cases := map[string]struct {
+		rewrite  string
+		path     string
+		expected string
+	}{
+		"no rewrite":       {"", "/api/users", "/api/users"},
+		"strip prefix":     {"/", "/api/users", "/users"},
+		"replace prefix":   {"/v2/", "/api/users", "/v2/users"},
+		"replace no slash": {"/v2", "/api/users", "/v2/users"},
+		"outside prefix":   {"/v2/", "/other/users", "/other/users"},
+		"prefix only":      {"/v2/", "/api/", "/v2/"},
+		"bare prefix":      {"/v2/", "/api", "/v2"},
+		"bare prefix root": {"/", "/api", "/"},
+		"nested":           {"/backend/api/", "/api/a/b?c", "/backend/api/a/b?c"},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			proxy := &ProxyConfig{Target: "http://localhost:5000", Rewrite: tc.rewrite}
+			assert.Equal(t, tc.expected, proxy.RewritePath("/api/", tc.path))
+		})
+	}
+}
+
+func TestNormaliseProxyPrefix(t *testing.T) {
+	assert.Equal(t, "/api/", NormaliseProxyPrefix("api"))
+	assert.Equal(t, "/api/", NormaliseProxyPrefix("/api"))
+	assert.Equal(t, "/api/", NormaliseProxyPrefix("/api/"))
+}
+
+func TestProxyTargetURL(t *testing.T) {
+	target, err := (&ProxyConfig{Target: "http://localhost:5000/base"}).TargetURL()
+	assert.Nil(t, err)
+	assert.Equal(t, "localhost:5000", target.Host)
+
+	_, err = (&ProxyConfig{Target: "localhost:5000"}).TargetURL()
+	assert.NotNil(t, err)
+}
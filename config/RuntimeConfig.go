@@ -3,14 +3,24 @@ package config
 // RuntimeConfig describes the expected state at runtime (currently, just what the base path will be)
 type RuntimeConfig struct {
 	// BaseHref gets the expected base path at runtime, e.g. <base href="app" /> ==> "app"
-	BuildPath               string `json:"path"`
-	BaseHref                string `json:"baseHref"`
+	BuildPath               string               `json:"path"`
+	BaseHref                string               `json:"baseHref"`
+	InlineSourceMaps        bool                 `json:"inlineSourceMaps"`
+	SourceRoot              string               `json:"sourceRoot"`
+	SourcePathRewrites      []*SourcePathRewrite `json:"sourcePathRewrites"`
+	SystemJSRewrites        []*SystemJSRewrite   `json:"systemJSRewrites"`
+	SystemJSMap             map[string]string    `json:"systemJSMap"`
+	SystemJSPaths           map[string]string    `json:"systemJSPaths"`
 	pathInterpolationValues map[string]string
 }
 
 // NewRuntimeConfig creates a RuntimeConfig
 func NewRuntimeConfig(buildPath string, baseHref string) *RuntimeConfig {
-	return &RuntimeConfig{buildPath, baseHref, map[string]string{}}
+	return &RuntimeConfig{
+		BuildPath:               buildPath,
+		BaseHref:                baseHref,
+		pathInterpolationValues: map[string]string{},
+	}
 }
 
 // SourceMapsEnabled ...
@@ -18,6 +28,50 @@ func (rtc *RuntimeConfig) SourceMapsEnabled() bool {
 	return true
 }
 
+// InlineSourceMapsEnabled gets whether bundles should embed their source map, rather than serve it separately
+func (rtc *RuntimeConfig) InlineSourceMapsEnabled() bool {
+	return rtc != nil && rtc.InlineSourceMaps
+}
+
+// RewriteSourcePath applies the first matching SourcePathRewrite rule to a source map source path
+func (rtc *RuntimeConfig) RewriteSourcePath(sourcePath string) string {
+	if rtc == nil {
+		return sourcePath
+	}
+	for _, rule := range rtc.SourcePathRewrites {
+		if rewritten, ok := rule.Apply(sourcePath); ok {
+			return rewritten
+		}
+	}
+	return sourcePath
+}
+
+// SystemJSConfigRewrites gets the search/replace rules for the build's systemjs.config.js.  Builds without any
+// "systemJSRewrites" get the default rules; an empty list disables them.
+func (rtc *RuntimeConfig) SystemJSConfigRewrites() []*SystemJSRewrite {
+	if rtc == nil || rtc.SystemJSRewrites == nil {
+		return DefaultSystemJSRewrites()
+	}
+	return rtc.SystemJSRewrites
+}
+
+// RewriteSystemJSConfigText applies the build's systemjs.config.js search/replace rules in order.  A rule that
+// can't be applied is skipped and reported in the error.
+func (rtc *RuntimeConfig) RewriteSystemJSConfigText(configJS string) (string, error) {
+	var firstErr error
+	for _, rule := range rtc.SystemJSConfigRewrites() {
+		rewritten, err := rule.Apply(configJS)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		configJS = rewritten
+	}
+	return configJS, firstErr
+}
+
 // SetPathInterpolationValues sets a map of key/value pairs to be interpolated into import paths
 func (rtc *RuntimeConfig) SetPathInterpolationValues(values map[string]string) {
 	rtc.pathInterpolationValues = values
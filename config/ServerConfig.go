@@ -2,12 +2,41 @@ package config
 
 // ServerConfig is the configuration for the built-in web server
 type ServerConfig struct {
-	Port      uint16 `json:"port"`
-	Open      bool   `json:"open"`
-	HotReload bool   `json:"hotReload"`
+	Port            uint16                  `json:"port"`
+	Open            bool                    `json:"open"`
+	HotReload       bool                    `json:"hotReload"`
+	Console         string                  `json:"console"`
+	Proxy           map[string]*ProxyConfig `json:"proxy"`
+	HTTPS           *HTTPSConfig            `json:"https"`
+	HistoryFallback *HistoryFallbackConfig  `json:"historyFallback"`
 }
 
+const (
+	// ConsoleSymbolicated prints browser console errors/warnings in the terminal, with positions mapped back to original sources (the default)
+	ConsoleSymbolicated = "symbolicated"
+
+	// ConsoleRaw prints browser console errors/warnings in the terminal, as sent by the browser
+	ConsoleRaw = "raw"
+
+	// ConsoleOff ignores browser console errors/warnings
+	ConsoleOff = "off"
+)
+
 // NewServerConfig creates a new ServerConfig
 func NewServerConfig(port uint16, open bool, enableHotReload bool) *ServerConfig {
-	return &ServerConfig{port, open, enableHotReload}
+	return &ServerConfig{
+		Port:      port,
+		Open:      open,
+		HotReload: enableHotReload,
+	}
+}
+
+// ForwardsConsole indicates whether browser console errors/warnings should be printed in the terminal
+func (config *ServerConfig) ForwardsConsole() bool {
+	return config.Console != ConsoleOff
+}
+
+// SymbolicatesConsole indicates whether forwarded browser console messages should be mapped through the source maps
+func (config *ServerConfig) SymbolicatesConsole() bool {
+	return config.Console == "" || config.Console == ConsoleSymbolicated
 }
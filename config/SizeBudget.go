@@ -0,0 +1,47 @@
+package config
+
+import (
+	"encoding/json"
+	"github.com/mrcrowl/swarm/util"
+)
+
+// SizeBudget limits the size of a module's bundle, e.g. {"raw": "500 KB", "gzip": "120 KB"}.  Either limit may be
+// omitted.
+type SizeBudget struct {
+	Raw  ByteSize `json:"raw"`
+	Gzip ByteSize `json:"gzip"`
+}
+
+// ByteSize is a number of bytes, written in JSON as a number or a string such as "120 KB"
+type ByteSize int
+
+// UnmarshalJSON reads a ByteSize from a number or string
+func (size *ByteSize) UnmarshalJSON(data []byte) error {
+	var number int
+	if err := json.Unmarshal(data, &number); err == nil {
+		*size = ByteSize(number)
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+	bytes, err := util.ParseByteSize(text)
+	*size = ByteSize(bytes)
+	return err
+}
+
+// Exceeded lists the limits that a bundle of a size exceeds, e.g. "503.2 KB exceeds the budget of 500.0 KB"
+func (budget *SizeBudget) Exceeded(raw int, gzip int) []string {
+	var exceeded []string
+	if budget == nil {
+		return exceeded
+	}
+	if budget.Raw > 0 && raw > int(budget.Raw) {
+		exceeded = append(exceeded, util.FormatByteSize(raw)+" exceeds the budget of "+util.FormatByteSize(int(budget.Raw)))
+	}
+	if budget.Gzip > 0 && gzip > int(budget.Gzip) {
+		exceeded = append(exceeded, util.FormatByteSize(gzip)+" gzipped exceeds the budget of "+util.FormatByteSize(int(budget.Gzip)))
+	}
+	return exceeded
+}
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadSizeBudget(t *testing.T) {
+	descr, err := LoadBuildDescriptionString(`{"modules": [{"name": "ep/App", "budget": {"raw": "500 KB", "gzip": 4096}}]}`)
+	assert.Nil(t, err)
+	assert.Equal(t, &SizeBudget{Raw: 500 * 1024, Gzip: 4096}, descr.Modules[0].Budget)
+
+	_, err = LoadBuildDescriptionString(`{"modules": [{"name": "ep/App", "budget": {"raw": "500 GB"}}]}`)
+	assert.NotNil(t, err)
+}
+
+func TestSizeBudgetExceeded(t *testing.T) {
+	budget := &SizeBudget{Raw: 2048, Gzip: 1024}
+	assert.Empty(t, budget.Exceeded(2048, 1024))
+	assert.Equal(t, []string{"2.5 KB exceeds the budget of 2.0 KB"}, budget.Exceeded(2560, 1000))
+	assert.Equal(t, []string{"2.5 KB exceeds the budget of 2.0 KB", "1.5 KB gzipped exceeds the budget of 1.0 KB"},
+		budget.Exceeded(2560, 1536))
+	assert.Empty(t, (&SizeBudget{Gzip: 1024}).Exceeded(1000000, 1000))
+
+	var noBudget *SizeBudget
+	assert.Empty(t, noBudget.Exceeded(1000000, 1000000))
+}
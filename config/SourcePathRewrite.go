@@ -0,0 +1,40 @@
+package config
+
+import (
+	"path"
+	"strings"
+)
+
+// SourcePathRewrite describes a rule for rewriting the path of a source listed in a bundle's source map
+type SourcePathRewrite struct {
+	// Match is a glob (see path.Match); a glob without a "/" is matched against the filename only
+	Match string `json:"match"`
+	// Replace is the replacement path; a replacement ending in "/" keeps the original filename
+	Replace string `json:"replace"`
+}
+
+// NewSourcePathRewrite creates a SourcePathRewrite
+func NewSourcePathRewrite(match string, replace string) *SourcePathRewrite {
+	return &SourcePathRewrite{match, replace}
+}
+
+// Matches tests whether a source path matches this rule
+func (rule *SourcePathRewrite) Matches(sourcePath string) bool {
+	target := sourcePath
+	if !strings.Contains(rule.Match, "/") {
+		target = path.Base(sourcePath)
+	}
+	matched, err := path.Match(rule.Match, target)
+	return err == nil && matched
+}
+
+// Apply rewrites a source path, if it matches this rule
+func (rule *SourcePathRewrite) Apply(sourcePath string) (string, bool) {
+	if !rule.Matches(sourcePath) {
+		return sourcePath, false
+	}
+	if strings.HasSuffix(rule.Replace, "/") {
+		return rule.Replace + path.Base(sourcePath), true
+	}
+	return rule.Replace, true
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourcePathRewrite(t *testing.T) {
+	cases := map[string]struct {
+		match    string
+		replace  string
+		path     string
+		expected string
+		ok       bool
+	}{
+		"filename": {
+			match: "BaseController.ts", replace: "ui/base/BaseController.ts",
+			path: "BaseController.ts", expected: "ui/base/BaseController.ts", ok: true,
+		},
+		"filename-in-dir": {
+			match: "Base*.ts", replace: "ui/base/",
+			path: "../src/BaseView.ts", expected: "ui/base/BaseView.ts", ok: true,
+		},
+		"full-path": {
+			match: "../*/Config.ts", replace: "common/Config.ts",
+			path: "../src/Config.ts", expected: "common/Config.ts", ok: true,
+		},
+		"no-match": {
+			match: "*.tsx", replace: "ui/",
+			path: "ui/App.ts", expected: "ui/App.ts", ok: false,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			actual, ok := NewSourcePathRewrite(tc.match, tc.replace).Apply(tc.path)
+			assert.Equal(t, tc.expected, actual)
+			assert.Equal(t, tc.ok, ok)
+		})
+	}
+}
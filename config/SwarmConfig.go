@@ -37,6 +37,16 @@ type SwarmConfig struct {
 	Server   *ServerConfig             `json:"server"`
 }
 
+// BuildName gets the name of one of the builds, or its base href if it isn't one of them
+func (config *SwarmConfig) BuildName(build *RuntimeConfig) string {
+	for name, b := range config.Builds {
+		if b == build {
+			return name
+		}
+	}
+	return build.BaseHref
+}
+
 func (config *SwarmConfig) expandAndNormalisePaths(cwd string) {
 	norm := func(base string, path string) string {
 		if filepath.IsAbs(path) {
@@ -50,6 +60,13 @@ func (config *SwarmConfig) expandAndNormalisePaths(cwd string) {
 	for _, b := range config.Builds {
 		b.BuildPath = norm(config.RootPath, b.BuildPath)
 	}
+	if config.Server != nil && config.Server.HTTPS != nil {
+		https := config.Server.HTTPS
+		if !https.SelfSigned() {
+			https.Cert = norm(cwd, https.Cert)
+			https.Key = norm(cwd, https.Key)
+		}
+	}
 }
 
 func (config *SwarmConfig) backfillWithDefaults(cwd string) {
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"errors"
+	"regexp"
+)
+
+// SystemJSRewrite describes a search/replace rule applied to the text of a build's systemjs.config.js
+type SystemJSRewrite struct {
+	// Search is a regular expression (see regexp.Compile)
+	Search string `json:"search"`
+	// Replace is the replacement text, which may refer to submatches as $1, $2, etc.
+	Replace string `json:"replace"`
+}
+
+// defaultSystemJSRewrites point the shared folders of an app at the root, since bundled modules are registered
+// relative to the root, rather than the app's base href
+var defaultSystemJSRewrites = []*SystemJSRewrite{
+	NewSystemJSRewrite(`"\.\/(common|services|utils)",`, `"../$1", /* <-- REWRITTEN BY SWARM */`),
+}
+
+// NewSystemJSRewrite creates a SystemJSRewrite
+func NewSystemJSRewrite(search string, replace string) *SystemJSRewrite {
+	return &SystemJSRewrite{search, replace}
+}
+
+// DefaultSystemJSRewrites gets the rules used by builds that don't specify any "systemJSRewrites"
+func DefaultSystemJSRewrites() []*SystemJSRewrite {
+	return defaultSystemJSRewrites
+}
+
+// Pattern compiles the rule's search expression
+func (rule *SystemJSRewrite) Pattern() (*regexp.Regexp, error) {
+	pattern, err := regexp.Compile(rule.Search)
+	if err != nil {
+		return nil, errors.New("Invalid systemJSRewrites search expression: " + err.Error())
+	}
+	return pattern, nil
+}
+
+// Apply replaces every match of the rule's search expression
+func (rule *SystemJSRewrite) Apply(configJS string) (string, error) {
+	pattern, err := rule.Pattern()
+	if err != nil {
+		return configJS, err
+	}
+	return pattern.ReplaceAllString(configJS, rule.Replace), nil
+}
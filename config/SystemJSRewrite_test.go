@@ -0,0 +1,37 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSystemJSRewrite(t *testing.T) {
+	rule := NewSystemJSRewrite(`"\./(lib)/"`, `"/shared/$1/"`)
+	rewritten, err := rule.Apply(`map: { lib: "./lib/", other: "./other/" }`)
+	assert.Nil(t, err)
+	assert.Equal(t, `map: { lib: "/shared/lib/", other: "./other/" }`, rewritten)
+}
+
+func TestSystemJSRewriteInvalid(t *testing.T) {
+	rule := NewSystemJSRewrite(`(`, ``)
+	rewritten, err := rule.Apply(`map: {}`)
+	assert.NotNil(t, err)
+	assert.Equal(t, `map: {}`, rewritten)
+}
+
+func TestSystemJSConfigRewrites(t *testing.T) {
+	assert.Equal(t, DefaultSystemJSRewrites(), (*RuntimeConfig)(nil).SystemJSConfigRewrites())
+	assert.Equal(t, DefaultSystemJSRewrites(), NewRuntimeConfig("", "app").SystemJSConfigRewrites())
+
+	rtc := NewRuntimeConfig("", "app")
+	rtc.SystemJSRewrites = []*SystemJSRewrite{}
+	rewritten, err := rtc.RewriteSystemJSConfigText(`common: "./common",`)
+	assert.Nil(t, err)
+	assert.Equal(t, `common: "./common",`, rewritten)
+
+	rtc.SystemJSRewrites = []*SystemJSRewrite{NewSystemJSRewrite(`(`, ``), NewSystemJSRewrite(`common`, `shared`)}
+	rewritten, err = rtc.RewriteSystemJSConfigText(`common: "./common",`)
+	assert.NotNil(t, err)
+	assert.Equal(t, `shared: "./shared",`, rewritten)
+}
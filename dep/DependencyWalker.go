@@ -8,21 +8,30 @@ import (
 	"github.com/mrcrowl/swarm/util"
 )
 
+// registerLinePrefix starts the System.register line that TypeScript emits, e.g.
+// System.register(["./Shared"], function (exports_1, context_1) {
+const registerLinePrefix = "System.register(["
+
 // BuildFileSet creates a FileSet by following the dependency graph of an entry file
 func BuildFileSet(
 	workspace *source.Workspace,
 	entryFileRelativePath string,
 	excludedFilesets []*source.FileSet,
 	interpolationValues map[string]string,
+	resolver *source.ImportResolver, /* may be nil */
 ) *source.FileSet {
-	imports, links := followDependencyChain(workspace, entryFileRelativePath, excludedFilesets, interpolationValues)
-	fileset := source.NewFileSet(imports, links, workspace)
+	chain := followDependencyChain(workspace, entryFileRelativePath, excludedFilesets, interpolationValues, resolver)
+	fileset := source.NewFileSet(chain.imports, chain.links, workspace)
+	fileset.AddExternals(chain.externals)
+	fileset.AddResolvedImports(chain.resolvedImports)
+	chain.setLazyImports(fileset)
+	chain.setResolvedRequires(fileset)
 
 	return fileset
 }
 
-// UpdateFileset adds dependencies for an entry file to a FileSet
-func UpdateFileset(fileset *source.FileSet, modifiedFileRelativePath string, excludedFilesets []*source.FileSet, interpolationValues map[string]string) {
+// UpdateFileset adds dependencies for an entry file to a FileSet, returning any newly found external imports
+func UpdateFileset(fileset *source.FileSet, modifiedFileRelativePath string, excludedFilesets []*source.FileSet, interpolationValues map[string]string, resolver *source.ImportResolver) []string {
 	// assume a file has been touched/changed, so:
 	//
 	// 1. invalidate it's content
@@ -46,8 +55,48 @@ func UpdateFileset(fileset *source.FileSet, modifiedFileRelativePath string, exc
 		fileset.MarkDirty()
 
 		// 2. update the dependencies (but include "fileset" in the exclusions, so we don't follow paths we already know about)
-		imports, links := followDependencyChain(fileset.Workspace(), fileID, append(excludedFilesets, fileset), interpolationValues)
-		fileset.Ingest(imports, links, true)
+		chain := followDependencyChain(fileset.Workspace(), fileID, append(excludedFilesets, fileset), interpolationValues, resolver)
+		fileset.Ingest(chain.imports, chain.links, true)
+		fileset.AddResolvedImports(chain.resolvedImports)
+		chain.setLazyImports(fileset)
+		chain.setResolvedRequires(fileset)
+		return fileset.AddExternals(chain.externals)
+	}
+	return nil
+}
+
+// AddEntryPoint adds the dependencies of another entry file (e.g. one that's imported on demand) to a FileSet,
+// returning any newly found external imports
+func AddEntryPoint(fileset *source.FileSet, entryFileRelativePath string, excludedFilesets []*source.FileSet, interpolationValues map[string]string, resolver *source.ImportResolver) []string {
+	chain := followDependencyChain(fileset.Workspace(), entryFileRelativePath, append(excludedFilesets, fileset), interpolationValues, resolver)
+	fileset.Ingest(chain.imports, chain.links, false)
+	fileset.AddResolvedImports(chain.resolvedImports)
+	chain.setLazyImports(fileset)
+	chain.setResolvedRequires(fileset)
+	return fileset.AddExternals(chain.externals)
+}
+
+// dependencyChain is what's found by following the dependencies of an entry file
+type dependencyChain struct {
+	imports         []*source.Import
+	links           []*source.DependencyLink
+	externals       []string                     // imports that aren't bundled
+	resolvedImports map[string]string            // bare imports of System.register files => the IDs of the files they resolved to
+	lazyLinks       map[string][]string          // file ID => the IDs of the files it imports on demand, which aren't followed
+	requires        map[string]map[string]string // CommonJS file ID => its bare requires => the IDs they resolved to
+}
+
+// setLazyImports records the lazy edges of the followed files in a FileSet, clearing those that have gone
+func (chain *dependencyChain) setLazyImports(fileset *source.FileSet) {
+	for id, lazyIDs := range chain.lazyLinks {
+		fileset.SetLazyImports(id, lazyIDs)
+	}
+}
+
+// setResolvedRequires records what the bare requires of the followed CommonJS files resolved to in a FileSet
+func (chain *dependencyChain) setResolvedRequires(fileset *source.FileSet) {
+	for id, resolvedRequires := range chain.requires {
+		fileset.SetResolvedRequires(id, resolvedRequires)
 	}
 }
 
@@ -56,9 +105,15 @@ func followDependencyChain(
 	entryFileRelativePath string,
 	excludedFilesets []*source.FileSet, /* may be nil */
 	interpolationValues map[string]string,
-) ([]*source.Import, []*source.DependencyLink) {
+	resolver *source.ImportResolver, /* may be nil */
+) *dependencyChain {
 	queue := newImportQueue()
 	links := make([]*source.DependencyLink, 0, 2048)
+	var externals []string
+	resolvedImports := map[string]string{}
+	lazyLinks := map[string][]string{}
+	requires := map[string]map[string]string{}
+	resolutions := map[string]*source.Import{}
 
 	entryFileRelativePath = strings.Replace(entryFileRelativePath, "\\", "/", -1)
 	queue.pushPath(entryFileRelativePath)
@@ -86,13 +141,20 @@ func followDependencyChain(
 			return
 		}
 
+		dependencies, lazyDependencies, isCommonJS := readDependencies(file, interpolationValues)
 		var dependencyIDs []string
-		for _, dep := range readDependencies(file, interpolationValues) {
-			if dep.IsSolo {
+		resolvedRequires := map[string]string{}
+		for _, dep := range dependencies {
+			depRootRelative, ok := resolveDependency(workspace, resolver, resolutions, imp, dep)
+			if !ok {
+				externals = append(externals, dep.Path())
 				continue
 			}
-
-			depRootRelative := imp.ToRootRelativeImport(dep)
+			if dep.IsRooted && isCommonJS {
+				resolvedRequires[dep.Path()] = depRootRelative.Path()
+			} else if dep.IsRooted {
+				resolvedImports[dep.Path()] = depRootRelative.Path()
+			}
 
 			if shouldEnqueue(depRootRelative) {
 				queue.push(depRootRelative)
@@ -101,10 +163,24 @@ func followDependencyChain(
 			dependencyIDs = append(dependencyIDs, depRootRelative.Path())
 		}
 
+		if isCommonJS {
+			requires[importPath] = resolvedRequires
+		}
+
 		if len(dependencyIDs) > 0 {
 			link := source.NewDependencyLink(importPath, dependencyIDs)
 			links = append(links, link)
 		}
+
+		var lazyIDs []string
+		for _, dep := range lazyDependencies {
+			if depRootRelative, ok := resolveDependency(workspace, resolver, resolutions, imp, dep); ok {
+				lazyIDs = append(lazyIDs, depRootRelative.Path())
+			} else {
+				externals = append(externals, dep.Path())
+			}
+		}
+		lazyLinks[importPath] = lazyIDs
 	}
 
 	for queue.nonEmpty() {
@@ -113,24 +189,116 @@ func followDependencyChain(
 		}
 	}
 
-	return queue.outputImports(), links
+	return &dependencyChain{
+		imports:         queue.outputImports(),
+		links:           links,
+		externals:       externals,
+		resolvedImports: resolvedImports,
+		lazyLinks:       lazyLinks,
+		requires:        requires,
+	}
+}
+
+// resolveDependency makes a dependency root-relative: relative imports are relative to the importing file, whereas
+// bare and aliased imports are resolved through the build's SystemJS config or node_modules.  Resolving a bare
+// import can search a chain of node_modules directories, so each is resolved once per importing directory (and
+// walk), with the results kept in resolutions.
+func resolveDependency(workspace *source.Workspace, resolver *source.ImportResolver, resolutions map[string]*source.Import, imp *source.Import, dep *source.Import) (*source.Import, bool) {
+	if !dep.IsRooted {
+		return imp.ToRootRelativeImport(dep), true
+	}
+
+	key := imp.Directory + "|" + dep.Path()
+	if resolved, cached := resolutions[key]; cached {
+		return resolved, resolved != nil
+	}
+	resolved, ok := workspace.ResolveImport(dep, imp, resolver)
+	if !ok {
+		resolved = nil
+	}
+	resolutions[key] = resolved
+	return resolved, ok
 }
 
-func readDependencies(file *source.File, interpValues map[string]string) []*source.Import {
-	var line string
-	var err error
-	if line, err = util.ReadFirstLine(file.Filepath); err != nil {
-		return nil
+// readDependencies reads the imports of a file, and those that its body imports on demand, or the requires of a
+// CommonJS file
+func readDependencies(file *source.File, interpValues map[string]string) (dependencies []*source.Import, lazyDependencies []*source.Import, isCommonJS bool) {
+	contents, err := util.ReadContents(file.Filepath)
+	if err != nil {
+		return nil, nil, false
 	}
 
 	var filteredDeps []*source.Import
-	if dependencies, ok := source.ParseRegisterDependencies(line, true); ok {
-		filteredDeps = make([]*source.Import, 0, len(dependencies))
-		for _, dependencyImportPath := range dependencies {
+	if dependencyPaths, body, ok := readRegisterLine(contents); ok && !source.IsNodeModule(file.ID) {
+		filteredDeps = make([]*source.Import, 0, len(dependencyPaths))
+		for _, dependencyImportPath := range dependencyPaths {
+			filteredDeps = append(filteredDeps, source.NewImportWithInterpolation(dependencyImportPath, interpValues))
+		}
+		if strings.Contains(body, ".import(") {
+			for _, lazyImportPath := range source.ParseDynamicImports(body) {
+				lazyDependencies = append(lazyDependencies, source.NewImportWithInterpolation(lazyImportPath, interpValues))
+			}
+		}
+		return filteredDeps, lazyDependencies, false
+	}
+
+	register, err := source.ParseSystemRegister(contents)
+	if err != nil {
+		fmt.Printf("WARNING: Malformed System.register in %s:%s\n", file.Filepath, err)
+	}
+
+	if register != nil {
+		filteredDeps = make([]*source.Import, 0, len(register.Dependencies))
+		for _, dependencyImportPath := range register.DependencyPaths() {
 			dependencyImport := source.NewImportWithInterpolation(dependencyImportPath, interpValues)
 			filteredDeps = append(filteredDeps, dependencyImport)
 		}
+		for _, lazyImportPath := range source.ParseDynamicImports(contents[register.End:]) {
+			lazyDependencies = append(lazyDependencies, source.NewImportWithInterpolation(lazyImportPath, interpValues))
+		}
+	} else if source.IsNodeModule(file.ID) && file.Ext() == ".js" {
+		// CommonJS, which is bundled with a System.register shim, so its requires are dependencies
+		isCommonJS = true
+		for _, required := range source.ParseCommonJSRequires(contents) {
+			// file IDs omit .js, which is added back when they're registered, e.g. "./cjs/react.js" ==> "./cjs/react"
+			filteredDeps = append(filteredDeps, source.NewImport(strings.TrimSuffix(required, ".js")))
+		}
+	}
+
+	return filteredDeps, lazyDependencies, isCommonJS
+}
+
+// readRegisterLine reads the dependencies of a file that starts with a single-line System.register call, as
+// TypeScript emits, without tokenizing the file.  Any other start (e.g. comments, or a multi-line dependency array)
+// isn't ok, and is left for source.ParseSystemRegister.
+func readRegisterLine(contents string) (dependencyPaths []string, body string, ok bool) {
+	line := contents
+	if newline := strings.IndexByte(contents, '\n'); newline >= 0 {
+		line = contents[:newline]
+	}
+	if !strings.HasPrefix(line, registerLinePrefix) {
+		return nil, "", false
 	}
 
-	return filteredDeps
+	rest := line[len(registerLinePrefix):]
+	for !strings.HasPrefix(rest, "]") {
+		if !strings.HasPrefix(rest, "\"") {
+			return nil, "", false
+		}
+		end := strings.IndexAny(rest[1:], "\"\\")
+		if end < 0 || rest[1+end] != '"' {
+			return nil, "", false
+		}
+		dependencyPaths = append(dependencyPaths, rest[1:1+end])
+		rest = rest[2+end:]
+		if strings.HasPrefix(rest, ", \"") {
+			rest = rest[2:]
+		} else if !strings.HasPrefix(rest, "]") {
+			return nil, "", false
+		}
+	}
+	if !strings.HasPrefix(rest, "], function (") || !strings.HasSuffix(strings.TrimSuffix(rest, "\r"), "{") {
+		return nil, "", false
+	}
+	return dependencyPaths, contents[len(line):], true
 }
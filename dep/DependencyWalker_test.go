@@ -10,7 +10,7 @@ import (
 
 func TestFollowDependencyGraph(t *testing.T) {
 	ws := source.NewWorkspace("C:\\WF\\LP\\web\\App")
-	followDependencyChain(ws, "app\\src\\ep\\App.js", nil, map[string]string{})
+	followDependencyChain(ws, "app\\src\\ep\\App.js", nil, map[string]string{}, nil)
 }
 
 const jsFileWithCommentsBeforeSystemRegister = `// the dependency above is required by evaluateVariables() method
@@ -27,6 +27,115 @@ func TestReadDependencies(t *testing.T) {
 	imp := source.NewImport("./VariableEvaluator.js")
 	file, err := ws.ReadSourceFile(imp)
 	assert.Nil(t, err)
-	dependencies := readDependencies(file, map[string]string{})
+	dependencies, _, _ := readDependencies(file, map[string]string{})
 	assert.Len(t, dependencies, 3)
 }
+
+func TestFollowDependencyChainResolvesBareImports(t *testing.T) {
+	temppath := testutil.CreateTempDir()
+	defer testutil.RemoveTempDir(temppath)
+	srcPath := testutil.MakeSubdirectoryTree(temppath, "app/src")
+	testutil.WriteTextFile(srcPath, "App.js", `System.register(["./First", "lodash", "common/util", "tslib"], function (exports_1, context_1) {`)
+	testutil.WriteTextFile(srcPath, "First.js", `System.register(["tslib"], function (exports_1, context_1) {`)
+	testutil.WriteTextFile(testutil.MakeSubdirectoryTree(temppath, "node_modules/lodash"), "lodash.js", "")
+	testutil.WriteTextFile(testutil.MakeSubdirectoryTree(temppath, "common"), "util.js", "")
+	cfg, _ := source.ParseSystemJSConfig(`System.config({ map: { lodash: "/node_modules/lodash/lodash.js", common: "../common" } });`)
+	ws := source.NewWorkspace(temppath)
+
+	chain := followDependencyChain(ws, "app/src/App.js", nil, map[string]string{}, source.NewImportResolver(source.NewSystemJSResolver(cfg, "app"), true, nil))
+	importPaths := make([]string, len(chain.imports))
+	for i, imp := range chain.imports {
+		importPaths[i] = imp.Path()
+	}
+	assert.ElementsMatch(t, []string{"app/src/App.js", "app/src/First", "node_modules/lodash/lodash", "common/util"}, importPaths)
+	assert.Len(t, chain.links, 1)
+	assert.Equal(t, []string{"tslib", "tslib"}, chain.externals)
+	assert.Equal(t, map[string]string{"lodash": "node_modules/lodash/lodash", "common/util": "common/util"}, chain.resolvedImports)
+
+	// packages in node_modules are external, unless the module opts in to bundling them
+	chain = followDependencyChain(ws, "app/src/App.js", nil, map[string]string{}, source.NewImportResolver(source.NewSystemJSResolver(cfg, "app"), false, nil))
+	assert.Equal(t, []string{"lodash", "tslib", "tslib"}, chain.externals)
+	assert.Equal(t, map[string]string{"common/util": "common/util"}, chain.resolvedImports)
+}
+
+func TestReadRegisterLine(t *testing.T) {
+	cases := map[string]struct {
+		contents     string
+		expectedOK   bool
+		expectedDeps []string
+	}{
+		"dependencies":    {"System.register([\"./a\", \"lodash\"], function (exports_1, context_1) {\n});", true, []string{"./a", "lodash"}},
+		"no dependencies": {"System.register([], function (exports_1, context_1) {\n});", true, nil},
+		"single quotes":   {"System.register(['./a'], function (exports_1, context_1) {\n});", false, nil},
+		"multi-line":      {"System.register([\n\"./a\"\n], function (exports_1, context_1) {\n});", false, nil},
+		"comment first":   {"// header\nSystem.register([\"./a\"], function (exports_1, context_1) {\n});", false, nil},
+		"no comma":        {"System.register([\"./a\"\"./b\"], function (exports_1, context_1) {\n});", false, nil},
+		"one line":        {"System.register([\"./a\"], function (exports_1, context_1) { var x = [1]; });", false, nil},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			dependencyPaths, _, ok := readRegisterLine(tc.contents)
+			assert.Equal(t, tc.expectedOK, ok)
+			assert.Equal(t, tc.expectedDeps, dependencyPaths)
+		})
+	}
+}
+
+func TestFollowDependencyChainIntoNodeModules(t *testing.T) {
+	temppath := testutil.CreateTempDir()
+	defer testutil.RemoveTempDir(temppath)
+	testutil.WriteTextFile(testutil.MakeSubdirectoryTree(temppath, "app/src"), "App.js", `System.register(["react", "react-dom"], function (exports_1, context_1) {`)
+	reactPath := testutil.MakeSubdirectoryTree(temppath, "node_modules/react")
+	testutil.WriteTextFile(reactPath, "package.json", `{"main": "index.js"}`)
+	testutil.WriteTextFile(reactPath, "index.js", `module.exports = require('./cjs/react.js');`)
+	testutil.WriteTextFile(testutil.MakeSubdirectoryTree(reactPath, "cjs"), "react.js", `var assign = require("object-assign");`)
+	testutil.WriteTextFile(testutil.MakeSubdirectoryTree(temppath, "node_modules/object-assign"), "index.js", `module.exports = Object.assign;`)
+	ws := source.NewWorkspace(temppath)
+
+	chain := followDependencyChain(ws, "app/src/App.js", nil, map[string]string{}, source.NewImportResolver(nil, true, []string{"react-*"}))
+	importPaths := make([]string, len(chain.imports))
+	for i, imp := range chain.imports {
+		importPaths[i] = imp.Path()
+	}
+	assert.ElementsMatch(t, []string{"app/src/App.js", "node_modules/react/index", "node_modules/react/cjs/react", "node_modules/object-assign/index"}, importPaths)
+	assert.Equal(t, []string{"react-dom"}, chain.externals)
+}
+
+func TestFollowDependencyChainResolvesNestedNodeModules(t *testing.T) {
+	temppath := testutil.CreateTempDir()
+	defer testutil.RemoveTempDir(temppath)
+	testutil.WriteTextFile(testutil.MakeSubdirectoryTree(temppath, "app/src"), "App.js", `System.register(["lodash", "a"], function (exports_1, context_1) {`)
+	testutil.WriteTextFile(testutil.MakeSubdirectoryTree(temppath, "node_modules/lodash"), "index.js", `module.exports = {};`)
+	testutil.WriteTextFile(testutil.MakeSubdirectoryTree(temppath, "node_modules/a"), "index.js", `module.exports = require("lodash");`)
+	testutil.WriteTextFile(testutil.MakeSubdirectoryTree(temppath, "node_modules/a/node_modules/lodash"), "index.js", `module.exports = {};`)
+	ws := source.NewWorkspace(temppath)
+
+	chain := followDependencyChain(ws, "app/src/App.js", nil, map[string]string{}, source.NewImportResolver(nil, true, nil))
+	assert.Equal(t, map[string]string{"lodash": "node_modules/lodash/index", "a": "node_modules/a/index"}, chain.resolvedImports)
+	assert.Equal(t, map[string]map[string]string{
+		"node_modules/a/index":                     {"lodash": "node_modules/a/node_modules/lodash/index"},
+		"node_modules/lodash/index":                {},
+		"node_modules/a/node_modules/lodash/index": {},
+	}, chain.requires)
+}
+
+func TestFollowDependencyChainRecordsLazyImports(t *testing.T) {
+	temppath := testutil.CreateTempDir()
+	defer testutil.RemoveTempDir(temppath)
+	srcPath := testutil.MakeSubdirectoryTree(temppath, "app/src")
+	testutil.WriteTextFile(srcPath, "App.js", `System.register(["./Routes"], function (exports_1, context_1) {`)
+	testutil.WriteTextFile(srcPath, "Routes.js", "System.register([], function (exports_1, context_1) {\n"+
+		"    var routes = [{ path: 'admin', load: function () { return context_1.import(\"./admin/Admin\"); } }];\n"+
+		"    var track = function () { return context_1.import(\"analytics\"); };")
+	testutil.WriteTextFile(testutil.MakeSubdirectoryTree(srcPath, "admin"), "Admin.js", `System.register([], function (exports_1, context_1) {`)
+	ws := source.NewWorkspace(temppath)
+
+	chain := followDependencyChain(ws, "app/src/App.js", nil, map[string]string{}, nil)
+	importPaths := make([]string, len(chain.imports))
+	for i, imp := range chain.imports {
+		importPaths[i] = imp.Path()
+	}
+	assert.ElementsMatch(t, []string{"app/src/App.js", "app/src/Routes"}, importPaths)
+	assert.Equal(t, []string{"app/src/admin/Admin"}, chain.lazyLinks["app/src/Routes"])
+	assert.Equal(t, []string{"analytics"}, chain.externals)
+}
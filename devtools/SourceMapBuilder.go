@@ -3,12 +3,26 @@ package devtools
 import (
 	"strings"
 	"github.com/mrcrowl/swarm/source"
+	"github.com/mrcrowl/swarm/util"
 )
 
 // SourceMapBuilder is used for compiling source maps from existing source map files
 type SourceMapBuilder struct {
-	filename string
-	sources  []*sourceMap
+	filename    string
+	sourceRoot  string
+	rewritePath func(string) string
+	sources     []*sourceMap
+	sourcePaths []string
+	names       []string
+	regions     []*SourceMapRegion
+}
+
+// SourceMapRegion describes the lines of the compiled output that were generated from a single file
+type SourceMapRegion struct {
+	Name      string
+	StartLine int
+	LineCount int
+	Sources   []string
 }
 
 // NewSourceMapBuilder creates a new sourceMapBuilder
@@ -19,6 +33,16 @@ func NewSourceMapBuilder(filename string, capacity int) *SourceMapBuilder {
 	}
 }
 
+// SetSourceRoot sets the sourceRoot written to the compiled source map
+func (smb *SourceMapBuilder) SetSourceRoot(sourceRoot string) {
+	smb.sourceRoot = sourceRoot
+}
+
+// SetPathRewriter sets a function used to rewrite each source path in the compiled source map
+func (smb *SourceMapBuilder) SetPathRewriter(rewritePath func(string) string) {
+	smb.rewritePath = rewritePath
+}
+
 // AddSourceMap adds a source map to be included in the build
 func (smb *SourceMapBuilder) AddSourceMap(spacerLines int, fileLineCount int, mapping *source.Mapping) {
 	source := &sourceMap{
@@ -29,41 +53,118 @@ func (smb *SourceMapBuilder) AddSourceMap(spacerLines int, fileLineCount int, ma
 	smb.sources = append(smb.sources, source)
 }
 
+// Regions returns the body region of each file, as calculated by the last call to GenerateMappings
+func (smb *SourceMapBuilder) Regions() []*SourceMapRegion {
+	return smb.regions
+}
+
 func (smb *SourceMapBuilder) String() string {
+	mappings := smb.GenerateMappings()
+
 	var sb strings.Builder
 	sb.WriteString(`{"version":3,"file":"`)
 	sb.WriteString(smb.filename)
-	sb.WriteString(`.js","sources":[`)
-	first := true
-	for _, source := range smb.sources {
-		if first {
-			first = false
-		} else {
-			sb.WriteByte(',')
-		}
-		sb.WriteString("\"" + source.path() + "\"")
+	sb.WriteString(`.js",`)
+	if smb.sourceRoot != "" {
+		sb.WriteString(`"sourceRoot":`)
+		sb.WriteString(util.JSONEncodeString(smb.sourceRoot))
+		sb.WriteByte(',')
 	}
+	sb.WriteString(`"sources":[`)
+	writeJSONStrings(&sb, smb.sourcePaths)
+	sb.WriteString(`],"names":[`)
+	writeJSONStrings(&sb, smb.names)
 	sb.WriteString(`],"mappings":"`)
-	sb.WriteString(smb.GenerateMappings())
+	sb.WriteString(mappings)
 	sb.WriteString(`"}`)
 	return sb.String()
 }
 
+func writeJSONStrings(sb *strings.Builder, values []string) {
+	for i, value := range values {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(util.JSONEncodeString(value))
+	}
+}
+
 // GenerateMappings outputs a string of the compiled sourcemap
 func (smb *SourceMapBuilder) GenerateMappings() string {
 	var sb strings.Builder
-	var lastMappingsDelta = source.Segment{GeneratedColumn: 0, SourceFile: 0, SourceLine: 0, SourceColumn: 0}
+	var state source.Segment // last absolute source file/line/column written
+	lastName := 0
+	sourceIndex := make(map[string]int)
+	nameIndex := make(map[string]int)
+	smb.sourcePaths = smb.sourcePaths[:0]
+	smb.names = smb.names[:0]
+	smb.regions = make([]*SourceMapRegion, 0, len(smb.sources))
+	generatedLine := 0
+
+	addSourcePath := func(sourcePath string) int {
+		if smb.rewritePath != nil {
+			sourcePath = smb.rewritePath(sourcePath)
+		}
+		if i, found := sourceIndex[sourcePath]; found {
+			return i
+		}
+		i := len(smb.sourcePaths)
+		sourceIndex[sourcePath] = i
+		smb.sourcePaths = append(smb.sourcePaths, sourcePath)
+		return i
+	}
+	addName := func(name string) int {
+		if i, found := nameIndex[name]; found {
+			return i
+		}
+		i := len(smb.names)
+		nameIndex[name] = i
+		smb.names = append(smb.names, name)
+		return i
+	}
+
 	for _, source := range smb.sources {
 		sb.WriteString(strings.Repeat(";", source.spacerLines))
 		source.mapping.EnsureLoaded()
-		mappings := source.OffsetMappings(lastMappingsDelta)
-		playback := source.PlayMappings()
-		lastMappingsDelta = playback.SegmentDelta
-		lastMappingsDelta.SourceFile = 1
+		generatedLine += source.spacerLines
+		region := &SourceMapRegion{Name: source.path(), StartLine: generatedLine, LineCount: source.fileLineCount}
+
+		var mappings string
+		var lineCount int
+		if source.isSingleSource() {
+			// fast path: only the first VLQ needs adjusting
+			fileIndex := addSourcePath(source.primaryPath())
+			region.Sources = []string{smb.sourcePaths[fileIndex]}
+			offset := state
+			offset.SourceFile = fileIndex - state.SourceFile
+			mappings = source.OffsetMappings(offset)
+			playback := source.PlayMappings()
+			if source.hasSegments() {
+				state.SourceFile = fileIndex + playback.SegmentDelta.SourceFile
+				state.SourceLine = playback.SegmentDelta.SourceLine
+				state.SourceColumn = playback.SegmentDelta.SourceColumn
+			}
+			lineCount = playback.LineCount
+		} else {
+			upstreamSources := source.mapping.Sources()
+			sourceIndexes := make([]int, len(upstreamSources))
+			for i, upstreamSource := range upstreamSources {
+				sourceIndexes[i] = addSourcePath(source.sourcePath(upstreamSource))
+				region.Sources = append(region.Sources, smb.sourcePaths[sourceIndexes[i]])
+			}
+			upstreamNames := source.mapping.Names()
+			nameIndexes := make([]int, len(upstreamNames))
+			for i, name := range upstreamNames {
+				nameIndexes[i] = addName(name)
+			}
+			mappings, lineCount = source.RemapMappings(&state, &lastName, sourceIndexes, nameIndexes)
+		}
 
 		sb.WriteString(mappings)
-		additionalSeparators := 1 + (source.fileLineCount - playback.LineCount)
+		additionalSeparators := 1 + (source.fileLineCount - lineCount)
 		sb.WriteString(strings.Repeat(";", additionalSeparators))
+		generatedLine += source.fileLineCount
+		smb.regions = append(smb.regions, region)
 	}
 	return sb.String()
 }
@@ -2,6 +2,7 @@ package devtools
 
 import (
 	"fmt"
+	"path"
 	"strings"
 	"github.com/mrcrowl/swarm/source"
 )
@@ -43,6 +44,68 @@ func (smap *sourceMap) OffsetMappings(segDelta source.Segment) string {
 	return offsetMappings
 }
 
+// RemapMappings fully decodes the Mappings field of this smap and re-encodes each segment
+// against the combined source map.  Upstream source and name indexes are translated using
+// sourceIndexes and nameIndexes, and state tracks the last absolute source file/line/column
+// (plus lastName for the name index) written to the combined mappings.  This is the slow path,
+// used when an upstream map has more than one source or references any names.
+func (smap *sourceMap) RemapMappings(state *source.Segment, lastName *int, sourceIndexes []int, nameIndexes []int) (string, int) {
+	var sb strings.Builder
+	var upstream source.Segment
+	upstreamName := 0
+	lineStrings := strings.Split(smap.mapping.Mappings(), ";")
+	for i, lineString := range lineStrings {
+		if i > 0 {
+			sb.WriteByte(';')
+		}
+		if lineString == "" {
+			continue
+		}
+		for j, segmentString := range strings.Split(lineString, ",") {
+			if j > 0 {
+				sb.WriteByte(',')
+			}
+			values := decode(segmentString)
+			if len(values) == 0 {
+				continue
+			}
+
+			// generated positions are unchanged within a line, so the column delta carries over
+			remapped := []int{values[0]}
+			if len(values) >= 4 {
+				upstream.SourceFile += values[1]
+				upstream.SourceLine += values[2]
+				upstream.SourceColumn += values[3]
+				sourceFile := remapIndex(sourceIndexes, upstream.SourceFile)
+				remapped = append(remapped,
+					sourceFile-state.SourceFile,
+					upstream.SourceLine-state.SourceLine,
+					upstream.SourceColumn-state.SourceColumn,
+				)
+				state.SourceFile = sourceFile
+				state.SourceLine = upstream.SourceLine
+				state.SourceColumn = upstream.SourceColumn
+
+				if len(values) >= 5 {
+					upstreamName += values[4]
+					name := remapIndex(nameIndexes, upstreamName)
+					remapped = append(remapped, name-*lastName)
+					*lastName = name
+				}
+			}
+			sb.WriteString(encode(remapped))
+		}
+	}
+	return sb.String(), len(lineStrings)
+}
+
+func remapIndex(indexes []int, i int) int {
+	if i >= 0 && i < len(indexes) {
+		return indexes[i]
+	}
+	return i
+}
+
 type sourceMap struct {
 	spacerLines   int
 	fileLineCount int
@@ -57,6 +120,43 @@ func (smap *sourceMap) path() string {
 	return smap.mapping.RelativePath()
 }
 
+// primaryPath gets the path for a single source map, preferring the upstream map's own source
+func (smap *sourceMap) primaryPath() string {
+	sources := smap.mapping.Sources()
+	if len(sources) == 1 && sources[0] != "" {
+		return smap.sourcePath(sources[0])
+	}
+	return smap.path()
+}
+
+// isSingleSource indicates whether the upstream map can be concatenated by offsetting its first VLQ only
+func (smap *sourceMap) isSingleSource() bool {
+	return len(smap.mapping.Sources()) <= 1 && len(smap.mapping.Names()) == 0
+}
+
+// hasSegments indicates whether the upstream map contains at least one VLQ segment
+func (smap *sourceMap) hasSegments() bool {
+	start, _ := findFirstVLQ(smap.mapping.Mappings())
+	return start >= 0
+}
+
+// sourcePath resolves one of the upstream map's sources (via its sourceRoot) relative to the entry point
+func (smap *sourceMap) sourcePath(upstreamSource string) string {
+	if isAbsoluteSourcePath(upstreamSource) {
+		return upstreamSource
+	}
+	sourceRoot := smap.mapping.SourceRoot()
+	if isAbsoluteSourcePath(sourceRoot) {
+		return strings.TrimSuffix(sourceRoot, "/") + "/" + upstreamSource
+	}
+	return path.Join(path.Dir(smap.path()), sourceRoot, upstreamSource)
+}
+
+// isAbsoluteSourcePath tests whether a source path is rooted, or a URL (e.g. webpack:///...)
+func isAbsoluteSourcePath(sourcePath string) bool {
+	return strings.HasPrefix(sourcePath, "/") || strings.Contains(sourcePath, "://")
+}
+
 type line struct {
 	segments []*source.Segment
 }
@@ -306,3 +306,64 @@ func TestEncode(t *testing.T) {
 		})
 	}
 }
+
+func TestRemapMappings(t *testing.T) {
+	config := &source.MapConfig{
+		Sources:  []string{"x.ts", "y.ts"},
+		Names:    []string{"foo"},
+		Mappings: "AAAA;ACAAA,EAAE",
+	}
+	smap := &sourceMap{mapping: source.NewMappingForTesting(config)}
+	state := source.Segment{SourceFile: 0, SourceLine: 1, SourceColumn: 0}
+	lastName := 0
+	actual, lineCount := smap.RemapMappings(&state, &lastName, []int{1, 2}, []int{3})
+	assert.Equal(t, "ACDA;ACAAG,EAAE", actual)
+	assert.Equal(t, 2, lineCount)
+	assert.Equal(t, source.Segment{SourceFile: 2, SourceLine: 0, SourceColumn: 2}, state)
+	assert.Equal(t, 3, lastName)
+}
+
+func TestGenerateMappingsMultiSource(t *testing.T) {
+	multi := source.NewMappingForTesting(&source.MapConfig{
+		Sources:  []string{"x.ts", "y.ts"},
+		Names:    []string{"foo"},
+		Mappings: "AAAA;ACAAA",
+	})
+	single := source.NewMappingForTesting(&source.MapConfig{
+		Sources:  []string{"First.ts"},
+		Mappings: "AAAA;AACA",
+	})
+	smb := NewSourceMapBuilder("bundle", 2)
+	smb.AddSourceMap(0, 2, multi)
+	smb.AddSourceMap(1, 2, single)
+	actual := smb.GenerateMappings()
+	assert.Equal(t, "AAAA;ACAAA;;ACAA;AACA;", actual)
+	assert.Equal(t, []string{"x.ts", "y.ts", "First.ts"}, smb.sourcePaths)
+	assert.Equal(t, []string{"foo"}, smb.names)
+}
+
+func TestSourcePathRewriting(t *testing.T) {
+	rooted := source.NewMappingForTesting(&source.MapConfig{
+		SourceRoot: "../../src/",
+		Sources:    []string{"BaseController.ts"},
+		Mappings:   "AAAA",
+	})
+	absolute := source.NewMappingForTesting(&source.MapConfig{
+		SourceRoot: "webpack:///",
+		Sources:    []string{"helpers.ts", "Other.ts"},
+		Mappings:   "AAAA;ACAA",
+	})
+	smb := NewSourceMapBuilder("bundle", 2)
+	smb.SetSourceRoot("/app/")
+	smb.SetPathRewriter(func(sourcePath string) string {
+		if sourcePath == "../../src/BaseController.ts" {
+			return "ui/base/BaseController.ts"
+		}
+		return sourcePath
+	})
+	smb.AddSourceMap(0, 1, rooted)
+	smb.AddSourceMap(0, 2, absolute)
+	actual := smb.String()
+	assert.Contains(t, actual, `"sourceRoot":"/app/"`)
+	assert.Contains(t, actual, `"sources":["ui/base/BaseController.ts","webpack:///helpers.ts","webpack:///Other.ts"]`)
+}
@@ -0,0 +1,96 @@
+package devtools
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mrcrowl/swarm/source"
+)
+
+// SourceMapConsumer looks up the original positions of generated code in a decoded source map
+type SourceMapConsumer struct {
+	sources  []string
+	segments []mappedSegment
+}
+
+// NewSourceMapConsumer decodes a source map for lookups.  Sources are resolved the way a browser would:
+// relative to the URL path of the source map (and its sourceRoot, if any)
+func NewSourceMapConsumer(config *source.MapConfig, sourceMapURLPath string) *SourceMapConsumer {
+	sources := make([]string, len(config.Sources))
+	for i, sourcePath := range config.Sources {
+		switch {
+		case isAbsoluteSourcePath(sourcePath):
+			sources[i] = sourcePath
+		case isAbsoluteSourcePath(config.SourceRoot):
+			sources[i] = strings.TrimSuffix(config.SourceRoot, "/") + "/" + sourcePath
+		default:
+			sources[i] = path.Join(path.Dir(sourceMapURLPath), config.SourceRoot, sourcePath)
+		}
+	}
+
+	// decodeMappings returns segments ordered by generated line, then column
+	return &SourceMapConsumer{
+		sources:  sources,
+		segments: decodeMappings(config.Mappings),
+	}
+}
+
+// OriginalPosition finds the source, line and column that generated the code at a (zero-based) line and column.
+// The closest mapping at or before the column is used, but only within the same generated line.
+func (consumer *SourceMapConsumer) OriginalPosition(generatedLine int, generatedColumn int) (sourcePath string, sourceLine int, sourceColumn int, found bool) {
+	segments := consumer.segments
+	i := sort.Search(len(segments), func(i int) bool {
+		seg := segments[i]
+		return seg.generatedLine > generatedLine || (seg.generatedLine == generatedLine && seg.generatedColumn > generatedColumn)
+	})
+	if i == 0 {
+		return "", 0, 0, false
+	}
+	seg := segments[i-1]
+	if seg.generatedLine != generatedLine || seg.SourceFile < 0 || seg.SourceFile >= len(consumer.sources) {
+		return "", 0, 0, false
+	}
+	return consumer.sources[seg.SourceFile], seg.SourceLine, seg.SourceColumn, true
+}
+
+// SourceMapResolver finds the source map consumer for a script's URL path, or returns nil if there isn't one
+type SourceMapResolver func(scriptURLPath string) *SourceMapConsumer
+
+// stackFramePositionPattern matches the script location in both the V8 "at fn (url:line:col)" and
+// the Firefox/Safari "fn@url:line:col" stack frame formats
+var stackFramePositionPattern = regexp.MustCompile(`(https?://[^\s()@]+|/[^\s()@:]+):(\d+):(\d+)`)
+
+// SymbolicateStackTrace rewrites each position in a browser stack trace (with one-based lines and columns) to
+// its original source, where a source map for the script can be resolved.  Other positions are left unchanged.
+func SymbolicateStackTrace(stackTrace string, resolve SourceMapResolver) string {
+	consumers := make(map[string]*SourceMapConsumer)
+	return stackFramePositionPattern.ReplaceAllStringFunc(stackTrace, func(position string) string {
+		groups := stackFramePositionPattern.FindStringSubmatch(position)
+		scriptURL, err := url.Parse(groups[1])
+		if err != nil {
+			return position
+		}
+		line, _ := strconv.Atoi(groups[2])
+		column, _ := strconv.Atoi(groups[3])
+
+		consumer, resolved := consumers[scriptURL.Path]
+		if !resolved {
+			consumer = resolve(scriptURL.Path)
+			consumers[scriptURL.Path] = consumer
+		}
+		if consumer == nil {
+			return position
+		}
+
+		sourcePath, sourceLine, sourceColumn, found := consumer.OriginalPosition(line-1, column-1)
+		if !found {
+			return position
+		}
+		return fmt.Sprintf("%s:%d:%d", sourcePath, sourceLine+1, sourceColumn+1)
+	})
+}
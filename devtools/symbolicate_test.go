@@ -0,0 +1,79 @@
+package devtools
+
+import (
+	"testing"
+
+	"github.com/mrcrowl/swarm/source"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOriginalPosition(t *testing.T) {
+	consumer := NewSourceMapConsumer(&source.MapConfig{
+		Sources:  []string{"First.ts", "/abs/Second.ts"},
+		Mappings: "AAAA,IAAI;;ACCF",
+	}, "/app/src/App.js")
+
+	cases := map[string]struct {
+		line, column     int
+		expectedSource   string
+		expectedLine     int
+		expectedColumn   int
+		expectedNotFound bool
+	}{
+		"first segment":       {0, 0, "/app/src/First.ts", 0, 0, false},
+		"between segments":    {0, 2, "/app/src/First.ts", 0, 0, false},
+		"second segment":      {0, 7, "/app/src/First.ts", 0, 4, false},
+		"unmapped line":       {1, 0, "", 0, 0, true},
+		"absolute source":     {2, 3, "/abs/Second.ts", 1, 2, false},
+		"before first column": {2, -1, "", 0, 0, true},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			sourcePath, line, column, found := consumer.OriginalPosition(c.line, c.column)
+			assert.Equal(t, !c.expectedNotFound, found)
+			assert.Equal(t, c.expectedSource, sourcePath)
+			assert.Equal(t, c.expectedLine, line)
+			assert.Equal(t, c.expectedColumn, column)
+		})
+	}
+}
+
+func TestOriginalPositionSourceRoot(t *testing.T) {
+	consumer := NewSourceMapConsumer(&source.MapConfig{
+		SourceRoot: "../ts",
+		Sources:    []string{"First.ts"},
+		Mappings:   "AAAA",
+	}, "/app/src/App.js")
+	sourcePath, _, _, found := consumer.OriginalPosition(0, 0)
+	assert.True(t, found)
+	assert.Equal(t, "/app/ts/First.ts", sourcePath)
+}
+
+func TestSymbolicateStackTrace(t *testing.T) {
+	consumer := NewSourceMapConsumer(&source.MapConfig{
+		Sources:  []string{"First.ts"},
+		Mappings: "AAAA;AACI",
+	}, "/app/App.js")
+	resolved := 0
+	resolve := func(scriptURLPath string) *SourceMapConsumer {
+		resolved++
+		if scriptURLPath == "/app/App.js" {
+			return consumer
+		}
+		return nil
+	}
+
+	trace := "TypeError: x is undefined\n" +
+		"    at go (http://localhost:8096/app/App.js:2:10)\n" +
+		"    at http://localhost:8096/app/App.js:1:1\n" +
+		"    at other (http://localhost:8096/lib/other.js:5:6)\n" +
+		"go@http://localhost:8096/app/App.js?v=1:2:5"
+	expected := "TypeError: x is undefined\n" +
+		"    at go (/app/First.ts:2:5)\n" +
+		"    at /app/First.ts:1:1\n" +
+		"    at other (http://localhost:8096/lib/other.js:5:6)\n" +
+		"go@/app/First.ts:2:5"
+	assert.Equal(t, expected, SymbolicateStackTrace(trace, resolve))
+	assert.Equal(t, 2, resolved)
+}
@@ -0,0 +1,214 @@
+package devtools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mrcrowl/swarm/source"
+)
+
+// SourceReader reads the lines of an original source, as named in a compiled source map
+type SourceReader func(sourcePath string) (lines []string, found bool)
+
+// SourceMapReport summarises the verification of a single file's region within a compiled source map
+type SourceMapReport struct {
+	Region        *SourceMapRegion
+	Checked       int
+	Discrepancies []string
+}
+
+// OK indicates whether verification found no discrepancies for this file
+func (report *SourceMapReport) OK() bool {
+	return len(report.Discrepancies) == 0
+}
+
+func (report *SourceMapReport) addDiscrepancy(format string, args ...interface{}) {
+	report.Discrepancies = append(report.Discrepancies, fmt.Sprintf(format, args...))
+}
+
+// mappedSegment is a segment with absolute (rather than relative) positions
+type mappedSegment struct {
+	generatedLine   int
+	generatedColumn int
+	source.Segment
+}
+
+// decodeMappings decodes a mappings string into absolute positions, ignoring segments without a source
+func decodeMappings(mappings string) []mappedSegment {
+	var segments []mappedSegment
+	var state source.Segment
+	for generatedLine, lineString := range strings.Split(mappings, ";") {
+		if lineString == "" {
+			continue
+		}
+		generatedColumn := 0
+		for _, segmentString := range strings.Split(lineString, ",") {
+			values := decode(segmentString)
+			if len(values) == 0 {
+				continue
+			}
+			generatedColumn += values[0]
+			if len(values) < 4 {
+				continue
+			}
+			state = state.Add(source.Segment{SourceFile: values[1], SourceLine: values[2], SourceColumn: values[3]})
+			segments = append(segments, mappedSegment{generatedLine, generatedColumn, state})
+		}
+	}
+	return segments
+}
+
+// VerifySourceMap decodes a compiled source map and checks a sample of mappings for each region.  A mapping
+// is expected to be generated within the body region of the file it came from, and to point to a line and
+// column that exist in the original source.
+func VerifySourceMap(sourceMapJSON string, regions []*SourceMapRegion, readSource SourceReader, samplesPerFile int) ([]*SourceMapReport, error) {
+	config, err := source.ParseSourceMapConfig(sourceMapJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]*SourceMapReport, len(regions))
+	for i, region := range regions {
+		reports[i] = &SourceMapReport{Region: region}
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Region.StartLine < reports[j].Region.StartLine })
+
+	reportAt := func(generatedLine int) *SourceMapReport {
+		i := sort.Search(len(reports), func(i int) bool {
+			region := reports[i].Region
+			return region.StartLine+region.LineCount > generatedLine
+		})
+		if i < len(reports) && reports[i].Region.StartLine <= generatedLine {
+			return reports[i]
+		}
+		return nil
+	}
+	reportFor := func(sourcePath string) *SourceMapReport {
+		for _, report := range reports {
+			for _, regionSource := range report.Region.Sources {
+				if regionSource == sourcePath {
+					return report
+				}
+			}
+		}
+		return nil
+	}
+	sourcePathOf := func(i int) string {
+		if i >= 0 && i < len(config.Sources) {
+			return config.Sources[i]
+		}
+		return ""
+	}
+
+	// group segments by the region that generated them
+	grouped := make(map[*SourceMapReport][]mappedSegment)
+	var orphans []mappedSegment
+	for _, seg := range decodeMappings(config.Mappings) {
+		report := reportAt(seg.generatedLine)
+		if report == nil {
+			report = reportFor(sourcePathOf(seg.SourceFile))
+		}
+		if report == nil {
+			orphans = append(orphans, seg)
+			continue
+		}
+		grouped[report] = append(grouped[report], seg)
+	}
+
+	sourceLines := make(map[string][]string)
+	missingSources := make(map[string]bool)
+	readLines := func(sourcePath string) ([]string, bool) {
+		if lines, found := sourceLines[sourcePath]; found {
+			return lines, true
+		}
+		if missingSources[sourcePath] {
+			return nil, false
+		}
+		lines, found := readSource(sourcePath)
+		if !found {
+			missingSources[sourcePath] = true
+			return nil, false
+		}
+		sourceLines[sourcePath] = lines
+		return lines, true
+	}
+
+	for _, report := range reports {
+		segments := grouped[report]
+		if len(segments) == 0 {
+			report.addDiscrepancy("no mappings found")
+			continue
+		}
+
+		reportedMissing := make(map[string]bool)
+		for _, seg := range sampleSegments(segments, samplesPerFile) {
+			report.Checked++
+			region := report.Region
+			sourcePath := sourcePathOf(seg.SourceFile)
+			if sourcePath == "" {
+				report.addDiscrepancy("%d:%d maps to unknown source index %d", seg.generatedLine+1, seg.generatedColumn, seg.SourceFile)
+				continue
+			}
+			if seg.generatedLine < region.StartLine || seg.generatedLine >= region.StartLine+region.LineCount {
+				report.addDiscrepancy("%d:%d is outside the file's body region (lines %d-%d)",
+					seg.generatedLine+1, seg.generatedColumn, region.StartLine+1, region.StartLine+region.LineCount)
+			} else if !containsString(region.Sources, sourcePath) {
+				report.addDiscrepancy("%d:%d maps to %s, which belongs to another file", seg.generatedLine+1, seg.generatedColumn, sourcePath)
+			}
+
+			lines, found := readLines(sourcePath)
+			if !found {
+				if !reportedMissing[sourcePath] {
+					reportedMissing[sourcePath] = true
+					report.addDiscrepancy("original source not found: %s", sourcePath)
+				}
+				continue
+			}
+			if seg.SourceLine < 0 || seg.SourceLine >= len(lines) {
+				report.addDiscrepancy("%d:%d maps to %s:%d, but it only has %d lines",
+					seg.generatedLine+1, seg.generatedColumn, sourcePath, seg.SourceLine+1, len(lines))
+				continue
+			}
+			lineLength := utf8.RuneCountInString(lines[seg.SourceLine])
+			if seg.SourceColumn < 0 || seg.SourceColumn > lineLength {
+				report.addDiscrepancy("%d:%d maps to %s:%d:%d, but that line only has %d columns",
+					seg.generatedLine+1, seg.generatedColumn, sourcePath, seg.SourceLine+1, seg.SourceColumn, lineLength)
+			}
+		}
+	}
+
+	if len(orphans) > 0 {
+		orphanReport := &SourceMapReport{Region: &SourceMapRegion{Name: "(unattributed)"}, Checked: len(orphans)}
+		orphanReport.addDiscrepancy("%d mappings are outside every file's body region, the first at line %d", len(orphans), orphans[0].generatedLine+1)
+		reports = append(reports, orphanReport)
+	}
+
+	return reports, nil
+}
+
+// sampleSegments chooses up to n segments, evenly spaced
+func sampleSegments(segments []mappedSegment, n int) []mappedSegment {
+	if n <= 0 || len(segments) <= n {
+		return segments
+	}
+	if n == 1 {
+		return segments[:1]
+	}
+	samples := make([]mappedSegment, n)
+	step := float64(len(segments)-1) / float64(n-1)
+	for i := range samples {
+		samples[i] = segments[int(float64(i)*step+0.5)]
+	}
+	return samples
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
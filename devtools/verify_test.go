@@ -0,0 +1,66 @@
+package devtools
+
+import (
+	"testing"
+
+	"github.com/mrcrowl/swarm/source"
+	"github.com/stretchr/testify/assert"
+)
+
+func createVerifyBuilder(secondMappings string) *SourceMapBuilder {
+	first := source.NewMappingForTesting(&source.MapConfig{
+		Sources:  []string{"First.ts"},
+		Mappings: "AAAA;AACA",
+	})
+	second := source.NewMappingForTesting(&source.MapConfig{
+		Sources:  []string{"Second.ts"},
+		Mappings: secondMappings,
+	})
+	smb := NewSourceMapBuilder("bundle", 2)
+	smb.AddSourceMap(0, 2, first)
+	smb.AddSourceMap(1, 2, second)
+	return smb
+}
+
+func readVerifySource(sourcePath string) ([]string, bool) {
+	switch sourcePath {
+	case "First.ts":
+		return []string{"let a = 1;", "let b = 2;"}, true
+	case "Second.ts":
+		return []string{"export {}"}, true
+	}
+	return nil, false
+}
+
+func TestVerifySourceMap(t *testing.T) {
+	smb := createVerifyBuilder("AAAA;AAAE")
+	reports, err := VerifySourceMap(smb.String(), smb.Regions(), readVerifySource, 10)
+	assert.Nil(t, err)
+	assert.Len(t, reports, 2)
+	assert.True(t, reports[0].OK(), "%v", reports[0].Discrepancies)
+	assert.Equal(t, 2, reports[0].Checked)
+	assert.Equal(t, 3, reports[1].Region.StartLine)
+	assert.True(t, reports[1].OK(), "%v", reports[1].Discrepancies)
+}
+
+func TestVerifySourceMapDiscrepancies(t *testing.T) {
+	smb := createVerifyBuilder("AAAA;AACA,AAAA;AAAA")
+	reports, err := VerifySourceMap(smb.String(), smb.Regions(), readVerifySource, 10)
+	assert.Nil(t, err)
+	assert.True(t, reports[0].OK(), "%v", reports[0].Discrepancies)
+	assert.False(t, reports[1].OK())
+	assert.Contains(t, reports[1].Discrepancies[0], "only has 1 lines")
+	assert.Contains(t, reports[1].Discrepancies[2], "6:0 is outside the file's body region (lines 4-5)")
+}
+
+func TestSampleSegments(t *testing.T) {
+	segments := make([]mappedSegment, 10)
+	for i := range segments {
+		segments[i].generatedLine = i
+	}
+	samples := sampleSegments(segments, 4)
+	assert.Len(t, samples, 4)
+	assert.Equal(t, 0, samples[0].generatedLine)
+	assert.Equal(t, 9, samples[3].generatedLine)
+	assert.Len(t, sampleSegments(segments, 20), 10)
+}
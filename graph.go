@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"github.com/mrcrowl/swarm/graph"
+)
+
+// runGraph outputs the graph of a build's modules, or of the files in one of its modules
+func runGraph(cmd *command, args []string) int {
+	// stdout only holds the graph, so it can be redirected to a file, and everything else (e.g. the build's
+	// MISSING: lines) goes to stderr
+	stdout := os.Stdout
+	os.Stdout = os.Stderr
+	defer func() { os.Stdout = stdout }()
+
+	if len(args) != 0 {
+		cmd.printUsage()
+		return 2
+	}
+
+	if !graph.IsFormat(*formatFlag) {
+		fmt.Printf("Unknown graph format '%s'\n", *formatFlag)
+		cmd.printUsage()
+		return 2
+	}
+
+	_, _, _, moduleSet := loadBuild(buildArgs())
+	var g *graph.Graph
+	if *moduleFlag != "" {
+		module := findModule(moduleSet, *moduleFlag)
+		if module == nil {
+			return 2
+		}
+		g = module.FileGraph()
+	} else {
+		g = moduleSet.ModuleGraph()
+	}
+	g = g.Collapse(*collapseFlag)
+
+	var w io.Writer = stdout
+	if *outFlag != "" {
+		file, err := os.Create(*outFlag)
+		if err != nil {
+			fmt.Printf("Failed to create '%s': %s\n", *outFlag, err)
+			return 1
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if err := g.Write(w, *formatFlag); err != nil {
+		fmt.Println(err)
+		return 2
+	}
+	if *outFlag != "" {
+		fmt.Printf("Wrote %s graph of %d nodes and %d edges to %s\n", *formatFlag, len(g.Nodes), len(g.Edges), *outFlag)
+	}
+	return 0
+}
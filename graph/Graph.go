@@ -0,0 +1,191 @@
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Graph is a directed graph of files or modules, for visualising a build
+type Graph struct {
+	Name  string  `json:"name"`
+	Nodes []*Node `json:"nodes"`
+	Edges []*Edge `json:"edges"`
+}
+
+// Node is a file, module or (collapsed) directory
+type Node struct {
+	ID string `json:"id"`
+}
+
+// Edge is an import, or a dependency between modules.  Lazy edges are imports made on demand.
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Lazy bool   `json:"lazy,omitempty"`
+}
+
+// NewGraph creates a Graph from the links (and lazy links) between nodes, keyed by the node they're from.  Nodes
+// and edges are sorted, so the output is stable.
+func NewGraph(name string, nodeIDs []string, links map[string][]string, lazyLinks map[string][]string) *Graph {
+	g := &Graph{Name: name}
+	nodes := map[string]bool{}
+	addNode := func(id string) {
+		if !nodes[id] {
+			nodes[id] = true
+			g.Nodes = append(g.Nodes, &Node{ID: id})
+		}
+	}
+	for _, id := range nodeIDs {
+		addNode(id)
+	}
+
+	edges := map[Edge]bool{}
+	addEdges := func(links map[string][]string, lazy bool) {
+		for from, tos := range links {
+			for _, to := range tos {
+				addNode(from)
+				addNode(to)
+				if edge := (Edge{from, to, lazy}); !edges[edge] && !edges[Edge{from, to, false}] {
+					edges[edge] = true
+					g.Edges = append(g.Edges, &edge)
+				}
+			}
+		}
+	}
+	addEdges(links, false)
+	addEdges(lazyLinks, true) // after the static edges, so a lazy edge isn't added alongside a static one
+
+	g.sort()
+	return g
+}
+
+// Collapse merges the nodes within each directory into a node for the directory, up to a depth, e.g. at depth 3
+// "app/src/admin/users/List" becomes "app/src/admin" and "app/src/App" becomes "app/src".  Edges within a
+// directory are dropped, and the edges between directories are lazy only if all of their imports are lazy.
+func (g *Graph) Collapse(depth int) *Graph {
+	if depth <= 0 {
+		return g
+	}
+
+	var nodeIDs []string
+	for _, node := range g.Nodes {
+		nodeIDs = append(nodeIDs, collapseID(node.ID, depth))
+	}
+	links := map[string][]string{}
+	lazyLinks := map[string][]string{}
+	for _, edge := range g.Edges {
+		from, to := collapseID(edge.From, depth), collapseID(edge.To, depth)
+		if from == to {
+			continue
+		}
+		if edge.Lazy {
+			lazyLinks[from] = append(lazyLinks[from], to)
+		} else {
+			links[from] = append(links[from], to)
+		}
+	}
+
+	return NewGraph(g.Name, nodeIDs, links, lazyLinks)
+}
+
+func collapseID(id string, depth int) string {
+	parts := strings.Split(path.Dir(id), "/")
+	if len(parts) > depth {
+		parts = parts[:depth]
+	}
+	return strings.Join(parts, "/")
+}
+
+func (g *Graph) sort() {
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].ID < g.Nodes[j].ID })
+	sort.Slice(g.Edges, func(i, j int) bool {
+		a, b := g.Edges[i], g.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+}
+
+// Formats lists the formats that a Graph can be written in
+var Formats = []string{"dot", "json", "mermaid"}
+
+// IsFormat gets whether a Graph can be written in a format
+func IsFormat(format string) bool {
+	for _, f := range Formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// Write outputs the graph in a format: dot (Graphviz), json or mermaid
+func (g *Graph) Write(w io.Writer, format string) error {
+	switch format {
+	case "dot":
+		return g.WriteDOT(w)
+	case "json":
+		return g.WriteJSON(w)
+	case "mermaid":
+		return g.WriteMermaid(w)
+	}
+	return fmt.Errorf("unknown graph format '%s' (choose from %s)", format, strings.Join(Formats, ", "))
+}
+
+// WriteDOT outputs the graph for Graphviz, with lazy edges dashed
+func (g *Graph) WriteDOT(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("digraph " + quote(g.Name) + " {\n")
+	sb.WriteString("\trankdir=LR;\n")
+	sb.WriteString("\tnode [shape=box];\n")
+	for _, node := range g.Nodes {
+		sb.WriteString("\t" + quote(node.ID) + ";\n")
+	}
+	for _, edge := range g.Edges {
+		sb.WriteString("\t" + quote(edge.From) + " -> " + quote(edge.To))
+		if edge.Lazy {
+			sb.WriteString(" [style=dashed]")
+		}
+		sb.WriteString(";\n")
+	}
+	sb.WriteString("}\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// WriteJSON outputs the graph as JSON, i.e. {"name": ..., "nodes": [{"id": ...}], "edges": [{"from": ..., "to": ...}]}
+func (g *Graph) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(g)
+}
+
+// WriteMermaid outputs the graph as a Mermaid flowchart, with lazy edges dotted
+func (g *Graph) WriteMermaid(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("graph LR\n")
+	keys := make(map[string]string, len(g.Nodes))
+	for i, node := range g.Nodes {
+		keys[node.ID] = fmt.Sprintf("n%d", i)
+		sb.WriteString(fmt.Sprintf("\t%s[\"%s\"]\n", keys[node.ID], strings.Replace(node.ID, "\"", "#quot;", -1)))
+	}
+	for _, edge := range g.Edges {
+		arrow := " --> "
+		if edge.Lazy {
+			arrow = " -.-> "
+		}
+		sb.WriteString("\t" + keys[edge.From] + arrow + keys[edge.To] + "\n")
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func quote(id string) string {
+	quoted, _ := json.Marshal(id)
+	return string(quoted)
+}
@@ -0,0 +1,97 @@
+package graph
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func createGraph() *Graph {
+	return NewGraph("App", []string{"app/src/App", "app/src/Unused"},
+		map[string][]string{
+			"app/src/App":              {"app/src/Routes", "app/src/util/Format"},
+			"app/src/Routes":           {"app/src/util/Format"},
+			"app/src/admin/Admin":      {"app/src/admin/users/List"},
+			"app/src/admin/users/List": {"app/src/util/Format"},
+		},
+		map[string][]string{
+			"app/src/Routes": {"app/src/admin/Admin", "app/src/util/Format"},
+		})
+}
+
+func TestNewGraph(t *testing.T) {
+	g := createGraph()
+	assert.Len(t, g.Nodes, 6)
+	assert.Equal(t, "app/src/App", g.Nodes[0].ID)
+	assert.Equal(t, []*Edge{
+		{From: "app/src/App", To: "app/src/Routes"},
+		{From: "app/src/App", To: "app/src/util/Format"},
+		{From: "app/src/Routes", To: "app/src/admin/Admin", Lazy: true},
+		{From: "app/src/Routes", To: "app/src/util/Format"},
+		{From: "app/src/admin/Admin", To: "app/src/admin/users/List"},
+		{From: "app/src/admin/users/List", To: "app/src/util/Format"},
+	}, g.Edges)
+}
+
+func TestCollapse(t *testing.T) {
+	g := createGraph().Collapse(3)
+	ids := []string{}
+	for _, node := range g.Nodes {
+		ids = append(ids, node.ID)
+	}
+	assert.Equal(t, []string{"app/src", "app/src/admin", "app/src/util"}, ids)
+	assert.Equal(t, []*Edge{
+		{From: "app/src", To: "app/src/admin", Lazy: true},
+		{From: "app/src", To: "app/src/util"},
+		{From: "app/src/admin", To: "app/src/util"},
+	}, g.Edges)
+
+	assert.Equal(t, createGraph(), createGraph().Collapse(0))
+}
+
+func TestWrite(t *testing.T) {
+	g := NewGraph("Main", nil, map[string][]string{"a/Main": {"a/Util"}}, map[string][]string{"a/Main": {"a/Lazy"}})
+	cases := map[string]struct {
+		expected string
+	}{
+		"dot": {expected: "digraph \"Main\" {\n\trankdir=LR;\n\tnode [shape=box];\n\t\"a/Lazy\";\n\t\"a/Main\";\n\t\"a/Util\";\n" +
+			"\t\"a/Main\" -> \"a/Lazy\" [style=dashed];\n\t\"a/Main\" -> \"a/Util\";\n}\n"},
+		"mermaid": {expected: "graph LR\n\tn0[\"a/Lazy\"]\n\tn1[\"a/Main\"]\n\tn2[\"a/Util\"]\n\tn1 -.-> n0\n\tn1 --> n2\n"},
+		"json": {expected: `{
+  "name": "Main",
+  "nodes": [
+    {
+      "id": "a/Lazy"
+    },
+    {
+      "id": "a/Main"
+    },
+    {
+      "id": "a/Util"
+    }
+  ],
+  "edges": [
+    {
+      "from": "a/Main",
+      "to": "a/Lazy",
+      "lazy": true
+    },
+    {
+      "from": "a/Main",
+      "to": "a/Util"
+    }
+  ]
+}
+`},
+	}
+	for format, tc := range cases {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			assert.Nil(t, g.Write(&buf, format))
+			assert.Equal(t, tc.expected, buf.String())
+		})
+	}
+
+	assert.EqualError(t, g.Write(&bytes.Buffer{}, "svg"), "unknown graph format 'svg' (choose from dot, json, mermaid)")
+}
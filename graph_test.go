@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/mrcrowl/swarm/testutil"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraphStdoutHoldsOnlyTheGraph(t *testing.T) {
+	workspacePath := testutil.CreateTempDir()
+	defer testutil.RemoveTempDir(workspacePath)
+	testutil.WriteTextFile(workspacePath, "swarm.json", `{"root": ".", "builds": {"app": {"path": "build/app.json", "baseHref": "app"}}}`)
+	testutil.WriteTextFile(workspacePath, "Config.js", "")
+	testutil.WriteTextFile(testutil.MakeSubdirectoryTree(workspacePath, "build"), "app.json", `{"modules": [{"name": "App"}], "base": "app/src/"}`)
+	testutil.WriteTextFile(testutil.MakeSubdirectoryTree(workspacePath, "app/src"), "App.js", `System.register(["./Missing", "tslib"], function (exports_1, context_1) {`)
+
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	os.Chdir(workspacePath)
+	*buildFlag, *formatFlag = "app", "json"
+	defer func() { *buildFlag, *formatFlag = "", "dot" }()
+
+	stdout := os.Stdout
+	reader, writer, _ := os.Pipe()
+	os.Stdout = writer
+	exitCode := runGraph(&command{words: []string{"graph"}}, nil)
+	writer.Close()
+	os.Stdout = stdout
+	output, _ := ioutil.ReadAll(reader)
+
+	assert.Equal(t, 0, exitCode)
+	var graph map[string]interface{}
+	assert.Nil(t, json.Unmarshal(output, &graph), string(output))
+	assert.Equal(t, stdout, os.Stdout)
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	"fmt"
+	"net/http"
 	"os"
+	"sort"
 
 	"github.com/mrcrowl/swarm/bundle"
 	"github.com/mrcrowl/swarm/config"
@@ -20,46 +22,91 @@ const localver = "1.0.11"
 
 var portFlag = flag.Uint16P("port", "p", uint16(8096), "Web server port number")
 var helpFlag = flag.BoolP("help", "h", false, "Shows the usage")
+var buildFlag = flag.StringP("build", "b", "", "Build to use for commands, e.g. swarm sourcemaps verify <module> --build app")
+var samplesFlag = flag.Int("samples", 50, "Number of mappings to sample per file when verifying source maps")
+var moduleFlag = flag.StringP("module", "m", "", "Module for the graph, why and analyze size commands, e.g. swarm graph --module ep/App")
+var formatFlag = flag.String("format", "dot", "Graph format: dot, json or mermaid")
+var collapseFlag = flag.Int("collapse", 0, "Collapse the files of a graph into their directories, up to this depth")
+var outFlag = flag.StringP("out", "o", "", "File to write the graph to, instead of the console")
+var ignoreFlag = flag.StringArray("ignore", nil, "Glob of files for the analyze unused command to ignore, e.g. --ignore '*.spec.js' (repeatable)")
+var strictFlag = flag.Bool("strict", false, "Fail the build command on circular dependencies that aren't known cycles")
 
 func main() {
-	ui.PrintTitle(localver)
+	flag.Parse()
+	cmd, args := findCommand(flag.Args())
+	if cmd != nil && cmd.writesToStdout() {
+		ui.PrintTitleToStderr(localver)
+	} else {
+		ui.PrintTitle(localver)
+	}
 	ui.CheckHelp(helpFlag)
 
+	if cmd != nil {
+		os.Exit(cmd.run(cmd, args))
+	}
+
+	swarmConfig := loadSwarmConfig()
+	runtimeConfig := ui.ChooseBuild(swarmConfig.Builds, flag.Args())
+	serve(swarmConfig, []*config.RuntimeConfig{runtimeConfig})
+}
+
+// serve builds one or more builds, then serves them from a single web server, rebuilding and hot
+// reloading as files change
+func serve(swarmConfig *config.SwarmConfig, runtimeConfigs []*config.RuntimeConfig) {
 	if didUpdate, _ := version.AutoUpdate(localver); didUpdate {
 		fmt.Println("updated. Please restart!")
 		os.Exit(0)
 	}
 
-	// configuration
-	swarmConfig, err := config.TryLoadSwarmConfigFromCWD(portFlag)
-	util.ExitIfError(err, "Failed to load swarm.json file: %s", err)
-	runtimeConfig := ui.ChooseBuild(swarmConfig.Builds, flag.Args())
-	moduleDescrs, err := config.LoadBuildDescriptionFile(runtimeConfig.BuildPath)
-	util.ExitIfError(err, "Failed to load build description file: '%s'", runtimeConfig.BuildPath)
-
-	// workspace
+	// workspace & modules (one module set per build)
 	ws := source.NewWorkspace(swarmConfig.RootPath)
-	normalisedModules := moduleDescrs.NormaliseModules(ws.RootPath())
-	moduleSet := bundle.CreateModuleSet(ws, normalisedModules, runtimeConfig)
+	moduleSets := make(bundle.ModuleSets, len(runtimeConfigs))
+	handlers := map[string]http.HandlerFunc{}
+	servedBy := map[string]string{}
+	basePaths := make([]string, len(runtimeConfigs))
+	systemJSRewriters := map[string]web.SystemJSConfigRewriter{}
+	for i, runtimeConfig := range runtimeConfigs {
+		moduleSets[i] = loadModuleSet(ws, runtimeConfig)
+		if duplicates := moduleSets[i].Duplicates(); len(duplicates) > 0 {
+			fmt.Println("WARNING: Some files are bundled by more than one module (check the excludes of the build):")
+			printDuplicates(duplicates)
+		}
+		printCycles(moduleSets[i].Cycles(), loadBuildDescription(runtimeConfig))
+		err := mergeHandlers(handlers, servedBy, swarmConfig.BuildName(runtimeConfig), moduleSets[i].GenerateHTTPHandlers())
+		if err != nil {
+			fmt.Printf("ERROR: %s\n", err)
+			os.Exit(1)
+		}
+		basePaths[i] = runtimeConfig.BaseHref
+		systemJSRewriters[runtimeConfig.BaseHref] = moduleSets[i]
+	}
 
 	// web server
-	handlers := moduleSet.GenerateHTTPHandlers()
-	serverOptions := web.CreateServerOptions(swarmConfig.RootPath, swarmConfig.Server, handlers, runtimeConfig.BaseHref)
+	serverOptions := web.CreateServerOptions(swarmConfig.RootPath, swarmConfig.Server, handlers, basePaths...)
+	serverOptions.BundleServer = moduleSets
+	serverOptions.Symbolicator = moduleSets
+	serverOptions.ImportExplainer = moduleSets
+	serverOptions.BundleAnalyzer = moduleSets
+	serverOptions.SystemJSRewriters = systemJSRewriters
 	server := web.CreateServer(serverOptions)
-	hotReloader := web.NewHotReloader(server, ws, moduleSet)
 
 	// monitor
 	mon := monitor.NewMonitor(ws, swarmConfig.Monitor)
-	mon.RegisterCallback(moduleSet.NotifyChanges)
-	mon.RegisterCallback(hotReloader.NotifyReload)
+	for _, moduleSet := range moduleSets {
+		hotReloader := web.NewHotReloader(server, ws, moduleSet)
+		mon.RegisterCallback(moduleSet.NotifyChanges)
+		mon.RegisterCallback(hotReloader.NotifyReload)
+	}
 	fmt.Print("Performing initial build...")
 	mon.TriggerManually()
 
 	go server.Start()
 	go mon.NotifyOnChanges()
-	fmt.Printf("Listening on %s\n", server.URL())
-	if swarmConfig.Server.Open {
-		util.OpenBrowser(server.URL())
+	for _, url := range server.URLs() {
+		fmt.Printf("Listening on %s\n", url)
+		if swarmConfig.Server.Open {
+			util.OpenBrowser(url)
+		}
 	}
 
 	// sleep
@@ -67,3 +114,51 @@ func main() {
 	server.Stop()
 	mon.Stop()
 }
+
+// mergeHandlers adds the handlers of a build to those of the builds before it, failing if two builds serve the same URL
+func mergeHandlers(handlers map[string]http.HandlerFunc, servedBy map[string]string, build string,
+	buildHandlers map[string]http.HandlerFunc) error {
+	urls := make([]string, 0, len(buildHandlers))
+	for url := range buildHandlers {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+	for _, url := range urls {
+		if other, found := servedBy[url]; found {
+			return fmt.Errorf("%s is served by both the %s and %s builds", url, other, build)
+		}
+		handlers[url] = buildHandlers[url]
+		servedBy[url] = build
+	}
+	return nil
+}
+
+// loadSwarmConfig loads the swarm.json configuration
+func loadSwarmConfig() *config.SwarmConfig {
+	swarmConfig, err := config.TryLoadSwarmConfigFromCWD(portFlag)
+	util.ExitIfError(err, "Failed to load swarm.json file: %s", err)
+	return swarmConfig
+}
+
+// loadBuildDescription loads the build description (systemjs_build file) of a build
+func loadBuildDescription(runtimeConfig *config.RuntimeConfig) *config.BuildDescription {
+	description, err := config.LoadBuildDescriptionFile(runtimeConfig.BuildPath)
+	util.ExitIfError(err, "Failed to load build description file: '%s'", runtimeConfig.BuildPath)
+	return description
+}
+
+// loadModuleSet loads the build description of a build, then creates its modules
+func loadModuleSet(ws *source.Workspace, runtimeConfig *config.RuntimeConfig) *bundle.ModuleSet {
+	moduleDescrs := loadBuildDescription(runtimeConfig)
+	normalisedModules := moduleDescrs.NormaliseModules(ws.RootPath())
+	return bundle.CreateModuleSet(ws, normalisedModules, runtimeConfig)
+}
+
+// loadBuild loads the swarm.json configuration, then creates the workspace and modules for the chosen build
+func loadBuild(buildArgs []string) (*config.SwarmConfig, *config.RuntimeConfig, *source.Workspace, *bundle.ModuleSet) {
+	swarmConfig := loadSwarmConfig()
+	runtimeConfig := ui.ChooseBuild(swarmConfig.Builds, buildArgs)
+	ws := source.NewWorkspace(swarmConfig.RootPath)
+	moduleSet := loadModuleSet(ws, runtimeConfig)
+	return swarmConfig, runtimeConfig, ws, moduleSet
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeHandlersRejectsClashingBuilds(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {}
+	handlers := map[string]http.HandlerFunc{}
+	servedBy := map[string]string{}
+
+	err := mergeHandlers(handlers, servedBy, "app", map[string]http.HandlerFunc{"/app/src/App.js": handler})
+	assert.Nil(t, err)
+	err = mergeHandlers(handlers, servedBy, "admin", map[string]http.HandlerFunc{"/admin/src/Admin.js": handler})
+	assert.Nil(t, err)
+	assert.Len(t, handlers, 2)
+
+	err = mergeHandlers(handlers, servedBy, "other", map[string]http.HandlerFunc{"/app/src/App.js": handler})
+	assert.EqualError(t, err, "/app/src/App.js is served by both the app and other builds")
+}
@@ -10,7 +10,7 @@ import (
 type EventChangeset struct {
 	changeIndex map[string]bool
 	changes     []*Event
-	didBundle   bool
+	didBundle   map[string]bool
 }
 
 const hotReloadChangeThreshold = 50
@@ -20,6 +20,7 @@ func NewEventChangeset() *EventChangeset {
 	return &EventChangeset{
 		changeIndex: make(map[string]bool),
 		changes:     nil,
+		didBundle:   make(map[string]bool),
 	}
 }
 
@@ -64,14 +65,19 @@ func (ec *EventChangeset) HasSingleExt(ext string) bool {
 	return len(affectedExts) == 1 && affectedExts[0] == ext
 }
 
-// FlagDidBundle marks the changeset as having caused a bundle
-func (ec *EventChangeset) FlagDidBundle() {
-	ec.didBundle = true
+// FlagDidBundle marks the changeset as having caused a bundle of the build with the specified base href
+func (ec *EventChangeset) FlagDidBundle(baseHref string) {
+	ec.didBundle[baseHref] = true
 }
 
-// SkipHotReload gets a flag about whether this changeset should cause a HR
-func (ec *EventChangeset) SkipHotReload() bool {
-	if !ec.didBundle {
+// DidBundle gets whether the changeset caused a bundle of the build with the specified base href
+func (ec *EventChangeset) DidBundle(baseHref string) bool {
+	return ec.didBundle[baseHref]
+}
+
+// SkipHotReload gets a flag about whether this changeset should cause a HR of the build with the specified base href
+func (ec *EventChangeset) SkipHotReload(baseHref string) bool {
+	if !ec.DidBundle(baseHref) {
 		return true
 	}
 
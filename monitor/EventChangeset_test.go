@@ -85,3 +85,11 @@ func TestHasHTMLAndCSSChanges(t *testing.T) {
 	assert.ElementsMatch(t, []string{".html", ".css"}, sut.AffectedFileExts())
 	assert.False(t, sut.HasSingleExt(".css"))
 }
+
+func TestSkipHotReloadOfBuildNotBundled(t *testing.T) {
+	sut := NewEventChangeset()
+	sut.Add(notify.Write, "app/src/App.js")
+	sut.FlagDidBundle("app")
+	assert.False(t, sut.SkipHotReload("app"))
+	assert.True(t, sut.SkipHotReload("controlpanel"))
+}
@@ -0,0 +1,12 @@
+package main
+
+import (
+	"github.com/mrcrowl/swarm/ui"
+)
+
+// runServe serves several builds from one process, e.g. swarm serve app controlpanel
+func runServe(cmd *command, args []string) int {
+	swarmConfig := loadSwarmConfig()
+	serve(swarmConfig, ui.ChooseBuilds(swarmConfig.Builds, args))
+	return 0
+}
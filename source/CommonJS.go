@@ -0,0 +1,51 @@
+package source
+
+import (
+	"regexp"
+	"strings"
+)
+
+var requirePattern = regexp.MustCompile(`\brequire\s*\(\s*(?:"([^"\n]+)"|'([^'\n]+)')\s*\)`)
+
+// ParseCommonJSRequires lists the modules that a CommonJS file requires, in order, without duplicates
+func ParseCommonJSRequires(fileContents string) []string {
+	var requires []string
+	seen := map[string]bool{}
+	for _, match := range requirePattern.FindAllStringSubmatch(fileContents, -1) {
+		required := match[1] + match[2]
+		if !seen[required] {
+			seen[required] = true
+			requires = append(requires, required)
+		}
+	}
+	return requires
+}
+
+// getCommonJSRegisterLines outputs the lines that wrap a CommonJS file as a System.register module.  Its default
+// export is module.exports, and the properties of module.exports are also exported by name.  The file's requires
+// become the module's dependencies, which SystemJS loads before it executes.  Bare requires that were resolved
+// (keyed without .js, like file IDs) depend on the resolved file's URL, rather than on the SystemJS map, since the
+// same package can resolve to different versions from different places in node_modules.  Each is a single line,
+// like the System.register line of any other file, so that the file's lines aren't shifted any further.
+func getCommonJSRegisterLines(name string, requires []string, resolvedRequires map[string]string) (header string, footer string) {
+	dependencies := make([]string, len(requires))
+	setters := make([]string, len(requires))
+	for i, required := range requires {
+		dependencies[i] = quoteJS(required)
+		if id, resolved := resolvedRequires[strings.TrimSuffix(required, ".js")]; resolved {
+			dependencies[i] = quoteJS("/" + id + ".js")
+		}
+		setters[i] = "function (m) { required[" + quoteJS(required) + "] = m.__useDefault ? m.default : m; }"
+	}
+
+	header = "System.register(\"" + name + ".js\", [" + strings.Join(dependencies, ", ") + "], function (exports_1, context_1) { " +
+		"var module = { exports: {} }, required = {}; " +
+		"return { setters: [" + strings.Join(setters, ", ") + "], execute: function () { " +
+		"(function (module, exports, require, process, global) {"
+	footer = "}).call(module.exports, module, module.exports, function (id) { return required[id]; }, " +
+		"typeof process !== \"undefined\" ? process : { env: { NODE_ENV: \"development\" } }, " +
+		"typeof window !== \"undefined\" ? window : this); " +
+		"if (module.exports !== null && typeof module.exports === \"object\") { exports_1(module.exports); } " +
+		"exports_1(\"default\", module.exports); exports_1(\"__useDefault\", true); } }; });"
+	return header, footer
+}
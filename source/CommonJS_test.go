@@ -0,0 +1,45 @@
+package source
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCommonJSRequires(t *testing.T) {
+	contents := `'use strict';
+var assign = require('object-assign');
+var checkPropTypes = require("./checkPropTypes");
+if (process.env.NODE_ENV !== 'production') { require( './dev.js' ); }
+var again = require('object-assign');
+var notARequire = myrequire('x'), dynamic = require(name);`
+	assert.Equal(t, []string{"object-assign", "./checkPropTypes", "./dev.js"}, ParseCommonJSRequires(contents))
+}
+
+func TestParseCommonJSFileContents(t *testing.T) {
+	contents := "/* license */\nvar assign = require('object-assign');\nmodule.exports = assign;\n//# sourceMappingURL=index.js.map"
+	elems, err := ParseJSFileContents("node_modules/pkg/index", contents)
+	assert.Nil(t, err)
+	assert.False(t, elems.isSystemJS)
+	assert.Equal(t, 6, elems.lineCount)
+	assert.Len(t, elems.body, 5)
+	assert.Equal(t, "/* license */", elems.body[0])
+	assert.True(t, strings.HasPrefix(elems.body[1], `System.register("node_modules/pkg/index.js", ["object-assign"], function (exports_1, context_1) {`))
+	assert.Equal(t, "var assign = require('object-assign');", elems.body[2])
+	assert.True(t, strings.HasSuffix(elems.body[4], `exports_1("default", module.exports); exports_1("__useDefault", true); } }; });`))
+}
+
+func TestParseCommonJSFileContentsWithResolvedRequires(t *testing.T) {
+	contents := "var lodash = require('lodash');\nvar b = require('./b.js');\nmodule.exports = b(lodash);"
+	elems, err := parseJSFileContents("node_modules/a/index", contents, map[string]string{"lodash": "node_modules/a/node_modules/lodash/lodash"})
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(elems.body[0], `System.register("node_modules/a/index.js", ["/node_modules/a/node_modules/lodash/lodash.js", "./b.js"], function (exports_1, context_1) {`))
+	assert.Contains(t, elems.body[0], `function (m) { required["lodash"] = m.__useDefault ? m.default : m; }`)
+}
+
+func TestParseNonSystemJSOutsideNodeModules(t *testing.T) {
+	elems, _ := ParseJSFileContents("app/src/legacy", "window.legacy = true;")
+	assert.Equal(t, `System.register("app/src/legacy.js", [], function (exports_1, context_1) {`, elems.body[0])
+	assert.Equal(t, "});", elems.body[2])
+}
@@ -0,0 +1,24 @@
+package source
+
+import (
+	"regexp"
+)
+
+// dynamicImportPattern matches the dynamic imports that TypeScript (context_1.import), Babel (_context.import) and
+// hand-written code (System.import) use, where the specifier is a string literal
+var dynamicImportPattern = regexp.MustCompile(`\b(?:context_\d+|_context|System|SystemJS)\.import\(\s*(?:"([^"\n]+)"|'([^'\n]+)')\s*[,)]`)
+
+// ParseDynamicImports lists the modules that a file imports on demand, in order, without duplicates.  Imports of
+// computed specifiers can't be known until runtime, so they're ignored.
+func ParseDynamicImports(fileContents string) []string {
+	var imports []string
+	seen := map[string]bool{}
+	for _, match := range dynamicImportPattern.FindAllStringSubmatch(fileContents, -1) {
+		imported := match[1] + match[2]
+		if !seen[imported] {
+			seen[imported] = true
+			imports = append(imports, imported)
+		}
+	}
+	return imports
+}
@@ -0,0 +1,26 @@
+package source
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDynamicImports(t *testing.T) {
+	cases := map[string]struct {
+		js       string
+		expected []string
+	}{
+		"typescript":  {js: `return context_1.import("./admin/Admin").then(function (m) {});`, expected: []string{"./admin/Admin"}},
+		"babel":       {js: `_context.import('./admin/Admin')`, expected: []string{"./admin/Admin"}},
+		"system":      {js: `System.import("lodash"); SystemJS.import('./Other', parentURL);`, expected: []string{"lodash", "./Other"}},
+		"duplicates":  {js: `context_1.import("./A"); context_2.import( "./A" ); context_1.import("./B")`, expected: []string{"./A", "./B"}},
+		"computed":    {js: `context_1.import("./locales/" + locale)`, expected: nil},
+		"other calls": {js: `loader.import("./A"); context_1.importAll("./B")`, expected: nil},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ParseDynamicImports(tc.js))
+		})
+	}
+}
@@ -14,6 +14,7 @@ type File struct {
 	ext       string
 	contents  FileContents
 	sourceMap *Mapping
+	requires  map[string]string // a CommonJS file's bare requires => the IDs of the files they resolved to
 }
 
 // newFile creates a new SourceFile
@@ -71,7 +72,7 @@ func (file *File) LoadContents(runtimeConfig *config.RuntimeConfig) {
 
 	switch file.ext {
 	case ".js":
-		file.contents, err = ParseJSFileContents(file.ID, contents)
+		file.contents, err = parseJSFileContents(file.ID, contents, file.requires)
 	case ".css":
 		file.contents, err = ParseCSSFileContents(file.ID, contents, baseHref)
 	default:
@@ -102,7 +103,10 @@ func (file *File) SourceMap(runtimeConfig *config.RuntimeConfig, entryPointRootR
 			return nil
 		}
 		relativePath := file.PathRelativeTo(runtimeConfig, entryPointRootRelativePath)
-		absoluteFilepath := filepath.Join(filepath.Dir(file.Filepath), sourceMappingURL)
+		absoluteFilepath := ""
+		if !isDataURI(sourceMappingURL) {
+			absoluteFilepath = filepath.Join(filepath.Dir(file.Filepath), sourceMappingURL)
+		}
 		file.sourceMap = NewMapping(sourceMappingURL, relativePath, absoluteFilepath)
 	}
 	return file.sourceMap
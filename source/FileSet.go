@@ -2,6 +2,7 @@ package source
 
 import (
 	"fmt"
+	"sort"
 )
 
 // FileSet is
@@ -11,6 +12,9 @@ type FileSet struct {
 	reverseLinks map[string][]string
 	workspace    *Workspace
 	dirty        bool
+	externals    map[string]bool
+	resolved     map[string]string
+	lazyLinks    map[string][]string
 }
 
 // NewEmptyFileSet creates an empty FileSet
@@ -21,6 +25,9 @@ func NewEmptyFileSet(workspace *Workspace) *FileSet {
 		reverseLinks: make(map[string][]string),
 		workspace:    workspace,
 		dirty:        true,
+		externals:    make(map[string]bool),
+		resolved:     make(map[string]string),
+		lazyLinks:    make(map[string][]string),
 	}
 	return fs
 }
@@ -53,6 +60,111 @@ func (fs *FileSet) Ingest(imports []*Import, links []*DependencyLink, replace bo
 	}
 }
 
+// AddExternals records imports that aren't bundled, since they don't resolve to a workspace file, and returns
+// those that weren't already recorded
+func (fs *FileSet) AddExternals(specifiers []string) []string {
+	var added []string
+	for _, specifier := range specifiers {
+		if !fs.externals[specifier] {
+			fs.externals[specifier] = true
+			added = append(added, specifier)
+		}
+	}
+	return added
+}
+
+// Externals lists the imports that aren't bundled, in order
+func (fs *FileSet) Externals() []string {
+	externals := make([]string, 0, len(fs.externals))
+	for specifier := range fs.externals {
+		externals = append(externals, specifier)
+	}
+	sort.Strings(externals)
+	return externals
+}
+
+// AddResolvedImports records the files that the bare imports (e.g. "lodash") of System.register files resolved to,
+// keyed by the import
+func (fs *FileSet) AddResolvedImports(resolvedImports map[string]string) {
+	for specifier, id := range resolvedImports {
+		fs.resolved[specifier] = id
+	}
+}
+
+// ResolvedImports gets the IDs of the files that bare imports resolved to, keyed by the import
+func (fs *FileSet) ResolvedImports() map[string]string {
+	return fs.resolved
+}
+
+// SetResolvedRequires records the files that a CommonJS file's bare requires (e.g. "lodash") resolved to, which its
+// System.register shim depends on directly, since nested node_modules can resolve a require to another version
+func (fs *FileSet) SetResolvedRequires(id string, resolvedRequires map[string]string) {
+	if file := fs.index[id]; file != nil {
+		file.requires = resolvedRequires
+	}
+}
+
+// Links gets the IDs of the files that each file imports, keyed by the importing file's ID
+func (fs *FileSet) Links() map[string][]string {
+	return fs.links
+}
+
+// SetLazyImports records the files that a file imports on demand (e.g. context_1.import("./admin/Admin")), which
+// are lazy edges: the files aren't necessarily in the FileSet
+func (fs *FileSet) SetLazyImports(id string, lazyIDs []string) {
+	if len(lazyIDs) == 0 {
+		delete(fs.lazyLinks, id)
+		return
+	}
+	fs.lazyLinks[id] = lazyIDs
+}
+
+// LazyLinks gets the files that each file imports on demand, keyed by the importing file's ID
+func (fs *FileSet) LazyLinks() map[string][]string {
+	return fs.lazyLinks
+}
+
+// LazyImports lists the IDs of the files imported on demand, in order, without duplicates
+func (fs *FileSet) LazyImports() []string {
+	seen := map[string]bool{}
+	lazyIDs := []string{}
+	for _, ids := range fs.lazyLinks {
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				lazyIDs = append(lazyIDs, id)
+			}
+		}
+	}
+	sort.Strings(lazyIDs)
+	return lazyIDs
+}
+
+// ImportChain finds the shortest chain of imports (including those made on demand) from one file to another,
+// e.g. ["app/src/App", "app/src/Routes", "app/src/util/Format"], or nil if it doesn't import it
+func (fs *FileSet) ImportChain(fromID string, toID string) []string {
+	previous := map[string]string{fromID: ""}
+	queue := []string{fromID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if id == toID {
+			chain := []string{}
+			for ; id != ""; id = previous[id] {
+				chain = append([]string{id}, chain...)
+			}
+			return chain
+		}
+		for _, dependencyID := range append(append([]string(nil), fs.links[id]...), fs.lazyLinks[id]...) {
+			if _, seen := previous[dependencyID]; !seen {
+				previous[dependencyID] = id
+				queue = append(queue, dependencyID)
+			}
+		}
+	}
+	return nil
+}
+
 // Dirty gets a flag indicating whether the FileSet needs to be rebundled
 func (fs *FileSet) Dirty() bool { return fs.dirty }
 
@@ -94,6 +206,20 @@ func (fs *FileSet) Add(file *File) bool {
 	return true
 }
 
+// Remove removes a File (and its links) from a FileSet, e.g. when it's bundled elsewhere
+func (fs *FileSet) Remove(id string) *File /* may be nil */ {
+	file := fs.index[id]
+	if file == nil {
+		return nil
+	}
+	delete(fs.index, id)
+	delete(fs.links, id)
+	delete(fs.reverseLinks, id)
+	delete(fs.lazyLinks, id)
+	fs.dirty = true
+	return file
+}
+
 // Replace overwrites a File in a FileSet
 func (fs *FileSet) Replace(file *File) {
 	// if !fs.Contains(file.ID) {
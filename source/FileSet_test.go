@@ -120,3 +120,29 @@ func indexOf(element string, data []string) int {
 	}
 	return -1
 }
+
+func TestLazyImports(t *testing.T) {
+	sut := NewEmptyFileSet(createWorkspace())
+	sut.SetLazyImports("app/Routes", []string{"app/admin/Admin", "app/help/Help"})
+	sut.SetLazyImports("app/help/Help", []string{"app/admin/Admin"})
+	assert.Equal(t, []string{"app/admin/Admin", "app/help/Help"}, sut.LazyImports())
+
+	sut.SetLazyImports("app/Routes", nil)
+	assert.Equal(t, []string{"app/admin/Admin"}, sut.LazyImports())
+	assert.Equal(t, map[string][]string{"app/help/Help": {"app/admin/Admin"}}, sut.LazyLinks())
+}
+
+func TestImportChain(t *testing.T) {
+	sut := NewEmptyFileSet(createWorkspace())
+	for _, id := range []string{"App", "Routes", "Admin", "Format", "Unused"} {
+		sut.Add(newFile(id, "c:\\"+id))
+	}
+	sut.AddLink(NewDependencyLink("App", []string{"Routes", "Format"}))
+	sut.AddLink(NewDependencyLink("Routes", []string{"Format"}))
+	sut.SetLazyImports("Routes", []string{"Admin"})
+
+	assert.Equal(t, []string{"App", "Format"}, sut.ImportChain("App", "Format"))
+	assert.Equal(t, []string{"App", "Routes", "Admin"}, sut.ImportChain("App", "Admin"))
+	assert.Equal(t, []string{"App"}, sut.ImportChain("App", "App"))
+	assert.Nil(t, sut.ImportChain("App", "Unused"))
+}
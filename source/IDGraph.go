@@ -2,6 +2,7 @@ package source
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 )
 
@@ -68,6 +69,75 @@ func (graph *IDGraph) SortTopologically(ids []string) []string {
 	return sortedIDs
 }
 
+// Cycles finds the circular dependencies in the graph: its strongly connected components with more than one ID,
+// or an ID that depends on itself.  The IDs of each cycle are sorted, as are the cycles.  SortTopologically breaks
+// cycles by removing edges, so call Cycles before sorting.
+func (graph *IDGraph) Cycles() [][]string {
+	ids := make([]string, 0, len(graph.egressEdges))
+	for id := range graph.egressEdges {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	// Tarjan's algorithm
+	index := 0
+	indices := make(map[string]int)
+	lowLinks := make(map[string]int)
+	onStack := make(map[string]bool)
+	stack := make([]string, 0, len(ids))
+	cycles := [][]string{}
+
+	var connect func(string)
+	connect = func(id string) {
+		indices[id] = index
+		lowLinks[id] = index
+		index++
+		stack = append(stack, id)
+		onStack[id] = true
+
+		selfLink := false
+		for _, depID := range graph.egressEdges[id] {
+			if depID == id {
+				selfLink = true
+			}
+			if _, visited := indices[depID]; !visited {
+				connect(depID)
+				if lowLinks[depID] < lowLinks[id] {
+					lowLinks[id] = lowLinks[depID]
+				}
+			} else if onStack[depID] && indices[depID] < lowLinks[id] {
+				lowLinks[id] = indices[depID]
+			}
+		}
+
+		if lowLinks[id] == indices[id] {
+			var component []string
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component = append(component, top)
+				if top == id {
+					break
+				}
+			}
+			if len(component) > 1 || selfLink {
+				sort.Strings(component)
+				cycles = append(cycles, component)
+			}
+		}
+	}
+
+	for _, id := range ids {
+		if _, visited := indices[id]; !visited {
+			connect(id)
+		}
+	}
+
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0] < cycles[j][0] })
+	return cycles
+}
+
 func (graph *IDGraph) removeDependentID(dependentID string, targetID string) {
 	dependencies := graph.egressEdges[dependentID]
 	for i, dependencyID := range dependencies {
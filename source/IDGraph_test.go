@@ -17,6 +17,50 @@ func TestIDGraph(t *testing.T) {
 	assert.True(t, assert.ObjectsAreEqual([]string{"d", "c", "b", "a"}, topoOrder))
 }
 
+func TestIDGraphCycles(t *testing.T) {
+	cases := map[string]struct {
+		links    map[string][]string
+		expected [][]string
+	}{
+		"acyclic": {
+			links:    map[string][]string{"a": []string{"b", "c"}, "b": []string{"c"}},
+			expected: [][]string{},
+		},
+		"two IDs": {
+			links:    map[string][]string{"a": []string{"b"}, "b": []string{"a", "c"}},
+			expected: [][]string{{"a", "b"}},
+		},
+		"self import": {
+			links:    map[string][]string{"a": []string{"a", "b"}},
+			expected: [][]string{{"a"}},
+		},
+		"separate cycles": {
+			links: map[string][]string{
+				"z": []string{"y"},
+				"y": []string{"x"},
+				"x": []string{"z", "a"},
+				"a": []string{"b"},
+				"b": []string{"a"},
+			},
+			expected: [][]string{{"a", "b"}, {"x", "y", "z"}},
+		},
+		"overlapping cycles": {
+			links: map[string][]string{
+				"a": []string{"b"},
+				"b": []string{"a", "c"},
+				"c": []string{"b", "d"},
+			},
+			expected: [][]string{{"a", "b", "c"}},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, c.expected, NewIDGraph(c.links).Cycles())
+		})
+	}
+}
+
 func TestStringStack(t *testing.T) {
 	ss := newStringStack([]string{"a", "b", "c"})
 	c := ss.pop()
@@ -0,0 +1,49 @@
+package source
+
+import (
+	"path"
+)
+
+// ImportResolver resolves a module's bare and aliased imports: first through the build's SystemJS config, then
+// through node_modules.  Packages in node_modules are only bundled by modules that opt in to them, except for those
+// that the module keeps external.
+type ImportResolver struct {
+	systemJS  *SystemJSResolver
+	packages  bool
+	externals []string
+}
+
+// NewImportResolver creates an ImportResolver.  Externals are package names, or globs of them (e.g. "@angular/*").
+func NewImportResolver(systemJS *SystemJSResolver, packages bool, externals []string) *ImportResolver {
+	return &ImportResolver{
+		systemJS:  systemJS,
+		packages:  packages,
+		externals: externals,
+	}
+}
+
+// BundlesPackages gets whether imports that resolve into node_modules are bundled, rather than kept external
+func (resolver *ImportResolver) BundlesPackages() bool {
+	return resolver != nil && resolver.packages
+}
+
+// IsExternal gets whether an import belongs to a package that's kept external
+func (resolver *ImportResolver) IsExternal(specifier string) bool {
+	if resolver == nil {
+		return false
+	}
+	packageName, _ := SplitPackageSpecifier(specifier)
+	for _, external := range resolver.externals {
+		if matched, err := path.Match(external, packageName); (err == nil && matched) || external == specifier {
+			return true
+		}
+	}
+	return false
+}
+
+func (resolver *ImportResolver) systemJSResolver() *SystemJSResolver {
+	if resolver == nil {
+		return nil
+	}
+	return resolver.systemJS
+}
@@ -27,26 +27,41 @@ func (jsfc *JSFileContents) SourceMappingURL() string {
 
 // ParseJSFileContents parses the contents of a JS file
 func ParseJSFileContents(name string, fileContents string) (*JSFileContents, error) {
+	return parseJSFileContents(name, fileContents, nil)
+}
+
+// parseJSFileContents parses the contents of a JS file, which depends on the files its bare requires resolved to
+// (if it's CommonJS)
+func parseJSFileContents(name string, fileContents string, resolvedRequires map[string]string) (*JSFileContents, error) {
 	lines := util.StringToLines(fileContents)
 
 	numLines := len(lines)
 	var imports []string
-	var foundRegister = false
 	var sourceMappingURL = ""
 	var foundSourceMap = false
 	var body []string
 	var preamble []string
 	var numPreambleLines int
-	if numLines > 0 {
+
+	// a malformed register call is bundled like any other script; dep.readDependencies reports where it went wrong
+	register, _ := ParseSystemRegister(fileContents)
+	foundRegister := register != nil
+	if foundRegister {
+		numPreambleLines, _ = jsPosition(fileContents, register.Start)
+		preamble = lines[:numPreambleLines]
+		for _, dependency := range register.Dependencies {
+			imports = append(imports, quoteJS(dependency.Path))
+		}
+	} else if numLines > 0 {
 		preamble, numPreambleLines = skipPreamble(lines)
+	}
+
+	if numLines > 0 {
 		if numPreambleLines == numLines {
 			body = preamble
 			preamble = []string{}
 			sourceMappingURL = ""
 		} else {
-			registerLine := lines[numPreambleLines]
-			imports, foundRegister = ParseRegisterDependencies(registerLine, false)
-
 			sourceMapLine := lines[numLines-1]
 			sourceMappingURL, foundSourceMap = parseSourceMappingURL(sourceMapLine)
 
@@ -59,7 +74,13 @@ func ParseJSFileContents(name string, fileContents string) (*JSFileContents, err
 
 	if foundRegister {
 		bodyCopy = append(bodyCopy, body...)
-		bodyCopy[len(preamble)] = getRegisterLineForBundle(name, imports)
+		replaceRegisterCall(bodyCopy[len(preamble):], fileContents, register, getRegisterCallForBundle(name, imports))
+	} else if IsNodeModule(name) {
+		header, footer := getCommonJSRegisterLines(name, ParseCommonJSRequires(fileContents), resolvedRequires)
+		bodyCopy = append(bodyCopy, header)
+		bodyCopy = append(bodyCopy, body...)
+		bodyCopy = append(bodyCopy, footer)
+		numLines += 2
 	} else {
 		bodyCopy = append(bodyCopy, getRegisterLineForBundle(name, nil))
 		bodyCopy = append(bodyCopy, body...)
@@ -77,8 +98,30 @@ func ParseJSFileContents(name string, fileContents string) (*JSFileContents, err
 	}, nil
 }
 
+// replaceRegisterCall replaces a file's System.register(..., [...], with the bundle's own, keeping the rest of the
+// call (i.e. the declare function, whatever its parameters are called).  When the original spans several lines,
+// the lines it vacates are left blank, so the file's source map still lines up.
+func replaceRegisterCall(body []string, fileContents string, register *RegisterCall, replacement string) {
+	startLine, startColumn := jsPosition(fileContents, register.Start)
+	endLine, endColumn := jsPosition(fileContents, register.End)
+	endLine -= startLine
+	if endLine >= len(body) {
+		return
+	}
+
+	body[0] = body[0][:startColumn] + replacement + body[endLine][endColumn:]
+	for i := 1; i <= endLine; i++ {
+		body[i] = ""
+	}
+}
+
+// getRegisterCallForBundle outputs the start of a System.register call with a name, up to the declare function
+func getRegisterCallForBundle(name string, imports []string) string {
+	importsJoined := strings.Join(imports, ", ")
+	return "System.register(\"" + name + ".js\", [" + importsJoined + "],"
+}
+
 // getRegisterLineForBundle outputs the System.register line with a name
 func getRegisterLineForBundle(name string, imports []string) string {
-	importsJoined := strings.Join(imports, ", ")
-	return "System.register(\"" + name + ".js\", [" + importsJoined + "], function (exports_1, context_1) {"
+	return getRegisterCallForBundle(name, imports) + " function (exports_1, context_1) {"
 }
@@ -0,0 +1,77 @@
+package source
+
+import (
+	"strings"
+)
+
+type jsTokenKind int
+
+const (
+	jsEOF jsTokenKind = iota
+	jsIdentifier
+	jsString
+	jsPunctuator
+	jsInvalid // e.g. an unterminated string
+)
+
+// jsToken is a token read by a jsTokenizer.  Strings are unquoted in value, whereas text is the raw source.
+type jsToken struct {
+	kind  jsTokenKind
+	text  string
+	value string
+	start int
+	end   int
+}
+
+// jsTokenizer splits the head of a javascript file into identifiers, strings and punctuators, skipping whitespace
+// and comments.  It doesn't understand numbers, regular expressions or template substitutions, which is plenty for
+// reading the System.register call that starts a module.
+type jsTokenizer struct {
+	scanner *jsScanner
+}
+
+func newJSTokenizer(js string) *jsTokenizer {
+	return &jsTokenizer{&jsScanner{js, 0}}
+}
+
+func (t *jsTokenizer) next() *jsToken {
+	s := t.scanner
+	s.skipSpaceAndComments()
+	start := s.pos
+	c := s.peek()
+	switch {
+	case s.pos >= len(s.js):
+		return &jsToken{kind: jsEOF, start: start, end: start}
+	case isQuote(c):
+		value, ok := s.readString()
+		if !ok {
+			if end := strings.IndexByte(s.js[start:], '\n'); end >= 0 {
+				s.pos = start + end
+			} else {
+				s.pos = len(s.js)
+			}
+			return &jsToken{kind: jsInvalid, text: s.js[start:s.pos], start: start, end: s.pos}
+		}
+		return &jsToken{kind: jsString, text: s.js[start:s.pos], value: value, start: start, end: s.pos}
+	case isJSIdentifierChar(c):
+		text := s.readIdentifier()
+		return &jsToken{kind: jsIdentifier, text: text, value: text, start: start, end: s.pos}
+	default:
+		s.pos++
+		return &jsToken{kind: jsPunctuator, text: s.js[start:s.pos], value: s.js[start:s.pos], start: start, end: s.pos}
+	}
+}
+
+func (token *jsToken) is(kind jsTokenKind, text string) bool {
+	return token.kind == kind && token.text == text
+}
+
+// jsPosition converts an offset into a zero-based line and column
+func jsPosition(js string, offset int) (line int, column int) {
+	if offset > len(js) {
+		offset = len(js)
+	}
+	line = strings.Count(js[:offset], "\n")
+	column = offset - (strings.LastIndex(js[:offset], "\n") + 1)
+	return line, column
+}
@@ -1,10 +1,13 @@
 package source
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"net/url"
+	"strings"
 	"github.com/mrcrowl/swarm/util"
 )
 
@@ -36,6 +39,35 @@ func (mapping *Mapping) Mappings() string {
 	return mapping.config.Mappings
 }
 
+// Sources returns the list of original sources referenced by the source map
+func (mapping *Mapping) Sources() []string {
+	if mapping.config == nil {
+		return nil
+	}
+	return mapping.config.Sources
+}
+
+// SourceRoot returns the sourceRoot that the source map's sources are relative to
+func (mapping *Mapping) SourceRoot() string {
+	if mapping.config == nil {
+		return ""
+	}
+	return mapping.config.SourceRoot
+}
+
+// Config returns the parsed source map, or nil if it hasn't been loaded
+func (mapping *Mapping) Config() *MapConfig {
+	return mapping.config
+}
+
+// Names returns the list of symbol names referenced by the source map
+func (mapping *Mapping) Names() []string {
+	if mapping.config == nil {
+		return nil
+	}
+	return mapping.config.Names
+}
+
 // MapPlayback is a cache of the line count and segment delta
 type MapPlayback struct {
 	LineCount    int
@@ -95,6 +127,11 @@ func NewMappingForTesting(config *MapConfig) *Mapping {
 	return &Mapping{config: config}
 }
 
+// IsInline indicates whether the source map is embedded in the sourceMappingURL as a data: URI
+func (mapping *Mapping) IsInline() bool {
+	return isDataURI(mapping.sourceMappingURL)
+}
+
 // RelativePath returns the path relative to the entry point
 func (mapping *Mapping) RelativePath() string {
 	return mapping.relativePath
@@ -116,10 +153,20 @@ func (mapping *Mapping) EnsureLoaded() {
 
 // LoadConfig loads the files contents
 func (mapping *Mapping) LoadConfig() {
-	contents, err := util.ReadContents(mapping.filepath)
-	if err != nil {
-		log.Printf("Failed to load source map: %s", mapping.filepath)
-		return
+	var contents string
+	var err error
+	if mapping.IsInline() {
+		contents, err = decodeDataURI(mapping.sourceMappingURL)
+		if err != nil {
+			log.Printf("Failed to decode inline source map: %s", mapping.relativePath)
+			return
+		}
+	} else {
+		contents, err = util.ReadContents(mapping.filepath)
+		if err != nil {
+			log.Printf("Failed to load source map: %s", mapping.filepath)
+			return
+		}
 	}
 
 	smapConfig, err := ParseSourceMapConfig(contents)
@@ -129,3 +176,30 @@ func (mapping *Mapping) LoadConfig() {
 	}
 	mapping.config = smapConfig
 }
+
+const sourceMapDataURIPrefix = "data:application/json;charset=utf-8;base64,"
+
+// EncodeSourceMapDataURI encodes a source map as a data: URI, suitable for an inline sourceMappingURL
+func EncodeSourceMapDataURI(sourceMapJSON string) string {
+	return sourceMapDataURIPrefix + base64.StdEncoding.EncodeToString([]byte(sourceMapJSON))
+}
+
+// decodeDataURI extracts the contents of a data: URI, e.g. data:application/json;base64,eyJ2ZXJzaW9uIjozfQ==
+func decodeDataURI(uri string) (string, error) {
+	commaPos := strings.Index(uri, ",")
+	if !isDataURI(uri) || commaPos < 0 {
+		return "", errors.New("Invalid data URI")
+	}
+
+	mediaType := uri[len("data:"):commaPos]
+	data := uri[commaPos+1:]
+	if strings.HasSuffix(mediaType, ";base64") {
+		bytes, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return "", err
+		}
+		return string(bytes), nil
+	}
+
+	return url.PathUnescape(data)
+}
@@ -1,6 +1,7 @@
 package source
 
 import (
+	"encoding/base64"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -30,3 +31,34 @@ func TestParseSourceMap(t *testing.T) {
 	// parsed := parseMappings(value.Mappings)
 	// assert.Len(t, parsed, 19)
 }
+
+func TestDecodeDataURI(t *testing.T) {
+	cases := map[string]struct {
+		uri      string
+		expected string
+	}{
+		"base64": {
+			uri:      "data:application/json;charset=utf-8;base64," + base64.StdEncoding.EncodeToString([]byte(firstJSON)),
+			expected: firstJSON,
+		},
+		"percent-encoded": {
+			uri:      `data:application/json,%7B%22version%22%3A3%7D`,
+			expected: `{"version":3}`,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			actual, err := decodeDataURI(tc.uri)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestLoadInlineSourceMap(t *testing.T) {
+	mapping := NewMapping(EncodeSourceMapDataURI(firstJSON), "First.ts", "")
+	assert.True(t, mapping.IsInline())
+	mapping.EnsureLoaded()
+	assert.Equal(t, []string{"First.ts"}, mapping.Sources())
+	assert.NotEmpty(t, mapping.Mappings())
+}
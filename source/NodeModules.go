@@ -0,0 +1,121 @@
+package source
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const nodeModulesDirname = "node_modules"
+
+// packageJSON is the part of a package.json that describes a package's entry point
+type packageJSON struct {
+	Main    string          `json:"main"`
+	Module  string          `json:"module"`
+	Browser json.RawMessage `json:"browser"` // either a path, or an object of path replacements
+}
+
+// SplitPackageSpecifier splits a bare import into its package name and the path within the package,
+// e.g. "@angular/core/testing" ==> "@angular/core", "testing"
+func SplitPackageSpecifier(specifier string) (packageName string, subpath string) {
+	parts := strings.SplitN(specifier, "/", 3)
+	if strings.HasPrefix(specifier, "@") && len(parts) > 1 {
+		return parts[0] + "/" + parts[1], strings.Join(parts[2:], "/")
+	}
+	return parts[0], strings.Join(parts[1:], "/")
+}
+
+// IsNodeModule gets whether a root-relative path is within a node_modules directory
+func IsNodeModule(relativePath string) bool {
+	return strings.Contains("/"+relativePath, "/"+nodeModulesDirname+"/")
+}
+
+// resolveNodeModule resolves a bare import the way node does: by looking for the package in node_modules,
+// starting in the importing directory and moving up to the root
+func (ws *Workspace) resolveNodeModule(specifier string, fromDirectory string) (string, bool) {
+	packageName, subpath := SplitPackageSpecifier(specifier)
+	if packageName == "" || strings.HasPrefix(packageName, ".") {
+		return "", false
+	}
+
+	directory := path.Clean(strings.TrimPrefix(fromDirectory, "/"))
+	for {
+		packagePath := path.Join(directory, nodeModulesDirname, packageName)
+		if path.Base(directory) == nodeModulesDirname {
+			packagePath = path.Join(directory, packageName) // already in node_modules
+		}
+		if ws.isDirectory(packagePath) {
+			if subpath != "" {
+				return ws.resolveFileOrDirectory(path.Join(packagePath, subpath))
+			}
+			return ws.resolvePackageEntryPoint(packagePath)
+		}
+		if directory == "." || directory == "/" || directory == "" {
+			return "", false
+		}
+		directory = path.Dir(directory)
+	}
+}
+
+// resolvePackageEntryPoint finds a package's entry point from its package.json.  Since bundles are System.register
+// modules, and swarm doesn't transpile, the CommonJS main is preferred to an ES module, but a browser-specific
+// build is preferred to both.
+func (ws *Workspace) resolvePackageEntryPoint(packagePath string) (string, bool) {
+	pkg := ws.readPackageJSON(packagePath)
+
+	var browserPath string
+	var browserReplacements map[string]interface{}
+	if json.Unmarshal(pkg.Browser, &browserPath) != nil {
+		json.Unmarshal(pkg.Browser, &browserReplacements)
+	}
+
+	entryPoint := "index.js"
+	for _, candidate := range []string{browserPath, pkg.Main, pkg.Module} {
+		if candidate != "" {
+			entryPoint = candidate
+			break
+		}
+	}
+
+	// e.g. "browser": { "./lib/node.js": "./lib/browser.js" }
+	for from, to := range browserReplacements {
+		if replacement, ok := to.(string); ok && path.Clean(from) == path.Clean(entryPoint) {
+			entryPoint = replacement
+		}
+	}
+
+	return ws.resolveFileOrDirectory(path.Join(packagePath, entryPoint))
+}
+
+func (ws *Workspace) readPackageJSON(packagePath string) *packageJSON {
+	pkg := &packageJSON{}
+	bytes, err := ioutil.ReadFile(filepath.Join(ws.rootPath, packagePath, "package.json"))
+	if err == nil {
+		json.Unmarshal(bytes, pkg)
+	}
+	return pkg
+}
+
+// resolveFileOrDirectory finds the file for a path the way require() does: the file itself, with a .js extension,
+// or the index.js of the directory
+func (ws *Workspace) resolveFileOrDirectory(relativePath string) (string, bool) {
+	for _, candidate := range []string{relativePath, relativePath + ".js", path.Join(relativePath, "index.js")} {
+		if ws.isFile(candidate) {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+func (ws *Workspace) isFile(relativePath string) bool {
+	info, err := os.Stat(filepath.Join(ws.rootPath, relativePath))
+	return err == nil && !info.IsDir()
+}
+
+func (ws *Workspace) isDirectory(relativePath string) bool {
+	info, err := os.Stat(filepath.Join(ws.rootPath, relativePath))
+	return err == nil && info.IsDir()
+}
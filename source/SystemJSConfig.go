@@ -0,0 +1,435 @@
+package source
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var systemJSConfigCalls = []string{"System.config(", "SystemJS.config("}
+
+const systemJSRewrittenComment = " /* <-- REWRITTEN BY SWARM */"
+const systemJSAddedComment = " /* <-- ADDED BY SWARM */"
+
+// SystemJSConfig is the object literal passed to System.config({...}) in a systemjs.config.js file.  Entries of
+// its sections (e.g. map and paths) can be overridden without disturbing the rest of the file.
+type SystemJSConfig struct {
+	js        string
+	object    *jsObjectLiteral
+	overrides map[string][]*jsProperty
+}
+
+type jsObjectLiteral struct {
+	open       int // position of the {
+	properties []*jsProperty
+}
+
+type jsProperty struct {
+	key        string
+	keyStart   int
+	value      string // unquoted for string literals, otherwise the raw source
+	isString   bool
+	valueStart int
+	valueEnd   int
+	object     *jsObjectLiteral // set for object literal values
+}
+
+// ParseSystemJSConfig finds and parses the first System.config({...}) call in some javascript
+func ParseSystemJSConfig(js string) (*SystemJSConfig, bool) {
+	callPos := -1
+	for _, call := range systemJSConfigCalls {
+		if pos := strings.Index(js, call); pos >= 0 && (callPos < 0 || pos < callPos) {
+			callPos = pos + len(call)
+		}
+	}
+	if callPos < 0 {
+		return nil, false
+	}
+
+	scanner := &jsScanner{js, callPos}
+	scanner.skipSpaceAndComments()
+	if scanner.peek() != '{' {
+		return nil, false
+	}
+	object, ok := scanner.parseObject()
+	if !ok {
+		return nil, false
+	}
+	return &SystemJSConfig{js, object, map[string][]*jsProperty{}}, true
+}
+
+// Get finds the value of a string entry in a section, e.g. Get("map", "common")
+func (cfg *SystemJSConfig) Get(section string, key string) (string, bool) {
+	for _, override := range cfg.overrides[section] {
+		if override.key == key && override.isString {
+			return override.value, true
+		}
+	}
+	if prop := cfg.object.property(section); prop != nil && prop.object != nil {
+		if entry := prop.object.property(key); entry != nil && entry.isString {
+			return entry.value, true
+		}
+	}
+	return "", false
+}
+
+// Value gets a top-level string property of the config, e.g. Value("baseURL")
+func (cfg *SystemJSConfig) Value(key string) (string, bool) {
+	if prop := cfg.object.property(key); prop != nil && prop.isString {
+		return prop.value, true
+	}
+	return "", false
+}
+
+// Entries gets the string entries of a section, including overrides, e.g. Entries("map")
+func (cfg *SystemJSConfig) Entries(section string) map[string]string {
+	entries := map[string]string{}
+	if prop := cfg.object.property(section); prop != nil && prop.object != nil {
+		entries = prop.object.stringEntries()
+	}
+	for _, override := range cfg.overrides[section] {
+		if override.isString {
+			entries[override.key] = override.value
+		}
+	}
+	return entries
+}
+
+// ObjectEntries gets the string entries of each object in a section, e.g. ObjectEntries("packages")
+func (cfg *SystemJSConfig) ObjectEntries(section string) map[string]map[string]string {
+	objects := map[string]map[string]string{}
+	if prop := cfg.object.property(section); prop != nil && prop.object != nil {
+		for _, entry := range prop.object.properties {
+			if entry.object != nil {
+				objects[entry.key] = entry.object.stringEntries()
+			}
+		}
+	}
+	return objects
+}
+
+// Set overrides (or adds) a string entry in a section, e.g. Set("map", "common", "../common")
+func (cfg *SystemJSConfig) Set(section string, key string, value string) {
+	cfg.override(section, &jsProperty{key: key, value: value, isString: true})
+}
+
+// SetList overrides (or adds) a list of strings in a section, e.g. SetList("bundles", "/shared.js", modules)
+func (cfg *SystemJSConfig) SetList(section string, key string, values []string) {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = quoteJS(value)
+	}
+	cfg.override(section, &jsProperty{key: key, value: "[" + strings.Join(quoted, ", ") + "]"})
+}
+
+func (cfg *SystemJSConfig) override(section string, prop *jsProperty) {
+	for i, override := range cfg.overrides[section] {
+		if override.key == prop.key {
+			cfg.overrides[section][i] = prop
+			return
+		}
+	}
+	cfg.overrides[section] = append(cfg.overrides[section], prop)
+}
+
+// CheckOverrides reports an overridden section that can't be edited in place, because it's set to something other
+// than an object literal (e.g. a variable)
+func (cfg *SystemJSConfig) CheckOverrides() error {
+	for _, section := range cfg.sections() {
+		if prop := cfg.object.property(section); prop != nil && prop.object == nil {
+			return fmt.Errorf("System.config({...}) %s isn't an object literal, so can't be rewritten", section)
+		}
+	}
+	return nil
+}
+
+// String outputs the javascript with the overrides applied.  Sections that aren't object literals are left alone.
+func (cfg *SystemJSConfig) String() string {
+	type edit struct {
+		start, end int
+		text       string
+	}
+	edits := []edit{}
+
+	indent := cfg.indentOf(cfg.object, "")
+	for _, section := range cfg.sections() {
+		prop := cfg.object.property(section)
+		if prop != nil && prop.object == nil {
+			continue
+		}
+		if prop == nil {
+			var sb strings.Builder
+			sb.WriteString("\n" + indent + section + ": {")
+			for _, override := range cfg.overrides[section] {
+				sb.WriteString("\n" + indent + "\t" + quoteJS(override.key) + ": " + override.source() + ",")
+			}
+			sb.WriteString("\n" + indent + "}," + systemJSAddedComment)
+			edits = append(edits, edit{cfg.object.open + 1, cfg.object.open + 1, sb.String()})
+			continue
+		}
+
+		var added strings.Builder
+		entryIndent := cfg.indentOf(prop.object, indent+"\t")
+		for _, override := range cfg.overrides[section] {
+			if entry := prop.object.property(override.key); entry != nil {
+				edits = append(edits, edit{entry.valueStart, entry.valueEnd, override.source() + cfg.rewrittenComment(entry)})
+			} else {
+				added.WriteString("\n" + entryIndent + quoteJS(override.key) + ": " + override.source() + "," + systemJSAddedComment)
+			}
+		}
+		if added.Len() > 0 {
+			edits = append(edits, edit{prop.object.open + 1, prop.object.open + 1, added.String()})
+		}
+	}
+
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+	var sb strings.Builder
+	last := 0
+	for _, e := range edits {
+		sb.WriteString(cfg.js[last:e.start])
+		sb.WriteString(e.text)
+		last = e.end
+	}
+	sb.WriteString(cfg.js[last:])
+	return sb.String()
+}
+
+// sections lists the overridden sections, in order
+func (cfg *SystemJSConfig) sections() []string {
+	sections := make([]string, 0, len(cfg.overrides))
+	for section := range cfg.overrides {
+		sections = append(sections, section)
+	}
+	sort.Strings(sections)
+	return sections
+}
+
+// rewrittenComment marks a rewritten entry, unless it's already marked (e.g. by a text rewrite)
+func (cfg *SystemJSConfig) rewrittenComment(entry *jsProperty) string {
+	following := strings.TrimLeft(cfg.js[entry.valueEnd:], ", \t")
+	if strings.HasPrefix(following, strings.TrimSpace(systemJSRewrittenComment)) {
+		return ""
+	}
+	return systemJSRewrittenComment
+}
+
+// indentOf gets the whitespace before the first property of an object, if it starts its own line
+func (cfg *SystemJSConfig) indentOf(object *jsObjectLiteral, fallback string) string {
+	if len(object.properties) == 0 {
+		return fallback
+	}
+	keyStart := object.properties[0].keyStart
+	lineStart := strings.LastIndex(cfg.js[:keyStart], "\n") + 1
+	indent := cfg.js[lineStart:keyStart]
+	if strings.TrimSpace(indent) != "" || lineStart == 0 {
+		return fallback
+	}
+	return indent
+}
+
+// source outputs a property's value as javascript
+func (prop *jsProperty) source() string {
+	if prop.isString {
+		return quoteJS(prop.value)
+	}
+	return prop.value
+}
+
+func (object *jsObjectLiteral) property(key string) *jsProperty {
+	for _, prop := range object.properties {
+		if prop.key == key {
+			return prop
+		}
+	}
+	return nil
+}
+
+func (object *jsObjectLiteral) stringEntries() map[string]string {
+	entries := map[string]string{}
+	for _, prop := range object.properties {
+		if prop.isString {
+			entries[prop.key] = prop.value
+		}
+	}
+	return entries
+}
+
+func quoteJS(value string) string {
+	quoted, _ := json.Marshal(value)
+	return string(quoted)
+}
+
+// jsScanner is just enough of a javascript scanner to read object literals
+type jsScanner struct {
+	js  string
+	pos int
+}
+
+func (s *jsScanner) peek() byte {
+	if s.pos >= len(s.js) {
+		return 0
+	}
+	return s.js[s.pos]
+}
+
+func (s *jsScanner) skipSpaceAndComments() {
+	for s.pos < len(s.js) {
+		rest := s.js[s.pos:]
+		switch {
+		case strings.HasPrefix(rest, "//"):
+			if end := strings.IndexByte(rest, '\n'); end >= 0 {
+				s.pos += end + 1
+			} else {
+				s.pos = len(s.js)
+			}
+		case strings.HasPrefix(rest, "/*"):
+			if end := strings.Index(rest[2:], "*/"); end >= 0 {
+				s.pos += end + 4
+			} else {
+				s.pos = len(s.js)
+			}
+		case rest[0] == ' ' || rest[0] == '\t' || rest[0] == '\r' || rest[0] == '\n':
+			s.pos++
+		default:
+			return
+		}
+	}
+}
+
+// readString reads a quoted string literal, returning its unquoted value
+func (s *jsScanner) readString() (string, bool) {
+	quote := s.js[s.pos]
+	var sb strings.Builder
+	for i := s.pos + 1; i < len(s.js); i++ {
+		c := s.js[i]
+		switch {
+		case c == quote:
+			s.pos = i + 1
+			return sb.String(), true
+		case c == '\\' && i+1 < len(s.js):
+			i++
+			sb.WriteByte(s.js[i])
+		case c == '\n' && quote != '`':
+			return "", false
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", false
+}
+
+func (s *jsScanner) readIdentifier() string {
+	start := s.pos
+	for s.pos < len(s.js) && isJSIdentifierChar(s.js[s.pos]) {
+		s.pos++
+	}
+	return s.js[start:s.pos]
+}
+
+func isJSIdentifierChar(c byte) bool {
+	return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func isQuote(c byte) bool {
+	return c == '"' || c == '\'' || c == '`'
+}
+
+// parseObject reads an object literal of key: value properties; anything fancier (methods, spreads, computed
+// keys) isn't supported
+func (s *jsScanner) parseObject() (*jsObjectLiteral, bool) {
+	object := &jsObjectLiteral{open: s.pos}
+	s.pos++
+	for {
+		s.skipSpaceAndComments()
+		c := s.peek()
+		switch {
+		case c == '}':
+			s.pos++
+			return object, true
+		case c == ',':
+			s.pos++
+			continue
+		case c == 0:
+			return nil, false
+		}
+
+		prop := &jsProperty{keyStart: s.pos}
+		switch {
+		case isQuote(c):
+			key, ok := s.readString()
+			if !ok {
+				return nil, false
+			}
+			prop.key = key
+		case isJSIdentifierChar(c):
+			prop.key = s.readIdentifier()
+		default:
+			return nil, false
+		}
+
+		s.skipSpaceAndComments()
+		if s.peek() != ':' {
+			return nil, false
+		}
+		s.pos++
+		s.skipSpaceAndComments()
+
+		prop.valueStart = s.pos
+		switch c := s.peek(); {
+		case isQuote(c):
+			value, ok := s.readString()
+			if !ok {
+				return nil, false
+			}
+			prop.value, prop.isString = value, true
+		case c == '{':
+			if nested, ok := s.parseObject(); ok {
+				prop.object = nested
+			} else {
+				s.pos = prop.valueStart
+				s.skipValue()
+			}
+		default:
+			s.skipValue()
+		}
+		prop.valueEnd = s.pos
+		if !prop.isString {
+			prop.value = s.js[prop.valueStart:prop.valueEnd]
+		}
+		object.properties = append(object.properties, prop)
+	}
+}
+
+// skipValue advances to the end of an arbitrary expression, i.e. the next , or } that isn't nested
+func (s *jsScanner) skipValue() {
+	depth := 0
+	end := s.pos
+	for s.pos < len(s.js) {
+		s.skipSpaceAndComments()
+		c := s.peek()
+		switch {
+		case c == 0:
+			return
+		case isQuote(c):
+			if _, ok := s.readString(); !ok {
+				s.pos++
+			}
+			end = s.pos
+			continue
+		case c == '{' || c == '[' || c == '(':
+			depth++
+		case c == '}' || c == ']' || c == ')':
+			if depth == 0 {
+				s.pos = end
+				return
+			}
+			depth--
+		case c == ',' && depth == 0:
+			s.pos = end
+			return
+		}
+		s.pos++
+		end = s.pos
+	}
+}
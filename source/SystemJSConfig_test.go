@@ -0,0 +1,103 @@
+package source
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const systemJSConfigSample = `(function (global) {
+	System.config({
+		// comments and functions are left alone
+		baseURL: "/app/",
+		packages: { app: { defaultExtension: "js", meta: { "*.js": { loader: function () { return {}; } } } } },
+		map: {
+			appdir: './src/',
+			"common": "./common", /* shared */
+		}
+	});
+})(this);`
+
+func TestParseSystemJSConfig(t *testing.T) {
+	cfg, ok := ParseSystemJSConfig(systemJSConfigSample)
+	assert.True(t, ok)
+
+	value, found := cfg.Get("map", "appdir")
+	assert.True(t, found)
+	assert.Equal(t, "./src/", value)
+	value, found = cfg.Get("map", "common")
+	assert.True(t, found)
+	assert.Equal(t, "./common", value)
+	_, found = cfg.Get("map", "services")
+	assert.False(t, found)
+	_, found = cfg.Get("paths", "npm:")
+	assert.False(t, found)
+	assert.Equal(t, systemJSConfigSample, cfg.String())
+}
+
+func TestParseSystemJSConfigMissing(t *testing.T) {
+	cases := map[string]string{
+		"no config call":     `System.import("app")`,
+		"not an object":      `System.config(config);`,
+		"unterminated":       `System.config({ map: { a: "b" `,
+		"method shorthand":   `System.config({ fetch() { return 1; } });`,
+		"unterminated quote": `System.config({ baseURL: "/app/ });`,
+	}
+	for name, js := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, ok := ParseSystemJSConfig(js)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestSystemJSConfigSet(t *testing.T) {
+	cfg, _ := ParseSystemJSConfig(systemJSConfigSample)
+	cfg.Set("map", "common", "../common")
+	cfg.Set("map", "ep/App", "/app/src/ep/App.js")
+	cfg.Set("paths", "npm:", "node_modules/")
+	cfg.Set("map", "ep/App", "/app/src/ep/Main.js")
+
+	value, _ := cfg.Get("map", "ep/App")
+	assert.Equal(t, "/app/src/ep/Main.js", value)
+
+	expected := `(function (global) {
+	System.config({
+		paths: {
+			"npm:": "node_modules/",
+		}, /* <-- ADDED BY SWARM */
+		// comments and functions are left alone
+		baseURL: "/app/",
+		packages: { app: { defaultExtension: "js", meta: { "*.js": { loader: function () { return {}; } } } } },
+		map: {
+			"ep/App": "/app/src/ep/Main.js", /* <-- ADDED BY SWARM */
+			appdir: './src/',
+			"common": "../common" /* <-- REWRITTEN BY SWARM */, /* shared */
+		}
+	});
+})(this);`
+	assert.Equal(t, expected, cfg.String())
+}
+
+func TestSystemJSConfigSetList(t *testing.T) {
+	cfg, _ := ParseSystemJSConfig(`System.config({ bundles: { "/old.js": ["a.js"] } });`)
+	cfg.SetList("bundles", "/shared.js", []string{"app/src/Util.js", "app/src/Format.js"})
+	cfg.SetList("bundles", "/old.js", nil)
+
+	_, isString := cfg.Get("bundles", "/shared.js")
+	assert.False(t, isString)
+	expected := `System.config({ bundles: {
+	"/shared.js": ["app/src/Util.js", "app/src/Format.js"], /* <-- ADDED BY SWARM */ "/old.js": [] /* <-- REWRITTEN BY SWARM */ } });`
+	assert.Equal(t, expected, cfg.String())
+}
+
+func TestSystemJSConfigCheckOverrides(t *testing.T) {
+	configJS := `System.config({ map: appMap, paths: { "npm:": "node_modules/" } });`
+	cfg, _ := ParseSystemJSConfig(configJS)
+	cfg.Set("paths", "npm:", "/node_modules/")
+	assert.Nil(t, cfg.CheckOverrides())
+
+	cfg.Set("map", "common", "../common")
+	assert.EqualError(t, cfg.CheckOverrides(), "System.config({...}) map isn't an object literal, so can't be rewritten")
+	assert.NotContains(t, cfg.String(), "common")
+}
@@ -0,0 +1,148 @@
+package source
+
+import (
+	"path"
+	"strings"
+)
+
+// SystemJSResolver resolves bare and aliased import specifiers (e.g. "lodash" or "common/util") the way SystemJS
+// would, using the map, paths and packages of a build's System.config({...})
+type SystemJSResolver struct {
+	baseURL  string // rooted, e.g. "/app/"
+	mapping  map[string]string
+	paths    map[string]string
+	packages map[string]*systemJSPackage // keyed by rooted path, e.g. "/app/src"
+}
+
+type systemJSPackage struct {
+	main             string
+	defaultExtension string
+}
+
+// NewSystemJSResolver creates a SystemJSResolver for a build's config.  The baseURL of the config is relative to
+// the build's base href, which is also the baseURL when the config doesn't specify one.
+func NewSystemJSResolver(cfg *SystemJSConfig, baseHref string) *SystemJSResolver {
+	baseURL := path.Join("/", baseHref)
+	if configBaseURL, ok := cfg.Value("baseURL"); ok && !isURL(configBaseURL) {
+		baseURL = resolveURLPath(baseURL+"/", configBaseURL)
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/") + "/"
+
+	packages := map[string]*systemJSPackage{}
+	for packagePath, entries := range cfg.ObjectEntries("packages") {
+		packages[resolveURLPath(baseURL, packagePath)] = &systemJSPackage{
+			main:             entries["main"],
+			defaultExtension: entries["defaultExtension"],
+		}
+	}
+
+	return &SystemJSResolver{
+		baseURL:  baseURL,
+		mapping:  cfg.Entries("map"),
+		paths:    cfg.Entries("paths"),
+		packages: packages,
+	}
+}
+
+// Resolve resolves an import specifier to a root-relative path, e.g. "lodash" to "node_modules/lodash/lodash.js",
+// or returns false if it resolves beyond the server (e.g. to a CDN), or there's no resolver
+func (resolver *SystemJSResolver) Resolve(specifier string) (string, bool) {
+	if resolver == nil {
+		return "", false
+	}
+
+	resolved := applyMapping(resolver.mapping, specifier)
+	if !isRelativeOrRooted(resolved) {
+		resolved = applyPaths(resolver.paths, resolved)
+	}
+	if isURL(resolved) {
+		return "", false
+	}
+	resolved = resolver.applyPackage(resolveURLPath(resolver.baseURL, resolved))
+	return strings.TrimPrefix(resolved, "/"), true
+}
+
+// IsMapped gets whether the config's map or paths apply to an import specifier
+func (resolver *SystemJSResolver) IsMapped(specifier string) bool {
+	if resolver == nil {
+		return false
+	}
+	return applyMapping(resolver.mapping, specifier) != specifier || applyPaths(resolver.paths, specifier) != specifier
+}
+
+// applyMapping replaces the longest map key that matches the whole specifier, or a leading part of it
+func applyMapping(mapping map[string]string, specifier string) string {
+	longest := ""
+	for key := range mapping {
+		if (specifier == key || strings.HasPrefix(specifier, key+"/")) && len(key) > len(longest) {
+			longest = key
+		}
+	}
+	if longest == "" {
+		return specifier
+	}
+	return mapping[longest] + specifier[len(longest):]
+}
+
+// applyPaths applies the most specific paths rule: an exact match, or a wildcard, e.g. "npm:*": "node_modules/*"
+func applyPaths(paths map[string]string, specifier string) string {
+	if target, ok := paths[specifier]; ok {
+		return target
+	}
+	longest, wildcard := "", ""
+	for key := range paths {
+		star := strings.Index(key, "*")
+		if star < 0 {
+			continue
+		}
+		prefix, suffix := key[:star], key[star+1:]
+		if strings.HasPrefix(specifier, prefix) && strings.HasSuffix(specifier, suffix) &&
+			len(specifier) >= len(prefix)+len(suffix) && (wildcard == "" || len(prefix) > len(longest)) {
+			longest = prefix
+			wildcard = key
+		}
+	}
+	if wildcard == "" {
+		return specifier
+	}
+	star := strings.Index(wildcard, "*")
+	matched := specifier[star : len(specifier)-(len(wildcard)-star-1)]
+	return strings.Replace(paths[wildcard], "*", matched, 1)
+}
+
+// applyPackage uses the main of a package imported by its own path, and adds its default extension
+func (resolver *SystemJSResolver) applyPackage(urlPath string) string {
+	longest := ""
+	for packagePath := range resolver.packages {
+		if (urlPath == packagePath || strings.HasPrefix(urlPath, packagePath+"/")) && len(packagePath) > len(longest) {
+			longest = packagePath
+		}
+	}
+	if longest == "" {
+		return urlPath
+	}
+	pkg := resolver.packages[longest]
+	if urlPath == longest && pkg.main != "" {
+		urlPath = resolveURLPath(longest+"/", pkg.main)
+	}
+	if pkg.defaultExtension != "" && path.Ext(urlPath) == "" {
+		urlPath += "." + pkg.defaultExtension
+	}
+	return urlPath
+}
+
+// resolveURLPath resolves a path relative to a (rooted) base URL path, unless it's rooted itself
+func resolveURLPath(baseURL string, urlPath string) string {
+	if strings.HasPrefix(urlPath, "/") {
+		return path.Clean(urlPath)
+	}
+	return path.Join(baseURL, urlPath)
+}
+
+func isRelativeOrRooted(specifier string) bool {
+	return strings.HasPrefix(specifier, "./") || strings.HasPrefix(specifier, "../") || strings.HasPrefix(specifier, "/")
+}
+
+func isURL(specifier string) bool {
+	return strings.Contains(specifier, "://")
+}
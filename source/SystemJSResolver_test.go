@@ -0,0 +1,67 @@
+package source
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const systemJSResolverConfig = `System.config({
+	paths: {
+		"npm:*": "/node_modules/*",
+		"cdn:*": "https://cdn.example.com/*",
+		"libs:": "../libs/",
+	},
+	map: {
+		lodash: "npm:lodash/lodash.js",
+		common: "../common",
+		"common/legacy": "../legacy",
+		rxjs: "npm:rxjs",
+		jquery: "cdn:jquery.js",
+	},
+	packages: {
+		"/node_modules/rxjs": { main: "index", defaultExtension: "js" },
+		"../common": { defaultExtension: "js" },
+	}
+});`
+
+func TestSystemJSResolver(t *testing.T) {
+	cfg, ok := ParseSystemJSConfig(systemJSResolverConfig)
+	assert.True(t, ok)
+	resolver := NewSystemJSResolver(cfg, "app")
+
+	cases := map[string]struct {
+		specifier string
+		expected  string
+		ok        bool
+	}{
+		"mapped through paths": {"lodash", "node_modules/lodash/lodash.js", true},
+		"mapped prefix":        {"common/util", "common/util.js", true},
+		"longest map prefix":   {"common/legacy/dict", "legacy/dict", true},
+		"package main":         {"rxjs", "node_modules/rxjs/index.js", true},
+		"package file":         {"rxjs/operators", "node_modules/rxjs/operators.js", true},
+		"exact path":           {"libs:", "libs", true},
+		"unmapped":             {"tslib", "app/tslib", true},
+		"not a map prefix":     {"commonplace", "app/commonplace", true},
+		"beyond the server":    {"jquery", "", false},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			resolved, ok := resolver.Resolve(c.specifier)
+			assert.Equal(t, c.ok, ok)
+			assert.Equal(t, c.expected, resolved)
+		})
+	}
+}
+
+func TestSystemJSResolverBaseURL(t *testing.T) {
+	cfg, _ := ParseSystemJSConfig(`System.config({ baseURL: "../", map: { util: "./src/util.js" } });`)
+	resolved, ok := NewSystemJSResolver(cfg, "app").Resolve("util")
+	assert.True(t, ok)
+	assert.Equal(t, "src/util.js", resolved)
+}
+
+func TestNilSystemJSResolver(t *testing.T) {
+	_, ok := (*SystemJSResolver)(nil).Resolve("lodash")
+	assert.False(t, ok)
+}
@@ -3,9 +3,11 @@ package source
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"github.com/mrcrowl/swarm/config"
+	"github.com/mrcrowl/swarm/util"
 )
 
 // Workspace is
@@ -13,6 +15,9 @@ type Workspace struct {
 	rootPath string
 }
 
+// ConfigFilename is the file at the root of the workspace that holds the values interpolated into import paths
+const ConfigFilename = "Config.js"
+
 var explicitSep = os.PathSeparator
 
 func emulateUnix() {
@@ -69,22 +74,52 @@ func (ws *Workspace) ReadInterpolationValues(config *config.RuntimeConfig) map[s
 
 // ReadSourceFile loads a source file
 func (ws *Workspace) ReadSourceFile(imp *Import) (*File, error) {
-	exists := false
-	absoluteFilePath := ""
-	for _, ext := range []string{"", ".js"} {
-		absoluteFilePath = filepath.Join(ws.rootPath, (imp.Path() + ext))
-		if _, err := os.Stat(absoluteFilePath); os.IsNotExist(err) {
-			continue
+	if absoluteFilePath, exists := ws.locateFile(imp.Path()); exists {
+		return newFile(imp.Path(), absoluteFilePath), nil
+	}
+
+	return nil, os.ErrNotExist
+}
+
+// ResolveImport resolves a bare or aliased import (e.g. "lodash" or "common/util") to a root-relative import of a
+// workspace file: through the build's SystemJS config, or if it doesn't map the import, through node_modules
+// (starting from the importing file).  Imports that don't resolve to a file are external.
+func (ws *Workspace) ResolveImport(imp *Import, importer *Import, resolver *ImportResolver) (*Import, bool) {
+	specifier := imp.Path()
+	if resolver.IsExternal(specifier) {
+		return nil, false
+	}
+
+	systemJS := resolver.systemJSResolver()
+	resolvedPath, ok := systemJS.Resolve(specifier)
+	if _, exists := ws.locateFile(resolvedPath); !ok || !exists {
+		if systemJS.IsMapped(specifier) || !resolver.BundlesPackages() {
+			return nil, false
 		}
-		exists = true
-		break
+		if resolvedPath, ok = ws.resolveNodeModule(specifier, importer.Directory); !ok {
+			return nil, false
+		}
+	}
+	if IsNodeModule(resolvedPath) && !resolver.BundlesPackages() {
+		return nil, false
 	}
 
-	if exists {
-		return newFile(imp.Path(), absoluteFilePath), nil
+	if path.Ext(resolvedPath) == ".js" {
+		resolvedPath = util.RemoveExtension(resolvedPath)
 	}
+	return NewImport(resolvedPath), true
+}
 
-	return nil, os.ErrNotExist
+// locateFile finds the file for a root-relative path, which may omit the .js extension
+func (ws *Workspace) locateFile(relativePath string) (string, bool) {
+	for _, ext := range []string{"", ".js"} {
+		absoluteFilePath := filepath.Join(ws.rootPath, (relativePath + ext))
+		if info, err := os.Stat(absoluteFilePath); err != nil || info.IsDir() {
+			continue
+		}
+		return absoluteFilePath, true
+	}
+	return "", false
 }
 
 // ToRelativePath converts an absolute filepath to a root-relative path
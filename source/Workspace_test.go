@@ -1,8 +1,10 @@
 package source
 
 import (
+	"path"
 	"testing"
 
+	"github.com/mrcrowl/swarm/testutil"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -40,3 +42,86 @@ func TestToRelativePathInvalid(t *testing.T) {
 	assert.Equal(t, "", relative)
 	assert.False(t, ok)
 }
+
+func TestResolveImport(t *testing.T) {
+	temppath := testutil.CreateTempDir()
+	defer testutil.RemoveTempDir(temppath)
+	testutil.WriteTextFile(testutil.MakeSubdirectoryTree(temppath, "node_modules/lodash"), "lodash.js", "")
+	testutil.MakeSubdirectoryTree(temppath, "app/tslib")
+	cfg, _ := ParseSystemJSConfig(`System.config({ map: { lodash: "/node_modules/lodash/lodash.js", cdn: "https://cdn.example.com/cdn.js" } });`)
+	resolver := NewImportResolver(NewSystemJSResolver(cfg, "app"), true, nil)
+	ws := NewWorkspace(temppath)
+	importer := NewImport("app/src/App")
+
+	imp, ok := ws.ResolveImport(NewImport("lodash"), importer, resolver)
+	assert.True(t, ok)
+	assert.Equal(t, "node_modules/lodash/lodash", imp.Path())
+
+	_, ok = ws.ResolveImport(NewImport("tslib"), importer, resolver)
+	assert.False(t, ok, "directories aren't files")
+	_, ok = ws.ResolveImport(NewImport("cdn"), importer, resolver)
+	assert.False(t, ok, "mapped imports don't fall back to node_modules")
+	_, ok = ws.ResolveImport(NewImport("lodash"), importer, NewImportResolver(nil, true, []string{"lodash"}))
+	assert.False(t, ok, "external")
+	_, ok = ws.ResolveImport(NewImport("lodash"), importer, NewImportResolver(NewSystemJSResolver(cfg, "app"), false, nil))
+	assert.False(t, ok, "packages aren't bundled unless the module opts in")
+}
+
+func TestResolveNodeModule(t *testing.T) {
+	temppath := testutil.CreateTempDir()
+	defer testutil.RemoveTempDir(temppath)
+	writePackage := func(packagePath string, packageJSON string, files ...string) {
+		dir := testutil.MakeSubdirectoryTree(temppath, packagePath)
+		if packageJSON != "" {
+			testutil.WriteTextFile(dir, "package.json", packageJSON)
+		}
+		for _, file := range files {
+			testutil.WriteTextFile(testutil.MakeSubdirectoryTree(dir, path.Dir(file)), path.Base(file), "")
+		}
+	}
+	writePackage("node_modules/main", `{"main": "lib/main"}`, "lib/main.js", "lib/other.js")
+	writePackage("node_modules/module", `{"module": "esm/index.js"}`, "esm/index.js")
+	writePackage("node_modules/both", `{"main": "cjs.js", "module": "esm.js"}`, "cjs.js", "esm.js")
+	writePackage("node_modules/browser", `{"main": "node.js", "browser": "browser.js"}`, "node.js", "browser.js")
+	writePackage("node_modules/replaced", `{"main": "./node.js", "browser": {"./node.js": "./browser.js"}}`, "node.js", "browser.js")
+	writePackage("node_modules/index", ``, "index.js", "sub/index.js")
+	writePackage("node_modules/@scope/pkg", `{"main": "pkg.js"}`, "pkg.js")
+	writePackage("app/node_modules/main", `{"main": "nested.js"}`, "nested.js")
+	ws := NewWorkspace(temppath)
+
+	cases := map[string]struct {
+		specifier string
+		from      string
+		expected  string
+		ok        bool
+	}{
+		"main":                {"main", "lib/src", "node_modules/main/lib/main.js", true},
+		"subpath":             {"main/lib/other", "lib/src", "node_modules/main/lib/other.js", true},
+		"module":              {"module", "", "node_modules/module/esm/index.js", true},
+		"main before module":  {"both", "", "node_modules/both/cjs.js", true},
+		"browser":             {"browser", "", "node_modules/browser/browser.js", true},
+		"browser replacement": {"replaced", "", "node_modules/replaced/browser.js", true},
+		"index":               {"index", "", "node_modules/index/index.js", true},
+		"directory index":     {"index/sub", "", "node_modules/index/sub/index.js", true},
+		"scoped":              {"@scope/pkg", "", "node_modules/@scope/pkg/pkg.js", true},
+		"nearest":             {"main", "app/src", "app/node_modules/main/nested.js", true},
+		"within node_modules": {"index", "node_modules/main/lib", "node_modules/index/index.js", true},
+		"missing":             {"missing", "app/src", "", false},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			resolved, ok := ws.resolveNodeModule(c.specifier, c.from)
+			assert.Equal(t, c.ok, ok)
+			assert.Equal(t, c.expected, resolved)
+		})
+	}
+}
+
+func TestSplitPackageSpecifier(t *testing.T) {
+	packageName, subpath := SplitPackageSpecifier("@angular/core/testing/x")
+	assert.Equal(t, "@angular/core", packageName)
+	assert.Equal(t, "testing/x", subpath)
+	packageName, subpath = SplitPackageSpecifier("lodash")
+	assert.Equal(t, "lodash", packageName)
+	assert.Equal(t, "", subpath)
+}
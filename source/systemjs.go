@@ -1,36 +1,112 @@
 package source
 
 import (
+	"fmt"
 	"strings"
 )
 
-const systemJSRegisterPrefix = "System.register(["
+// RegisterCall is the System.register([...], function (...) {...}) call that starts a SystemJS formatted file
+type RegisterCall struct {
+	Name         string // only for named registers, e.g. System.register("app/Main", [...], ...)
+	Dependencies []*RegisterDependency
+	Start        int // offset of System.register
+	End          int // offset just beyond the comma that follows the dependencies
+}
+
+// RegisterDependency is a dependency of a System.register call, with its (zero-based) position for diagnostics
+type RegisterDependency struct {
+	Path   string
+	Line   int
+	Column int
+}
+
+// RegisterParseError describes where a System.register call couldn't be parsed
+type RegisterParseError struct {
+	Line    int // zero-based
+	Column  int // zero-based
+	Message string
+}
+
+func (err *RegisterParseError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", err.Line+1, err.Column+1, err.Message)
+}
 
-// ParseRegisterDependencies parses the first line of a SystemJS formatted file and returns the import dependencies
-func ParseRegisterDependencies(line string, trimQuotes bool) ([]string, bool) {
-	if !strings.HasPrefix(line, systemJSRegisterPrefix) {
-		return nil, false // not a register line
+// ParseSystemRegister parses the System.register (or SystemJS.register) call that starts a file, after any
+// comments and directives such as "use strict".  Named registers, multi-line dependency arrays and either quote
+// style are understood.  It returns nil, and no error, when the file doesn't start with a register call, or an
+// error with the position of the problem when it does, but the call is malformed.
+func ParseSystemRegister(js string) (*RegisterCall, error) {
+	tokenizer := newJSTokenizer(js)
+	failAt := func(token *jsToken, message string) (*RegisterCall, error) {
+		line, column := jsPosition(js, token.start)
+		return nil, &RegisterParseError{line, column, message}
 	}
 
-	openPos := strings.Index(line, "[")
-	closePos := strings.LastIndex(line, "]")
-	if openPos < 0 || closePos < 0 || openPos > closePos {
-		return nil, false // not a register line
+	// skip directives, e.g. "use strict";
+	token := tokenizer.next()
+	for token.kind == jsString {
+		if token = tokenizer.next(); token.is(jsPunctuator, ";") {
+			token = tokenizer.next()
+		}
 	}
 
-	if closePos == (openPos + 1) {
-		return nil, true // no imports
+	if token.kind != jsIdentifier || (token.text != "System" && token.text != "SystemJS") {
+		return nil, nil
+	}
+	call := &RegisterCall{Start: token.start}
+	if !tokenizer.next().is(jsPunctuator, ".") || !tokenizer.next().is(jsIdentifier, "register") {
+		return nil, nil // e.g. System.import(...)
+	}
+	if token = tokenizer.next(); !token.is(jsPunctuator, "(") {
+		return failAt(token, "expected ( after System.register")
 	}
 
-	dependencySlice := line[(openPos + 1):closePos]
-	dependencies := strings.Split(dependencySlice, ", ")
-	if trimQuotes {
-		for i, quotedDependency := range dependencies {
-			dependencies[i] = strings.Trim(quotedDependency, "\"")
+	if token = tokenizer.next(); token.kind == jsString {
+		call.Name = token.value
+		if token = tokenizer.next(); !token.is(jsPunctuator, ",") {
+			return failAt(token, "expected , after the module name")
 		}
+		token = tokenizer.next()
+	}
+	if !token.is(jsPunctuator, "[") {
+		return failAt(token, "expected [ to start the dependencies")
 	}
 
-	return dependencies, true // has imports
+	call.Dependencies = []*RegisterDependency{}
+	for {
+		token = tokenizer.next()
+		if token.is(jsPunctuator, "]") {
+			break
+		}
+		if token.kind != jsString {
+			return failAt(token, "expected a string dependency, or ]")
+		}
+		line, column := jsPosition(js, token.start)
+		call.Dependencies = append(call.Dependencies, &RegisterDependency{token.value, line, column})
+
+		token = tokenizer.next()
+		if token.is(jsPunctuator, "]") {
+			break
+		}
+		if !token.is(jsPunctuator, ",") {
+			return failAt(token, "expected , or ] after a dependency")
+		}
+	}
+
+	if token = tokenizer.next(); !token.is(jsPunctuator, ",") {
+		return failAt(token, "expected , after the dependencies")
+	}
+	call.End = token.end
+	return call, nil
+}
+
+// DependencyPaths gets the (unquoted) paths of the dependencies
+func (call *RegisterCall) DependencyPaths() []string {
+	paths := make([]string, len(call.Dependencies))
+	for i, dependency := range call.Dependencies {
+		paths[i] = dependency.Path
+	}
+	return paths
 }
 
 func skipPreamble(lines []string) ([]string, int) {
@@ -67,12 +143,15 @@ func chooseBodyLines(lines []string, numPreambleLines int, foundSourceMap bool)
 	return lines[numPreambleLines:]
 }
 
-const sourceMappingURLPrefix = "//# sourceMappingURL="
+// sourceMappingURLPrefixes includes the legacy //@ form, which is still emitted by some older tools
+var sourceMappingURLPrefixes = []string{"//# sourceMappingURL=", "//@ sourceMappingURL="}
 
 // parseSourceMappingURL extracts the sourceMappingURL from a line of text
 func parseSourceMappingURL(line string) (string, bool) {
-	if strings.HasPrefix(line, sourceMappingURLPrefix) {
-		return line[len(sourceMappingURLPrefix):], true
+	for _, prefix := range sourceMappingURLPrefixes {
+		if strings.HasPrefix(line, prefix) {
+			return strings.TrimSpace(line[len(prefix):]), true
+		}
 	}
 	return "", false
 }
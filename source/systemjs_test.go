@@ -71,6 +71,26 @@ func TestParseSourceMapOnly(t *testing.T) {
 	assert.False(t, elems.isSystemJS)
 }
 
+func TestParseSourceMappingURL(t *testing.T) {
+	cases := map[string]struct {
+		line     string
+		expected string
+		found    bool
+	}{
+		"standard": {line: "//# sourceMappingURL=First.js.map", expected: "First.js.map", found: true},
+		"legacy":   {line: "//@ sourceMappingURL=First.js.map", expected: "First.js.map", found: true},
+		"inline":   {line: "//# sourceMappingURL=data:application/json;base64,e30= ", expected: "data:application/json;base64,e30=", found: true},
+		"none":     {line: "// sourceMappingURL=First.js.map", expected: "", found: false},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			actual, found := parseSourceMappingURL(tc.line)
+			assert.Equal(t, tc.expected, actual)
+			assert.Equal(t, tc.found, found)
+		})
+	}
+}
+
 func TestParseRegisterOnly(t *testing.T) {
 	source := `System.register(["tslib"], function (exports_1, context_1) {
 }`
@@ -173,3 +193,85 @@ blah blah something else`
 	assert.Len(t, preamble, 4)
 	assert.Equal(t, 4, numLines)
 }
+
+func TestParseSystemRegister(t *testing.T) {
+	cases := map[string]struct {
+		js           string
+		name         string
+		dependencies []string
+		notRegister  bool
+		err          string
+	}{
+		"typescript":     {js: `System.register(["tslib", "./Other"], function (exports_1, context_1) {`, dependencies: []string{"tslib", "./Other"}},
+		"no imports":     {js: `System.register([], function (exports_1, context_1) {`, dependencies: []string{}},
+		"single quotes":  {js: `System.register(['tslib','./Other'], function (_export, _context) {`, dependencies: []string{"tslib", "./Other"}},
+		"named":          {js: `System.register("app/Main", ["./Other"], function (exports_1) {`, name: "app/Main", dependencies: []string{"./Other"}},
+		"use strict":     {js: "'use strict';\n\nSystem.register(['./Other'], function (_export) {", dependencies: []string{"./Other"}},
+		"comments":       {js: "/* header\n */ // more\nSystem.register([/* none */], function () {", dependencies: []string{}},
+		"multi-line":     {js: "System.register([\n    \"tslib\",\n    \"./Other\",\n], function (exports_1) {", dependencies: []string{"tslib", "./Other"}},
+		"SystemJS":       {js: `SystemJS.register(["./Other"], function () {`, dependencies: []string{"./Other"}},
+		"not a register": {js: `System.import("./Main");`, notRegister: true},
+		"script":         {js: "var x = 1;\nSystem.register([], function () {", notRegister: true},
+		"empty":          {js: "", notRegister: true},
+		"missing [":      {js: `System.register(][, function () {`, err: "1:17: expected [ to start the dependencies"},
+		"missing ]":      {js: "System.register([\n  \"./Other\"\n  function () {", err: "3:3: expected , or ] after a dependency"},
+		"not a string":   {js: `System.register([other], function () {`, err: "1:18: expected a string dependency, or ]"},
+		"missing ,":      {js: `System.register([] function () {`, err: "1:20: expected , after the dependencies"},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			register, err := ParseSystemRegister(tc.js)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				assert.Nil(t, register)
+				return
+			}
+			assert.Nil(t, err)
+			if tc.notRegister {
+				assert.Nil(t, register)
+				return
+			}
+			assert.Equal(t, tc.name, register.Name)
+			assert.Equal(t, tc.dependencies, register.DependencyPaths())
+		})
+	}
+}
+
+func TestParseSystemRegisterPositions(t *testing.T) {
+	js := "\"use strict\";\nSystem.register([\n    \"tslib\",\n    './Other'], function (_export) {"
+	register, err := ParseSystemRegister(js)
+	assert.Nil(t, err)
+	assert.Equal(t, &RegisterDependency{Path: "tslib", Line: 2, Column: 4}, register.Dependencies[0])
+	assert.Equal(t, &RegisterDependency{Path: "./Other", Line: 3, Column: 4}, register.Dependencies[1])
+	assert.Equal(t, 14, register.Start)
+	assert.Equal(t, " function (_export) {", js[register.End:])
+}
+
+func TestParseMultiLineRegister(t *testing.T) {
+	source := `"use strict";
+System.register("Named", [
+    'tslib',
+    './Other'
+], function (_export, _context) {
+    return { setters: [], execute: function () {} };
+});
+//# sourceMappingURL=Named.js.map`
+
+	elems, err := ParseJSFileContents("app/Named", source)
+	assert.Nil(t, err)
+	assert.True(t, elems.isSystemJS)
+	assert.Equal(t, []string{`"tslib"`, `"./Other"`}, elems.imports)
+	assert.Equal(t, []string{`"use strict";`}, elems.preamble)
+	expectedBody := []string{
+		`"use strict";`,
+		`System.register("app/Named.js", ["tslib", "./Other"], function (_export, _context) {`,
+		"",
+		"",
+		"",
+		"    return { setters: [], execute: function () {} };",
+		"});",
+	}
+	assert.Equal(t, expectedBody, elems.body)
+	assert.Equal(t, "Named.js.map", elems.sourceMappingURL)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+)
+
+// runSourceMapsVerify builds a module, then checks its compiled source map against the bundle and original sources
+func runSourceMapsVerify(cmd *command, args []string) int {
+	if len(args) != 1 {
+		cmd.printUsage()
+		return 2
+	}
+
+	_, _, _, moduleSet := loadBuild(buildArgs())
+	moduleName := args[0]
+	module := findModule(moduleSet, moduleName)
+	if module == nil {
+		return 2
+	}
+
+	fmt.Println("Performing initial build...")
+	moduleSet.NotifyChanges(nil)
+
+	reports, err := module.VerifySourceMap(*samplesFlag)
+	if err != nil {
+		fmt.Printf("Failed to decode source map for '%s': %s\n", moduleName, err)
+		return 1
+	}
+
+	checked, discrepancies, failedFiles := 0, 0, 0
+	for _, report := range reports {
+		checked += report.Checked
+		if report.OK() {
+			continue
+		}
+		failedFiles++
+		discrepancies += len(report.Discrepancies)
+		fmt.Printf("%s (lines %d-%d)\n", report.Region.Name, report.Region.StartLine+1, report.Region.StartLine+report.Region.LineCount)
+		for _, discrepancy := range report.Discrepancies {
+			fmt.Printf("   %s\n", discrepancy)
+		}
+	}
+
+	fmt.Printf("Verified %d mappings across %d files: %d discrepancies in %d files\n", checked, len(reports), discrepancies, failedFiles)
+	if discrepancies > 0 {
+		return 1
+	}
+	return 0
+}
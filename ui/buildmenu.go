@@ -46,6 +46,28 @@ func ChooseBuild(builds map[string]*config.RuntimeConfig, tailArgs []string) *co
 	return selectedBuild
 }
 
+// ChooseBuilds chooses the builds named on the command line, or a single build (see ChooseBuild) if none are named
+func ChooseBuilds(builds map[string]*config.RuntimeConfig, buildNames []string) []*config.RuntimeConfig {
+	if len(buildNames) == 0 {
+		return []*config.RuntimeConfig{ChooseBuild(builds, nil)}
+	}
+
+	chosen := make([]*config.RuntimeConfig, 0, len(buildNames))
+	seen := make(map[string]bool)
+	for _, buildName := range buildNames {
+		build, found := builds[buildName]
+		if !found {
+			fmt.Printf("Build '%s' not found.  Choose from: %s\n", buildName, strings.Join(enumerateBuildNames(builds), ", "))
+			os.Exit(2)
+		}
+		if !seen[buildName] {
+			seen[buildName] = true
+			chosen = append(chosen, build)
+		}
+	}
+	return chosen
+}
+
 // chooseBuildFromMenu presents a menu to select a build
 func chooseBuildFromMenu(builds map[string]*config.RuntimeConfig) *config.RuntimeConfig {
 	buildNames := enumerateBuildNames(builds)
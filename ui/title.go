@@ -21,6 +21,11 @@ func PrintTitle(version string) {
 	fmt.Printf(title, version)
 }
 
+// PrintTitleToStderr outputs the title to stderr, for commands that write their output to stdout
+func PrintTitleToStderr(version string) {
+	fmt.Fprintf(os.Stderr, title, version)
+}
+
 // CheckHelp checks if the --help flag has been set
 func CheckHelp(helpFlag *bool) {
 	flag.Parse()
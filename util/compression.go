@@ -0,0 +1,108 @@
+package util
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// AcceptsEncoding tests whether an Accept-Encoding header value allows a content coding (e.g. "gzip"),
+// either by name or with a "*" wildcard, and without a q-value of zero
+func AcceptsEncoding(acceptEncoding string, encoding string) bool {
+	accepted := false
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		if name != encoding && name != "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if value, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = value
+				}
+			}
+		}
+		if name == encoding {
+			return q > 0 // an explicit coding overrides the wildcard
+		}
+		accepted = q > 0
+	}
+	return accepted
+}
+
+// NegotiateEncoding picks the first of the content codings (in order of preference) that an Accept-Encoding header
+// value allows, or "" if none are, in which case the response shouldn't be compressed
+func NegotiateEncoding(acceptEncoding string, encodings ...string) string {
+	for _, encoding := range encodings {
+		if AcceptsEncoding(acceptEncoding, encoding) {
+			return encoding
+		}
+	}
+	return ""
+}
+
+// NewEncoder creates a writer that compresses with a content coding: "br" (brotli) or "gzip"
+func NewEncoder(w io.Writer, encoding string) io.WriteCloser {
+	if encoding == "br" {
+		return brotli.NewWriterLevel(w, brotli.DefaultCompression)
+	}
+	return gzip.NewWriter(w)
+}
+
+// GzipString compresses a string with gzip
+func GzipString(s string) []byte {
+	return encodeString(s, "gzip")
+}
+
+// BrotliString compresses a string with brotli
+func BrotliString(s string) []byte {
+	return encodeString(s, "br")
+}
+
+func encodeString(s string, encoding string) []byte {
+	var buffer bytes.Buffer
+	writer := NewEncoder(&buffer, encoding)
+	writer.Write([]byte(s))
+	writer.Close()
+	return buffer.Bytes()
+}
+
+// FormatByteSize formats a number of bytes for display, e.g. 538 B, 12.3 KB, 5.1 MB
+func FormatByteSize(size int) string {
+	switch {
+	case size < 1024:
+		return fmt.Sprintf("%d B", size)
+	case size < 1024*1024:
+		return fmt.Sprintf("%.1f KB", float64(size)/1024)
+	}
+	return fmt.Sprintf("%.1f MB", float64(size)/(1024*1024))
+}
+
+// ParseByteSize parses a number of bytes in the form displayed by FormatByteSize, e.g. 538 B, 12.3 KB, 5.1 MB, or 538
+func ParseByteSize(s string) (int, error) {
+	text := strings.ToUpper(strings.TrimSpace(s))
+	multiplier := 1.0
+	for _, unit := range []struct {
+		suffix     string
+		multiplier float64
+	}{{"KB", 1024}, {"MB", 1024 * 1024}, {"B", 1}} {
+		if strings.HasSuffix(text, unit.suffix) {
+			text = strings.TrimSpace(strings.TrimSuffix(text, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size '%s' (expected e.g. 538 B, 12.3 KB or 5.1 MB)", s)
+	}
+	return int(value * multiplier), nil
+}
@@ -0,0 +1,98 @@
+package util
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAcceptsEncoding(t *testing.T) {
+	cases := map[string]struct {
+		acceptEncoding string
+		expected       bool
+	}{
+		"empty":           {"", false},
+		"gzip":            {"gzip", true},
+		"list":            {"deflate, gzip, br", true},
+		"uppercase":       {"GZIP", true},
+		"q-value":         {"gzip;q=0.5", true},
+		"refused":         {"gzip;q=0", false},
+		"other":           {"br, deflate", false},
+		"wildcard":        {"*", true},
+		"wildcard refuse": {"*;q=0", false},
+		"explicit wins":   {"gzip;q=0, *", false},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, AcceptsEncoding(tc.acceptEncoding, "gzip"))
+		})
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	cases := map[string]struct {
+		acceptEncoding string
+		expected       string
+	}{
+		"none":        {"", ""},
+		"gzip only":   {"gzip, deflate", "gzip"},
+		"both":        {"gzip, deflate, br", "br"},
+		"br refused":  {"gzip, br;q=0", "gzip"},
+		"wildcard":    {"*", "br"},
+		"unsupported": {"deflate", ""},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, NegotiateEncoding(tc.acceptEncoding, "br", "gzip"))
+		})
+	}
+}
+
+func TestBrotliString(t *testing.T) {
+	contents, err := ioutil.ReadAll(brotli.NewReader(bytes.NewReader(BrotliString("hello hello hello"))))
+	assert.Nil(t, err)
+	assert.Equal(t, "hello hello hello", string(contents))
+}
+
+func TestGzipString(t *testing.T) {
+	reader, err := gzip.NewReader(bytes.NewReader(GzipString("hello hello hello")))
+	assert.Nil(t, err)
+	contents, err := ioutil.ReadAll(reader)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello hello hello", string(contents))
+}
+
+func TestFormatByteSize(t *testing.T) {
+	assert.Equal(t, "538 B", FormatByteSize(538))
+	assert.Equal(t, "12.3 KB", FormatByteSize(12595))
+	assert.Equal(t, "5.1 MB", FormatByteSize(5347737))
+}
+
+func TestParseByteSize(t *testing.T) {
+	cases := map[string]struct {
+		size     string
+		expected int
+		valid    bool
+	}{
+		"bytes":      {"538 B", 538, true},
+		"plain":      {"538", 538, true},
+		"kilobytes":  {"12.3 KB", 12595, true},
+		"megabytes":  {"5MB", 5 * 1024 * 1024, true},
+		"lower case": {" 2 kb ", 2048, true},
+		"unknown":    {"12 GB", 0, false},
+		"negative":   {"-1 KB", 0, false},
+		"empty":      {"", 0, false},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			size, err := ParseByteSize(c.size)
+			assert.Equal(t, c.expected, size)
+			assert.Equal(t, c.valid, err == nil)
+		})
+	}
+}
@@ -21,6 +21,8 @@ func MimeTypeFromFilename(filename string) string {
 		return "text/html; charset=utf-8"
 	case ".css":
 		return "text/css; charset=utf-8"
+	case ".json", ".map":
+		return "application/json"
 	}
 	return "text/plain; charset=utf-8"
 }
@@ -33,6 +33,10 @@ func TestMimeTypeFromFilename(t *testing.T) {
 			filename: "blah.css",
 			expected: "text/css; charset=utf-8",
 		},
+		".map": {
+			filename: "blah.js.map",
+			expected: "application/json",
+		},
 		"???": {
 			filename: "akldfoiasudyfiun234",
 			expected: "text/plain; charset=utf-8",
@@ -0,0 +1,45 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"github.com/mrcrowl/swarm/bundle"
+)
+
+const analyzeServerPath = swarmVirtualPath + "/analyze"
+const analyzeDataPath = analyzeServerPath + ".json"
+const analyzePageFilename = "Analyze.html"
+const analyzeScriptFilename = "Analyze.js"
+
+// BundleAnalyzer reports the size and contents of every bundle, for the analyze page
+type BundleAnalyzer interface {
+	Sizes() []*bundle.BundleSize
+}
+
+// analyzeData is rendered by the analyze page
+type analyzeData struct {
+	Live    bool                 `json:"live"` // whether the page is told to refresh after each rebuild
+	Bundles []*bundle.BundleSize `json:"bundles"`
+}
+
+// attachAnalyzeListener serves a treemap of the contents of every bundle at /__swarm__/analyze, and the data it
+// renders at /__swarm__/analyze.json.  With hot reload enabled, the page refreshes over the websocket after each
+// rebuild.
+func (server *Server) attachAnalyzeListener(mux *http.ServeMux) {
+	mux.HandleFunc(analyzeServerPath, createStringHandleFunc(analyzePageFilename))
+	mux.HandleFunc(swarmify(analyzeScriptFilename), createStringHandleFunc(analyzeScriptFilename))
+	if server.hub == nil {
+		// the page imports the socket client, which is otherwise only served for hot reloading
+		mux.HandleFunc(swarmify(socketClientFilename), createStringHandleFunc(socketClientFilename))
+	}
+	mux.Handle(analyzeDataPath, compressHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := &analyzeData{Live: server.hub != nil, Bundles: server.bundleAnalyzer.Sizes()}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(data)
+	})))
+}
+
+// TriggerAnalyzeRefresh tells the analyze page to reload its data, after a rebuild
+func (server *Server) TriggerAnalyzeRefresh() {
+	server.hub.broadcast("", "analyze", "")
+}
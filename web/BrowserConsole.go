@@ -0,0 +1,64 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+const consoleMessageType = "console"
+
+// ConsolePayloadData encapsulates a console message (or uncaught exception) forwarded by the client page
+type ConsolePayloadData struct {
+	Level   string `json:"level"`
+	URL     string `json:"url"`
+	Message string `json:"message"`
+}
+
+var consoleLevelTitles = map[string]string{
+	"error":    "Browser console error",
+	"warn":     "Browser console warning",
+	"uncaught": "Uncaught exception",
+}
+
+// receiveSocketMessage handles a message sent by a client page over the websocket
+func (server *Server) receiveSocketMessage(payload *SocketPayload) {
+	switch payload.Type {
+	case consoleMessageType:
+		if !server.forwardConsole {
+			return
+		}
+		var data ConsolePayloadData
+		if err := json.Unmarshal([]byte(payload.Data), &data); err != nil {
+			log.Printf("Ignoring invalid console message: %s", err)
+			return
+		}
+		server.printConsoleMessage(&data)
+	}
+}
+
+func (server *Server) printConsoleMessage(data *ConsolePayloadData) {
+	message := data.Message
+	if server.symbolicateConsole && server.symbolicator != nil {
+		message = server.symbolicator.SymbolicateStackTrace(message)
+	}
+
+	title, found := consoleLevelTitles[data.Level]
+	if !found {
+		title = "Browser console " + data.Level
+	}
+	printBrowserMessage(title, data.URL, message)
+}
+
+// printBrowserMessage prints a message from the browser in the terminal, indented beneath a timestamped title
+func printBrowserMessage(title string, pageURL string, message string) {
+	if pageURL == "" {
+		pageURL = "unknown page"
+	}
+	fmt.Printf("\n%s %s on %s:\n", time.Now().Format("15:04:05"), title, pageURL)
+	for _, line := range strings.Split(strings.TrimRight(message, "\r\n"), "\n") {
+		fmt.Printf("   %s\n", strings.TrimRight(line, "\r"))
+	}
+}
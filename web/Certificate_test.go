@@ -0,0 +1,44 @@
+package web
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateSelfSignedCertificate(t *testing.T) {
+	now := time.Now()
+	certPEM, keyPEM, err := generateSelfSignedCertificate(now)
+	assert.Nil(t, err)
+
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	assert.Nil(t, err)
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	assert.Nil(t, err)
+	assert.Nil(t, cert.VerifyHostname("localhost"))
+	assert.Nil(t, cert.VerifyHostname("127.0.0.1"))
+	assert.True(t, cert.NotAfter.After(now.Add(selfSignedRenewBefore)))
+}
+
+func TestEnsureSelfSignedCertificateIsCached(t *testing.T) {
+	dir, err := ioutil.TempDir("", "swarm-cert")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	certFile, keyFile, err := ensureSelfSignedCertificate(dir)
+	assert.Nil(t, err)
+	first, _ := ioutil.ReadFile(certFile)
+
+	_, _, err = ensureSelfSignedCertificate(dir)
+	assert.Nil(t, err)
+	second, _ := ioutil.ReadFile(certFile)
+	assert.Equal(t, first, second)
+
+	assert.True(t, isCertificateCurrent(certFile, keyFile, time.Now()))
+	assert.False(t, isCertificateCurrent(certFile, keyFile, time.Now().Add(selfSignedValidity)))
+}
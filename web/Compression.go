@@ -0,0 +1,86 @@
+package web
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/mrcrowl/swarm/util"
+)
+
+// compressibleContentTypes are the (prefixes of) content types worth compressing
+var compressibleContentTypes = []string{
+	"text/",
+	"application/javascript",
+	"application/json",
+	"image/svg+xml",
+}
+
+func isCompressible(contentType string) bool {
+	for _, prefix := range compressibleContentTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// compressResponseWriter compresses a response on the fly, once its status & content type show that it's worthwhile
+type compressResponseWriter struct {
+	http.ResponseWriter
+	encoding string // the negotiated content coding, e.g. "br" or "gzip"
+	encoder  io.WriteCloser
+	decided  bool
+}
+
+func (w *compressResponseWriter) WriteHeader(statusCode int) {
+	if !w.decided {
+		w.decided = true
+		header := w.Header()
+		if statusCode == http.StatusOK && header.Get("Content-Encoding") == "" && isCompressible(header.Get("Content-Type")) {
+			header.Del("Content-Length")
+			header.Set("Content-Encoding", w.encoding)
+			w.encoder = util.NewEncoder(w.ResponseWriter, w.encoding)
+		}
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *compressResponseWriter) Write(bytes []byte) (int, error) {
+	if !w.decided {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(bytes))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.encoder != nil {
+		return w.encoder.Write(bytes)
+	}
+	return w.ResponseWriter.Write(bytes)
+}
+
+func (w *compressResponseWriter) close() {
+	if w.encoder != nil {
+		w.encoder.Close()
+	}
+}
+
+// compressHandler compresses the compressible responses of a handler with brotli or gzip, for clients that accept
+// either (preferring brotli).  Nested compressHandlers are harmless: only the innermost compresses, as the outer ones
+// see the Content-Encoding.
+func compressHandler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if w.Header().Get("Vary") == "" {
+			w.Header().Set("Vary", "Accept-Encoding")
+		}
+		encoding := util.NegotiateEncoding(r.Header.Get("Accept-Encoding"), "br", "gzip")
+		if r.Method != http.MethodGet || encoding == "" {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		compressWriter := &compressResponseWriter{ResponseWriter: w, encoding: encoding}
+		defer compressWriter.close()
+		handler.ServeHTTP(compressWriter, r)
+	})
+}
@@ -0,0 +1,68 @@
+package web
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompressHandler(t *testing.T) {
+	handler := compressHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app.js":
+			w.Header().Set("Content-Type", "application/javascript")
+			w.Header().Set("Content-Length", "15")
+			io.WriteString(w, "console.log(1);")
+		case "/image.png":
+			w.Header().Set("Content-Type", "image/png")
+			io.WriteString(w, "PNG")
+		case "/missing.js":
+			http.NotFound(w, r)
+		}
+	}))
+
+	cases := map[string]struct {
+		path             string
+		acceptEncoding   string
+		expectedEncoding string
+	}{
+		"javascript":         {"/app.js", "gzip, deflate", "gzip"},
+		"not accepted":       {"/app.js", "", ""},
+		"already compressed": {"/image.png", "gzip", ""},
+		"not found":          {"/missing.js", "gzip", ""},
+		"nested handlers":    {"/app.js", "gzip", "gzip"},
+		"brotli":             {"/app.js", "gzip, deflate, br", "br"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			served := handler
+			if name == "nested handlers" {
+				served = compressHandler(handler)
+			}
+			request := httptest.NewRequest("GET", tc.path, nil)
+			request.Header.Set("Accept-Encoding", tc.acceptEncoding)
+			recorder := httptest.NewRecorder()
+			served.ServeHTTP(recorder, request)
+			assert.Equal(t, tc.expectedEncoding, recorder.Header().Get("Content-Encoding"))
+			assert.Equal(t, []string{"Accept-Encoding"}, recorder.Header()["Vary"])
+			if tc.expectedEncoding == "gzip" {
+				assert.Equal(t, "", recorder.Header().Get("Content-Length"))
+				reader, err := gzip.NewReader(bytes.NewReader(recorder.Body.Bytes()))
+				assert.Nil(t, err)
+				contents, _ := ioutil.ReadAll(reader)
+				assert.Equal(t, "console.log(1);", string(contents))
+			}
+			if tc.expectedEncoding == "br" {
+				contents, _ := ioutil.ReadAll(brotli.NewReader(bytes.NewReader(recorder.Body.Bytes())))
+				assert.Equal(t, "console.log(1);", string(contents))
+			}
+		})
+	}
+}
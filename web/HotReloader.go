@@ -28,8 +28,12 @@ func (hot *HotReloader) NotifyReload(changes *monitor.EventChangeset) {
 		return
 	}
 
+	if changes == nil || changes.DidBundle(hot.moduleSet.BaseHref()) {
+		hot.server.TriggerAnalyzeRefresh()
+	}
+
 	if changes != nil {
-		if changes.SkipHotReload() {
+		if changes.SkipHotReload(hot.moduleSet.BaseHref()) {
 			return
 		}
 
@@ -46,7 +50,7 @@ func (hot *HotReloader) NotifyReload(changes *monitor.EventChangeset) {
 				if relativePath, ok := hot.workspace.ToRelativePath(change.AbsoluteFilepath()); ok {
 					if file := hot.moduleSet.FindFileByPath(relativePath); file != nil {
 						cssContent := file.RawContents().(*source.CSSFileContents).RawCSSContent()
-						hot.server.TriggerCSSReload(relativePath, cssContent)
+						hot.server.TriggerCSSReload(hot.moduleSet.BaseHref(), relativePath, cssContent)
 					}
 				}
 			}
@@ -55,5 +59,5 @@ func (hot *HotReloader) NotifyReload(changes *monitor.EventChangeset) {
 		}
 	}
 
-	hot.server.TriggerFullReload()
+	hot.server.TriggerFullReload(hot.moduleSet.BaseHref())
 }
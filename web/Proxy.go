@@ -0,0 +1,92 @@
+package web
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/mrcrowl/swarm/config"
+)
+
+// newReverseProxy creates a handler that forwards requests beneath a path prefix to the proxy's target.
+// Websocket upgrades are passed through by httputil.ReverseProxy.
+func newReverseProxy(prefix string, proxyConfig *config.ProxyConfig) (*httputil.ReverseProxy, error) {
+	target, err := proxyConfig.TargetURL()
+	if err != nil {
+		return nil, err
+	}
+
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	director := proxy.Director
+	proxy.Director = func(r *http.Request) {
+		rewrittenPath := proxyConfig.RewritePath(prefix, r.URL.Path)
+		if rewrittenPath != r.URL.Path {
+			r.URL.Path = rewrittenPath
+			r.URL.RawPath = ""
+		}
+		director(r)
+		for name, value := range proxyConfig.Headers {
+			r.Header.Set(name, value)
+		}
+		if proxyConfig.RewriteHost {
+			r.Host = target.Host
+		}
+	}
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		log.Printf("ERROR: Failed to proxy %s to %s: %s", r.URL.Path, target, err)
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	return proxy, nil
+}
+
+func (server *Server) attachProxies(mux *http.ServeMux) {
+	prefixes := make([]string, 0, len(server.proxies))
+	for prefix := range server.proxies {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	reserved := server.reservedRoutes()
+	proxiedBy := make(map[string]string, len(prefixes))
+	for _, prefix := range prefixes {
+		proxyConfig := server.proxies[prefix]
+		normalisedPrefix := config.NormaliseProxyPrefix(prefix)
+		if reserved[normalisedPrefix] || strings.HasPrefix(normalisedPrefix, swarmVirtualPath+"/") {
+			log.Printf("ERROR: Skipping proxy for %s: %s is already served by swarm", prefix, normalisedPrefix)
+			continue
+		}
+		if other, found := proxiedBy[normalisedPrefix]; found {
+			log.Printf("ERROR: Skipping proxy for %s: %s is already proxied for %s", prefix, normalisedPrefix, other)
+			continue
+		}
+		proxy, err := newReverseProxy(normalisedPrefix, proxyConfig)
+		if err != nil {
+			log.Printf("ERROR: Skipping proxy for %s: %s", prefix, err)
+			continue
+		}
+		proxiedBy[normalisedPrefix] = prefix
+		mux.Handle(normalisedPrefix, proxy)
+		// without this, ServeMux redirects the bare prefix to the subtree, which breaks anything but a GET
+		if barePrefix := strings.TrimSuffix(normalisedPrefix, "/"); barePrefix != "" && !reserved[barePrefix] {
+			mux.Handle(barePrefix, proxy)
+		}
+		fmt.Printf("     Proxy: %s -> %s\n", normalisedPrefix, proxyConfig.Target)
+	}
+}
+
+// reservedRoutes lists the routes that the server registers itself, which a proxy can't also be registered at
+func (server *Server) reservedRoutes() map[string]bool {
+	reserved := map[string]bool{"/": true}
+	for _, basePath := range server.basePaths {
+		reserved[rootedPath(basePath)+"/"] = true
+		reserved[path.Join("/", basePath, systemJSConfigJS)] = true
+	}
+	for url := range server.handlers {
+		reserved[url] = true
+	}
+	return reserved
+}
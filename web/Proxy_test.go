@@ -0,0 +1,155 @@
+package web
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/mrcrowl/swarm/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReverseProxy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Host+" "+r.URL.Path+" "+r.Header.Get("X-Api-Key"))
+	}))
+	defer upstream.Close()
+	upstreamHost := upstream.Listener.Addr().String()
+
+	cases := map[string]struct {
+		proxyConfig *config.ProxyConfig
+		expected    string
+	}{
+		"passthrough": {&config.ProxyConfig{Target: upstream.URL}, "swarm.local /api/users "},
+		"rewrite":     {&config.ProxyConfig{Target: upstream.URL, Rewrite: "/v2/"}, "swarm.local /v2/users "},
+		"target path": {&config.ProxyConfig{Target: upstream.URL + "/backend"}, "swarm.local /backend/api/users "},
+		"headers":     {&config.ProxyConfig{Target: upstream.URL, Headers: map[string]string{"X-Api-Key": "secret"}}, "swarm.local /api/users secret"},
+		"host":        {&config.ProxyConfig{Target: upstream.URL, RewriteHost: true}, upstreamHost + " /api/users "},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			server, mux := createWebServer("c:\\")
+			server.proxies = map[string]*config.ProxyConfig{"/api": tc.proxyConfig}
+			server.attachProxies(mux)
+
+			request := httptest.NewRequest("GET", "http://swarm.local/api/users", nil)
+			recorder := httptest.NewRecorder()
+			mux.ServeHTTP(recorder, request)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, tc.expected, recorder.Body.String())
+		})
+	}
+}
+
+func TestReverseProxyBarePrefix(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		io.WriteString(w, r.Method+" "+r.URL.Path+" "+string(body))
+	}))
+	defer upstream.Close()
+
+	cases := map[string]struct {
+		proxyConfig *config.ProxyConfig
+		expected    string
+	}{
+		"passthrough": {&config.ProxyConfig{Target: upstream.URL}, "POST /api hello"},
+		"rewrite":     {&config.ProxyConfig{Target: upstream.URL, Rewrite: "/v2/"}, "POST /v2 hello"},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			server, mux := createWebServer("c:\\")
+			server.proxies = map[string]*config.ProxyConfig{"/api/": tc.proxyConfig}
+			server.attachProxies(mux)
+
+			request := httptest.NewRequest("POST", "http://swarm.local/api", strings.NewReader("hello"))
+			recorder := httptest.NewRecorder()
+			mux.ServeHTTP(recorder, request)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, tc.expected, recorder.Body.String())
+		})
+	}
+}
+
+func TestReverseProxyWebsocket(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		socket, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer socket.Close()
+		messageType, message, _ := socket.ReadMessage()
+		socket.WriteMessage(messageType, append([]byte(r.URL.Path+" "), message...))
+	}))
+	defer upstream.Close()
+
+	server, mux := createWebServer("c:\\")
+	server.proxies = map[string]*config.ProxyConfig{"/api/": {Target: upstream.URL, Rewrite: "/", RewriteHost: true}}
+	server.attachProxies(mux)
+	proxyServer := httptest.NewServer(mux)
+	defer proxyServer.Close()
+
+	socket, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(proxyServer.URL, "http")+"/api/socket", nil)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer socket.Close()
+	socket.WriteMessage(websocket.TextMessage, []byte("hello"))
+	_, message, err := socket.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, "/socket hello", string(message))
+}
+
+func TestReverseProxyInvalidTarget(t *testing.T) {
+	server, mux := createWebServer("c:\\")
+	server.proxies = map[string]*config.ProxyConfig{"/api": {Target: "localhost:5000"}}
+	server.attachProxies(mux)
+
+	request := httptest.NewRequest("GET", "http://swarm.local/api/users", nil)
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+}
+
+func TestReverseProxyConflictingPrefixes(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "proxied "+r.URL.Path)
+	}))
+	defer upstream.Close()
+
+	server, mux := createWebServer("c:\\")
+	server.proxies = map[string]*config.ProxyConfig{
+		"/":         {Target: upstream.URL},
+		"app":       {Target: upstream.URL},
+		"__swarm__": {Target: upstream.URL},
+		"/api/":     {Target: upstream.URL},
+		"api":       {Target: upstream.URL + "/other"},
+		"/services": {Target: upstream.URL},
+	}
+	assert.NotPanics(t, func() {
+		server.attachStaticFileServer(mux)
+		server.attachProxies(mux)
+		server.attachIndexInjectionListener(mux, http.NotFoundHandler())
+	})
+
+	cases := map[string]struct {
+		url      string
+		expected string
+	}{
+		"first of duplicates": {"http://swarm.local/api/users", "proxied /api/users"},
+		"other prefix":        {"http://swarm.local/services/x", "proxied /services/x"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			mux.ServeHTTP(recorder, httptest.NewRequest("GET", tc.url, nil))
+			assert.Equal(t, tc.expected, recorder.Body.String())
+		})
+	}
+}
@@ -3,15 +3,18 @@ package web
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"os"
 	"path"
 	"path/filepath"
-	"regexp"
+	"strings"
 	"github.com/mrcrowl/swarm/assets"
+	"github.com/mrcrowl/swarm/config"
 	"github.com/mrcrowl/swarm/source"
 	"github.com/mrcrowl/swarm/util"
 	"time"
@@ -28,12 +31,22 @@ const webSocketServerPath = swarmVirtualPath + "/ws"
 
 // Server is the state of the web server
 type Server struct {
-	srv          *http.Server
-	rootFilepath string
-	basePath     string
-	port         uint16
-	handlers     map[string]http.HandlerFunc
-	hub          *SocketHub
+	srv                *http.Server
+	rootFilepath       string
+	basePaths          []string
+	port               uint16
+	handlers           map[string]http.HandlerFunc
+	bundleServer       BundleServer
+	hub                *SocketHub
+	symbolicator       StackTraceSymbolicator
+	importExplainer    ImportExplainer
+	bundleAnalyzer     BundleAnalyzer
+	systemJSRewriters  map[string]SystemJSConfigRewriter
+	forwardConsole     bool
+	symbolicateConsole bool
+	proxies            map[string]*config.ProxyConfig
+	https              *config.HTTPSConfig
+	historyFallback    *config.HistoryFallbackConfig
 }
 
 // DefaultPort will be automatically assigned, if no port is specified in the options
@@ -57,12 +70,26 @@ func CreateServer(opts *ServerOptions) *Server {
 	}
 
 	server := &Server{
-		srv:          nil,
-		rootFilepath: opts.RootFilepath,
-		basePath:     opts.BasePath,
-		port:         port,
-		handlers:     opts.Handlers,
-		hub:          hub,
+		srv:                nil,
+		rootFilepath:       opts.RootFilepath,
+		basePaths:          uniqueBasePaths(opts.BasePaths),
+		port:               port,
+		handlers:           opts.Handlers,
+		bundleServer:       opts.BundleServer,
+		hub:                hub,
+		symbolicator:       opts.Symbolicator,
+		importExplainer:    opts.ImportExplainer,
+		bundleAnalyzer:     opts.BundleAnalyzer,
+		systemJSRewriters:  opts.SystemJSRewriters,
+		forwardConsole:     opts.ForwardConsole,
+		symbolicateConsole: opts.SymbolicateConsole,
+		proxies:            opts.Proxies,
+		https:              opts.HTTPS,
+		historyFallback:    opts.HistoryFallback,
+	}
+
+	if hub != nil {
+		hub.receiver = server.receiveSocketMessage
 	}
 
 	return server
@@ -75,10 +102,20 @@ func (server *Server) Start() {
 	fileServer := server.attachStaticFileServer(mux)
 	server.attachSystemJSRewriteHandler(mux)
 	server.attachCustomHandlers(mux)
+	server.attachProxies(mux)
+	if server.symbolicator != nil {
+		server.attachSymbolicationListener(mux)
+	}
+	if server.importExplainer != nil {
+		server.attachWhyListener(mux)
+	}
+	if server.bundleAnalyzer != nil {
+		server.attachAnalyzeListener(mux)
+	}
 
+	server.attachIndexInjectionListener(mux, fileServer)
 	if server.hub != nil {
 		// add HMR support
-		server.attachIndexInjectionListener(mux, fileServer)
 		server.attachWebSocketListeners(mux, server.hub)
 		go server.hub.run()
 	}
@@ -88,11 +125,41 @@ func (server *Server) Start() {
 		Handler: mux,
 	}
 
-	if err := server.srv.ListenAndServe(); err != nil {
+	var err error
+	if server.https == nil {
+		err = server.srv.ListenAndServe()
+	} else {
+		certFile, keyFile, certErr := server.certificateFiles()
+		if certErr != nil {
+			panic(certErr)
+		}
+		err = server.srv.ListenAndServeTLS(certFile, keyFile)
+	}
+	if err != nil {
 		panic(err)
 	}
 }
 
+// certificateFiles gets the configured certificate and key, or a cached self-signed certificate for localhost
+func (server *Server) certificateFiles() (certFile string, keyFile string, err error) {
+	if !server.https.SelfSigned() {
+		if server.https.Cert == "" || server.https.Key == "" {
+			return "", "", errors.New("HTTPS requires both a cert and a key (or neither, to use a self-signed certificate)")
+		}
+		return server.https.Cert, server.https.Key, nil
+	}
+
+	dir, err := selfSignedCertificateDir()
+	if err != nil {
+		return "", "", err
+	}
+	certFile, keyFile, err = ensureSelfSignedCertificate(dir)
+	if err == nil {
+		fmt.Printf("Using self-signed certificate: %s\n", certFile)
+	}
+	return certFile, keyFile, err
+}
+
 func (server *Server) attachCustomHandlers(mux *http.ServeMux) {
 	for url, handler := range server.handlers {
 		mux.HandleFunc(url, handler)
@@ -100,13 +167,37 @@ func (server *Server) attachCustomHandlers(mux *http.ServeMux) {
 }
 
 func (server *Server) attachStaticFileServer(mux *http.ServeMux) http.Handler {
-	fileServer := http.FileServer(http.Dir(server.rootFilepath))
+	fileServer := compressHandler(http.FileServer(http.Dir(server.rootFilepath)))
+	if server.bundleServer != nil {
+		fileServer = server.serveBundlesBefore(fileServer)
+	}
 	mux.Handle("/", fileServer)
 	return fileServer
 }
 
+// BundleServer serves the bundles that are created after the server starts (e.g. chunks for new lazy imports),
+// which don't have handlers of their own
+type BundleServer interface {
+	ServeBundle(w http.ResponseWriter, r *http.Request) bool
+}
+
+// serveBundlesBefore serves a bundle in place of the file at the same URL, if there is one
+func (server *Server) serveBundlesBefore(fileServer http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !server.bundleServer.ServeBundle(w, r) {
+			fileServer.ServeHTTP(w, r)
+		}
+	})
+}
+
 func (server *Server) attachSystemJSRewriteHandler(mux *http.ServeMux) {
-	systemJSFilepath := filepath.Join(server.rootFilepath, server.basePath, systemJSConfigJS)
+	for _, basePath := range server.basePaths {
+		server.attachSystemJSRewriteHandlerAt(mux, basePath)
+	}
+}
+
+func (server *Server) attachSystemJSRewriteHandlerAt(mux *http.ServeMux, basePath string) {
+	systemJSFilepath := filepath.Join(server.rootFilepath, basePath, systemJSConfigJS)
 	handler := func(w http.ResponseWriter, r *http.Request) {
 		bytes, err := ioutil.ReadFile(systemJSFilepath)
 		if err != nil {
@@ -114,20 +205,31 @@ func (server *Server) attachSystemJSRewriteHandler(mux *http.ServeMux) {
 			return
 		}
 		configJS := string(bytes)
-		rewrittenConfigJS := rewriteSystemJSConfigPaths(configJS)
+		rewrittenConfigJS := server.rewriteSystemJSConfig(basePath, configJS)
 		mimeType := util.MimeTypeFromFilename(systemJSFilepath)
 		w.Header().Set("Content-Type", mimeType)
 		io.WriteString(w, rewrittenConfigJS)
 		return
 	}
-	systemJSPath := path.Join("/", server.basePath, systemJSConfigJS)
-	mux.HandleFunc(systemJSPath, handler)
+	systemJSPath := path.Join("/", basePath, systemJSConfigJS)
+	mux.Handle(systemJSPath, compressHandler(http.HandlerFunc(handler)))
 }
 
-var rewriteSystemJSPattern = regexp.MustCompile(`"\.\/(common|services|utils)",`)
+// SystemJSConfigRewriter adapts a build's systemjs.config.js to the way swarm serves it
+type SystemJSConfigRewriter interface {
+	RewriteSystemJSConfig(configJS string) string
+}
 
-func rewriteSystemJSConfigPaths(systemJSConfig string) string {
-	return rewriteSystemJSPattern.ReplaceAllString(systemJSConfig, `"../$1", /* <-- REWRITTEN BY SWARM */`)
+// rewriteSystemJSConfig uses the build's rewriter, or the default text rewrites if it doesn't have one
+func (server *Server) rewriteSystemJSConfig(basePath string, configJS string) string {
+	if rewriter := server.systemJSRewriters[basePath]; rewriter != nil {
+		return rewriter.RewriteSystemJSConfig(configJS)
+	}
+	rewrittenConfigJS, err := (*config.RuntimeConfig)(nil).RewriteSystemJSConfigText(configJS)
+	if err != nil {
+		log.Printf("ERROR: %s", err)
+	}
+	return rewrittenConfigJS
 }
 
 func loadAssetString(assetFilename string) string {
@@ -153,46 +255,118 @@ func (server *Server) attachWebSocketListeners(mux *http.ServeMux, hub *SocketHu
 	mux.HandleFunc(swarmify(socketClientFilename), createStringHandleFunc(socketClientFilename))
 	mux.HandleFunc(swarmify(hotReloadFilename), createStringHandleFunc(hotReloadFilename))
 	mux.HandleFunc(webSocketServerPath, func(w http.ResponseWriter, r *http.Request) {
-		serveWebsocket(hub, w, r)
+		serveWebsocket(hub, server.basePathOfPage(r.URL.Query().Get("page")), w, r)
 	})
 }
 
 func (server *Server) attachIndexInjectionListener(mux *http.ServeMux, fileServer http.Handler) {
-	rootedBasePath := path.Join("/", server.basePath)
+	for _, basePath := range server.basePaths {
+		server.attachIndexInjectionListenerAt(mux, fileServer, basePath)
+	}
+}
+
+func (server *Server) attachIndexInjectionListenerAt(mux *http.ServeMux, fileServer http.Handler, basePath string) {
+	rootedBasePath := path.Join("/", basePath)
 	acceptedIndexPaths := []string{
 		rootedBasePath,
 		rootedBasePath + "/",
 		rootedBasePath + "/" + indexhtml,
 	}
 
-	indexFilepath := filepath.Join(server.rootFilepath, server.basePath, indexhtml)
+	indexFilepath := filepath.Join(server.rootFilepath, basePath, indexhtml)
 	indexHandler := func(w http.ResponseWriter, r *http.Request) {
 		for _, path := range acceptedIndexPaths {
 			if r.URL.Path == path {
-				bytes, err := ioutil.ReadFile(indexFilepath)
-				if err != nil {
-					log.Printf("ERROR: Failed to load index at: %s", indexFilepath)
-					return
-				}
-				indexHTML := string(bytes)
-				var injectedIndexHTML string
-				injectedIndexHTML = InjectSrcJavascript(indexHTML, swarmify(cssEscapePolyfillFilename), false)
-				injectedIndexHTML = InjectSrcJavascript(injectedIndexHTML, swarmify(hotReloadFilename), true)
-				w.Header().Set("Content-Type", "text/html; charset=utf-8")
-				io.WriteString(w, injectedIndexHTML)
+				server.serveIndex(w, indexFilepath)
 				return
 			}
 		}
 
+		if server.shouldFallbackToIndex(r) {
+			server.serveIndex(w, indexFilepath)
+			return
+		}
+
 		fileServer.ServeHTTP(w, r)
 	}
 
-	mux.HandleFunc(rootedBasePath+"/", indexHandler)
+	mux.Handle(rootedBasePath+"/", compressHandler(http.HandlerFunc(indexHandler)))
 }
 
-// TriggerFullReload causes a full HTML reload to be fired
-func (server *Server) TriggerFullReload() {
-	server.hub.broadcast("reload", "")
+// serveIndex serves the index page, injected with the hot reload scripts (if enabled)
+func (server *Server) serveIndex(w http.ResponseWriter, indexFilepath string) {
+	bytes, err := ioutil.ReadFile(indexFilepath)
+	if err != nil {
+		log.Printf("ERROR: Failed to load index at: %s", indexFilepath)
+		return
+	}
+	indexHTML := string(bytes)
+	if server.hub != nil {
+		indexHTML = InjectSrcJavascript(indexHTML, swarmify(cssEscapePolyfillFilename), false)
+		indexHTML = InjectSrcJavascript(indexHTML, swarmify(hotReloadFilename), true)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	io.WriteString(w, indexHTML)
+}
+
+// shouldFallbackToIndex tests whether a request beneath the base path is for a client-side (HTML5 history) route,
+// i.e. an extensionless GET for a page, which doesn't exist on disk and isn't excluded
+func (server *Server) shouldFallbackToIndex(r *http.Request) bool {
+	if !server.historyFallback.Enabled() {
+		return false
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if path.Ext(r.URL.Path) != "" {
+		return false
+	}
+	if accept := r.Header.Get("Accept"); accept != "" && !strings.Contains(accept, "text/html") && !strings.Contains(accept, "*/*") {
+		return false
+	}
+	if server.historyFallback.Excludes(r.URL.Path) {
+		return false
+	}
+	if _, err := os.Stat(filepath.Join(server.rootFilepath, filepath.FromSlash(r.URL.Path))); err == nil {
+		return false
+	}
+	return true
+}
+
+func rootedPath(basePath string) string {
+	return path.Join("/", basePath)
+}
+
+// uniqueBasePaths removes repeated base paths, since each may only be mounted once
+func uniqueBasePaths(basePaths []string) []string {
+	unique := make([]string, 0, len(basePaths))
+	seen := make(map[string]bool)
+	for _, basePath := range basePaths {
+		if !seen[rootedPath(basePath)] {
+			seen[rootedPath(basePath)] = true
+			unique = append(unique, basePath)
+		}
+	}
+	return unique
+}
+
+// basePathOfPage finds the (rooted) base path of the build that a page belongs to, or "" if it's not beneath any
+func (server *Server) basePathOfPage(pagePath string) string {
+	longest := ""
+	for _, basePath := range server.basePaths {
+		rootedBasePath := rootedPath(basePath)
+		if pagePath == rootedBasePath || strings.HasPrefix(pagePath, strings.TrimSuffix(rootedBasePath, "/")+"/") {
+			if len(rootedBasePath) > len(longest) {
+				longest = rootedBasePath
+			}
+		}
+	}
+	return longest
+}
+
+// TriggerFullReload causes a full HTML reload to be fired, on pages of the build with the specified base path
+func (server *Server) TriggerFullReload(basePath string) {
+	server.hub.broadcast(rootedPath(basePath), "reload", "")
 }
 
 // ReloadCSSPayloadData encapsulates the data to reload a specific style sheet
@@ -201,19 +375,44 @@ type ReloadCSSPayloadData struct {
 	CSS string `json:"css"`
 }
 
-// TriggerCSSReload causes a CSS-only reload to be fired
-func (server *Server) TriggerCSSReload(path string, css string) {
+// TriggerCSSReload causes a CSS-only reload to be fired, on pages of the build with the specified base path
+func (server *Server) TriggerCSSReload(basePath string, path string, css string) {
 	cssReloadData := &ReloadCSSPayloadData{
 		ID:  source.CSSPrefix + path,
 		CSS: css,
 	}
 	jsonBytes, _ := json.Marshal(cssReloadData)
-	server.hub.broadcast("reload-css", string(jsonBytes))
+	server.hub.broadcast(rootedPath(basePath), "reload-css", string(jsonBytes))
 }
 
-// URL gets the localhost URL for this server
+// URL gets the localhost URL for this server (of the first build, if it serves several)
 func (server *Server) URL() string {
-	return fmt.Sprintf("http://localhost:%d/%s", server.Port(), server.basePath)
+	if len(server.basePaths) == 0 {
+		return server.urlOf("")
+	}
+	return server.urlOf(server.basePaths[0])
+}
+
+// URLs gets the localhost URL of each build served by this server
+func (server *Server) URLs() []string {
+	urls := make([]string, len(server.basePaths))
+	for i, basePath := range server.basePaths {
+		urls[i] = server.urlOf(basePath)
+	}
+	return urls
+}
+
+func (server *Server) urlOf(basePath string) string {
+	scheme := "http"
+	if server.IsHTTPS() {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://localhost:%d/%s", scheme, server.Port(), basePath)
+}
+
+// IsHTTPS gets whether the server is serving over HTTPS
+func (server *Server) IsHTTPS() bool {
+	return server.https != nil
 }
 
 // IsHotReloadEnabled gets whether hot reload is enabled
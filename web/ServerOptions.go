@@ -7,11 +7,21 @@ import (
 
 // ServerOptions specifies the parameters for the web server
 type ServerOptions struct {
-	RootFilepath    string
-	Port            uint16
-	EnableHotReload bool
-	Handlers        map[string]http.HandlerFunc
-	BasePath        string
+	RootFilepath       string
+	Port               uint16
+	EnableHotReload    bool
+	Handlers           map[string]http.HandlerFunc
+	BundleServer       BundleServer
+	BasePaths          []string
+	Symbolicator       StackTraceSymbolicator
+	ImportExplainer    ImportExplainer
+	BundleAnalyzer     BundleAnalyzer
+	SystemJSRewriters  map[string]SystemJSConfigRewriter
+	ForwardConsole     bool
+	SymbolicateConsole bool
+	Proxies            map[string]*config.ProxyConfig
+	HTTPS              *config.HTTPSConfig
+	HistoryFallback    *config.HistoryFallbackConfig
 }
 
 // CreateServerOptions forms a server options object from various sources
@@ -19,13 +29,18 @@ func CreateServerOptions(
 	rootFilepath string,
 	serverConfig *config.ServerConfig,
 	handlers map[string]http.HandlerFunc,
-	basePath string,
+	basePaths ...string,
 ) *ServerOptions {
 	return &ServerOptions{
-		RootFilepath:    rootFilepath,
-		Port:            serverConfig.Port,
-		EnableHotReload: serverConfig.HotReload,
-		Handlers:        handlers,
-		BasePath:        basePath,
+		RootFilepath:       rootFilepath,
+		Port:               serverConfig.Port,
+		EnableHotReload:    serverConfig.HotReload,
+		Handlers:           handlers,
+		BasePaths:          basePaths,
+		ForwardConsole:     serverConfig.ForwardsConsole(),
+		SymbolicateConsole: serverConfig.SymbolicatesConsole(),
+		Proxies:            serverConfig.Proxy,
+		HTTPS:              serverConfig.HTTPS,
+		HistoryFallback:    serverConfig.HistoryFallback,
 	}
 }
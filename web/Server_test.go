@@ -3,9 +3,12 @@ package web
 import (
 	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
 
+	"github.com/mrcrowl/swarm/bundle"
 	"github.com/mrcrowl/swarm/config"
 	"github.com/mrcrowl/swarm/testutil"
 
@@ -73,6 +76,47 @@ func TestURL(t *testing.T) {
 	assert.Equal(t, "http://localhost:9001/app", actual)
 }
 
+func TestURLWithHTTPS(t *testing.T) {
+	server, _ := createWebServer("")
+	server.https = &config.HTTPSConfig{}
+	assert.Equal(t, "https://localhost:9001/app", server.URL())
+}
+
+func TestURLsOfMultipleBuilds(t *testing.T) {
+	serverConfig := config.NewServerConfig(9001, false, true)
+	server := CreateServer(CreateServerOptions("", serverConfig, nil, "app", "controlpanel", "app"))
+	assert.Equal(t, []string{"http://localhost:9001/app", "http://localhost:9001/controlpanel"}, server.URLs())
+}
+
+func TestBasePathOfPage(t *testing.T) {
+	serverConfig := config.NewServerConfig(9001, false, true)
+	server := CreateServer(CreateServerOptions("", serverConfig, nil, "app", "app/admin", "controlpanel"))
+	cases := map[string]struct {
+		pagePath string
+		expected string
+	}{
+		"base path":        {"/app", "/app"},
+		"index":            {"/app/index.html", "/app"},
+		"nested base path": {"/app/admin/users", "/app/admin"},
+		"other build":      {"/controlpanel/", "/controlpanel"},
+		"similar prefix":   {"/application", ""},
+		"unknown page":     {"/", ""},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, c.expected, server.basePathOfPage(c.pagePath))
+		})
+	}
+}
+
+func TestSocketClientIsOnBasePath(t *testing.T) {
+	client := &SocketClient{basePath: "/app"}
+	assert.True(t, client.isOnBasePath("/app"))
+	assert.True(t, client.isOnBasePath(""))
+	assert.False(t, client.isOnBasePath("/controlpanel"))
+	assert.True(t, (&SocketClient{}).isOnBasePath("/controlpanel"))
+}
+
 func TestPort(t *testing.T) {
 	server, _ := createWebServer("")
 	actual := server.Port()
@@ -85,7 +129,8 @@ func TestMakeServerAddress(t *testing.T) {
 }
 
 func TestSystemJSConfigRewritePaths(t *testing.T) {
-	actual := rewriteSystemJSConfigPaths(systemJSExample)
+	server, _ := createWebServer("")
+	actual := server.rewriteSystemJSConfig("app", systemJSExample)
 	assert.Equal(t, systemJSExpected, actual)
 }
 
@@ -109,6 +154,43 @@ func TestStaticFileServer(t *testing.T) {
 	assert.Equal(t, "text/css; charset=utf-8", writer.ContentType())
 }
 
+type fakeBundleServer map[string]string
+
+func (bundles fakeBundleServer) ServeBundle(w http.ResponseWriter, r *http.Request) bool {
+	contents, found := bundles[r.URL.Path]
+	if found {
+		w.Header().Set("Content-Type", "application/javascript")
+		w.Write([]byte(contents))
+	}
+	return found
+}
+
+func TestStaticFileServerServesBundles(t *testing.T) {
+	tempDir := testutil.CreateTempDir()
+	defer testutil.RemoveTempDir(tempDir)
+	srcDir := testutil.MakeSubdirectoryTree(tempDir, "app/src")
+	testutil.WriteTextFile(srcDir, "Lazy.js", "unbundled")
+	testutil.WriteTextFile(srcDir, "Other.js", "unbundled")
+	server, mux := createWebServer(tempDir)
+	server.bundleServer = fakeBundleServer{"/app/src/Lazy.js": "bundled"}
+	server.attachIndexInjectionListener(mux, server.attachStaticFileServer(mux))
+
+	cases := map[string]struct {
+		url      string
+		expected string
+	}{
+		"bundle":       {"/app/src/Lazy.js", "bundled"},
+		"not a bundle": {"/app/src/Other.js", "unbundled"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			mux.ServeHTTP(recorder, httptest.NewRequest("GET", tc.url, nil))
+			assert.Equal(t, tc.expected, recorder.Body.String())
+		})
+	}
+}
+
 func TestIndexInjectionListener(t *testing.T) {
 	// configure files and server
 	tempDir := testutil.CreateTempDir()
@@ -155,6 +237,49 @@ func TestIndexInjectionListener(t *testing.T) {
 	}
 }
 
+func TestHistoryFallback(t *testing.T) {
+	// configure files and server
+	tempDir := testutil.CreateTempDir()
+	defer testutil.RemoveTempDir(tempDir)
+	appDir := testutil.MakeSubdirectoryTree(tempDir, "app")
+	testutil.WriteTextFile(appDir, "index.html", "<body>HELLO WORLD</body>")
+	testutil.MakeSubdirectoryTree(appDir, "src")
+
+	server, mux := createWebServer(tempDir)
+	server.hub = nil
+	server.historyFallback = &config.HistoryFallbackConfig{Exclude: []string{"/app/api/"}}
+	fileServer := http.FileServer(http.Dir(server.rootFilepath))
+	server.attachIndexInjectionListener(mux, fileServer)
+
+	cases := map[string]struct {
+		method   string
+		url      string
+		accept   string
+		expected bool
+	}{
+		"client-side route":  {"GET", "/app/students/42", "text/html,application/xhtml+xml", true},
+		"no accept header":   {"GET", "/app/students", "", true},
+		"head":               {"HEAD", "/app/students", "", true},
+		"post":               {"POST", "/app/students", "", false},
+		"missing file":       {"GET", "/app/missing.js", "", false},
+		"json request":       {"GET", "/app/students", "application/json", false},
+		"excluded":           {"GET", "/app/api/students", "", false},
+		"existing directory": {"GET", "/app/src/", "", false},
+		"outside base path":  {"GET", "/other/students", "", false},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			request, _ := http.NewRequest(tc.method, tc.url, nil)
+			if tc.accept != "" {
+				request.Header.Set("Accept", tc.accept)
+			}
+			writer := newMockWriter()
+			mux.ServeHTTP(writer, request)
+			assert.Equal(t, tc.expected, writer.sb.String() == "<body>HELLO WORLD</body>")
+		})
+	}
+}
+
 func TestHotReloadScripts(t *testing.T) {
 	server, mux := createWebServer("c:\\")
 	// configure files and server
@@ -175,6 +300,65 @@ func TestHotReloadScripts(t *testing.T) {
 	}
 }
 
+type upperCaseSymbolicator struct{}
+
+func (upperCaseSymbolicator) SymbolicateStackTrace(stackTrace string) string {
+	return strings.ToUpper(stackTrace)
+}
+
+func TestSymbolicationListener(t *testing.T) {
+	server, mux := createWebServer("c:\\")
+	server.symbolicator = upperCaseSymbolicator{}
+	server.attachSymbolicationListener(mux)
+
+	request, _ := http.NewRequest("POST", symbolicateServerPath, strings.NewReader("at app.js:1:2"))
+	writer := newMockWriter()
+	mux.ServeHTTP(writer, request)
+	assert.Equal(t, "AT APP.JS:1:2", writer.sb.String())
+	assert.Equal(t, "text/plain; charset=utf-8", writer.ContentType())
+
+	request, _ = http.NewRequest("GET", symbolicateServerPath, nil)
+	writer = newMockWriter()
+	mux.ServeHTTP(writer, request)
+	assert.Equal(t, []string{"POST"}, writer.headers["Allow"])
+}
+
+type recordingSymbolicator struct {
+	stackTraces []string
+}
+
+func (rs *recordingSymbolicator) SymbolicateStackTrace(stackTrace string) string {
+	rs.stackTraces = append(rs.stackTraces, stackTrace)
+	return stackTrace
+}
+
+func TestReceiveConsoleMessage(t *testing.T) {
+	cases := map[string]struct {
+		console             string
+		payload             *SocketPayload
+		expectedStackTraces []string
+	}{
+		"symbolicated by default": {"", &SocketPayload{"console", `{"level":"error","message":"at app.js:1:2"}`}, []string{"at app.js:1:2"}},
+		"raw":                     {config.ConsoleRaw, &SocketPayload{"console", `{"level":"warn","message":"at app.js:1:2"}`}, nil},
+		"off":                     {config.ConsoleOff, &SocketPayload{"console", `{"level":"error","message":"at app.js:1:2"}`}, nil},
+		"invalid":                 {"", &SocketPayload{"console", `{`}, nil},
+		"other type":              {"", &SocketPayload{"reload", ""}, nil},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			serverConfig := config.NewServerConfig(9001, false, true)
+			serverConfig.Console = tc.console
+			symbolicator := &recordingSymbolicator{}
+			opts := CreateServerOptions("c:\\", serverConfig, nil, "app")
+			opts.Symbolicator = symbolicator
+			server := CreateServer(opts)
+			server.hub.receive([]byte(`{"type":"` + tc.payload.Type + `","data":` + strconv.Quote(tc.payload.Data) + `}`))
+			assert.Equal(t, tc.expectedStackTraces, symbolicator.stackTraces)
+		})
+	}
+}
+
 func TestSwarmify(t *testing.T) {
 	actual := swarmify("bob")
 	expected := fmt.Sprintf("%s/%s", swarmVirtualPath, "bob")
@@ -212,3 +396,76 @@ func (w *MockWriter) Write(bytes []byte) (int, error) {
 
 func (w *MockWriter) Header() http.Header        { return w.headers }
 func (w *MockWriter) WriteHeader(statusCode int) {}
+
+type mapExplainer map[string]string
+
+func (explainer mapExplainer) ExplainImport(file string, moduleName string) (string, bool) {
+	explanation, found := explainer[moduleName+":"+file]
+	if !found {
+		return file + " isn't bundled\n", false
+	}
+	return explanation, true
+}
+
+func TestWhyListener(t *testing.T) {
+	server, mux := createWebServer("c:\\")
+	server.importExplainer = mapExplainer{":app/src/Format.js": "App: app/src/App > app/src/Format\n"}
+	server.attachWhyListener(mux)
+
+	cases := map[string]struct {
+		url              string
+		expectedStatus   int
+		expectedResponse string
+	}{
+		"bundled":     {url: whyServerPath + "?file=app/src/Format.js", expectedStatus: http.StatusOK, expectedResponse: "App: app/src/App > app/src/Format\n"},
+		"not bundled": {url: whyServerPath + "?file=app/src/Other.js", expectedStatus: http.StatusNotFound, expectedResponse: "app/src/Other.js isn't bundled\n"},
+		"module":      {url: whyServerPath + "?file=app/src/Format.js&module=Admin", expectedStatus: http.StatusNotFound, expectedResponse: "app/src/Format.js isn't bundled\n"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			request, _ := http.NewRequest("GET", tc.url, nil)
+			recorder := httptest.NewRecorder()
+			mux.ServeHTTP(recorder, request)
+			assert.Equal(t, tc.expectedStatus, recorder.Code)
+			assert.Equal(t, tc.expectedResponse, recorder.Body.String())
+		})
+	}
+
+	request, _ := http.NewRequest("GET", whyServerPath, nil)
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+type fixedAnalyzer []*bundle.BundleSize
+
+func (analyzer fixedAnalyzer) Sizes() []*bundle.BundleSize {
+	return analyzer
+}
+
+func TestAnalyzeListener(t *testing.T) {
+	server, mux := createWebServer("c:\\")
+	server.bundleAnalyzer = fixedAnalyzer{{
+		Build:    "app",
+		Module:   "App",
+		URL:      "/app/src/App.js",
+		Raw:      300,
+		Gzip:     100,
+		Excludes: []string{"Admin"},
+		Files:    []*bundle.FileSize{{ID: "app/src/App", Size: 250}},
+	}}
+	server.attachAnalyzeListener(mux)
+
+	request, _ := http.NewRequest("GET", analyzeDataPath, nil)
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"live": true, "bundles": [{"build": "app", "module": "App", "url": "/app/src/App.js", "raw": 300,
+		"gzip": 100, "excludes": ["Admin"], "files": [{"id": "app/src/App", "size": 250}]}]}`, recorder.Body.String())
+
+	request, _ = http.NewRequest("GET", analyzeServerPath, nil)
+	recorder = httptest.NewRecorder()
+	mux.ServeHTTP(recorder, request)
+	assert.Equal(t, "text/html; charset=utf-8", recorder.Header().Get("Content-Type"))
+}
@@ -18,8 +18,8 @@ const (
 	// Send pings to peer with this period. Must be less than pongWait.
 	pingPeriod = (pongWait * 9) / 10
 
-	// Maximum message size allowed from peer.
-	maxMessageSize = 512
+	// Maximum message size allowed from peer (large enough for a forwarded stack trace).
+	maxMessageSize = 64 * 1024
 )
 
 var (
@@ -41,6 +41,15 @@ type SocketClient struct {
 
 	// Buffered channel of outbound messages.
 	send chan []byte
+
+	// The base path of the build that the client's page belongs to ("" if unknown).
+	basePath string
+}
+
+// isOnBasePath tests whether a client should receive messages for a build's base path.  Clients
+// on unknown pages receive messages for every build, as do all clients for messages without a base path.
+func (client *SocketClient) isOnBasePath(basePath string) bool {
+	return basePath == "" || client.basePath == "" || client.basePath == basePath
 }
 
 // readPump pumps messages from the websocket connection to the hub.
@@ -57,15 +66,14 @@ func (client *SocketClient) readPump() {
 	client.ws.SetReadDeadline(time.Now().Add(pongWait))
 	client.ws.SetPongHandler(func(string) error { client.ws.SetReadDeadline(time.Now().Add(pongWait)); return nil })
 	for {
-		_, _, err := client.ws.ReadMessage()
+		_, message, err := client.ws.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Printf("error: %v", err)
 			}
 			break
 		}
-		// message = bytes.TrimSpace(bytes.Replace(message, newline, space, -1))
-		// client.hub.broadcast <- message
+		client.hub.receive(message)
 	}
 }
 
@@ -117,13 +125,13 @@ func (client *SocketClient) writePump() {
 }
 
 // serveWs handles websocket requests from the peer.
-func serveWebsocket(hub *SocketHub, w http.ResponseWriter, r *http.Request) {
+func serveWebsocket(hub *SocketHub, basePath string, w http.ResponseWriter, r *http.Request) {
 	socket, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("Failed to upgrade socket: %s\n", err)
 		return
 	}
-	client := &SocketClient{hub: hub, ws: socket, send: make(chan []byte, 256)}
+	client := &SocketClient{hub: hub, ws: socket, send: make(chan []byte, 256), basePath: basePath}
 	client.hub.registerChannel <- client
 
 	// Allow collection of memory referenced by the caller by doing all work in
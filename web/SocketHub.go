@@ -2,6 +2,7 @@ package web
 
 import (
 	"encoding/json"
+	"log"
 	"time"
 )
 
@@ -11,7 +12,7 @@ type SocketHub struct {
 	clients map[*SocketClient]bool
 
 	// used to broadcast to clients.
-	broadcastChannel chan []byte
+	broadcastChannel chan *hubMessage
 
 	// register requests from the clients.
 	registerChannel chan *SocketClient
@@ -21,11 +22,14 @@ type SocketHub struct {
 
 	// stopChannel closes the hub
 	stopChannel chan bool
+
+	// receiver handles messages sent by clients (called from each client's read goroutine)
+	receiver func(payload *SocketPayload)
 }
 
 func newSocketHub() *SocketHub {
 	return &SocketHub{
-		broadcastChannel:  make(chan []byte),
+		broadcastChannel:  make(chan *hubMessage),
 		registerChannel:   make(chan *SocketClient),
 		unregisterChannel: make(chan *SocketClient),
 		stopChannel:       make(chan bool),
@@ -43,14 +47,32 @@ type SocketPayload struct {
 	Data string `json:"data"`
 }
 
-func (hub *SocketHub) broadcast(typ string, data string) {
+// hubMessage is a message for the clients on pages beneath a base path (or all clients, if empty)
+type hubMessage struct {
+	basePath string
+	payload  []byte
+}
+
+func (hub *SocketHub) broadcast(basePath string, typ string, data string) {
 	go func() {
 		message := &SocketPayload{Type: typ, Data: data}
 		jsonBytes, _ := json.Marshal(message)
-		hub.broadcastChannel <- jsonBytes
+		hub.broadcastChannel <- &hubMessage{basePath, jsonBytes}
 	}()
 }
 
+func (hub *SocketHub) receive(message []byte) {
+	if hub.receiver == nil {
+		return
+	}
+	var payload SocketPayload
+	if err := json.Unmarshal(message, &payload); err != nil {
+		log.Printf("Ignoring invalid websocket message: %s", err)
+		return
+	}
+	hub.receiver(&payload)
+}
+
 func (hub *SocketHub) run() {
 	for {
 		select {
@@ -65,8 +87,11 @@ func (hub *SocketHub) run() {
 
 		case message := <-hub.broadcastChannel:
 			for client := range hub.clients {
+				if !client.isOnBasePath(message.basePath) {
+					continue
+				}
 				select {
-				case client.send <- message:
+				case client.send <- message.payload:
 				default:
 					close(client.send)
 					delete(hub.clients, client)
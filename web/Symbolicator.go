@@ -0,0 +1,41 @@
+package web
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+const symbolicateServerPath = swarmVirtualPath + "/symbolicate"
+const maxStackTraceBytes = 1 << 20
+
+// StackTraceSymbolicator maps the positions in a browser stack trace back to their original sources
+type StackTraceSymbolicator interface {
+	SymbolicateStackTrace(stackTrace string) string
+}
+
+// attachSymbolicationListener accepts a POSTed stack trace and responds with its symbolicated equivalent.
+// When the "log" query parameter is present, the symbolicated trace is also printed to the terminal.
+func (server *Server) attachSymbolicationListener(mux *http.ServeMux) {
+	mux.HandleFunc(symbolicateServerPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		bytes, err := ioutil.ReadAll(io.LimitReader(r.Body, maxStackTraceBytes))
+		if err != nil {
+			http.Error(w, "Failed to read stack trace", http.StatusBadRequest)
+			return
+		}
+
+		stackTrace := server.symbolicator.SymbolicateStackTrace(string(bytes))
+		if _, log := r.URL.Query()["log"]; log {
+			printBrowserMessage("Browser error", r.Referer(), stackTrace)
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		io.WriteString(w, stackTrace)
+	})
+}
@@ -0,0 +1,33 @@
+package web
+
+import (
+	"io"
+	"net/http"
+)
+
+const whyServerPath = swarmVirtualPath + "/why"
+
+// ImportExplainer describes why a file is bundled, i.e. the chains of imports that pull it into each bundle
+type ImportExplainer interface {
+	ExplainImport(file string, moduleName string) (string, bool)
+}
+
+// attachWhyListener responds to /__swarm__/why?file=<file>[&module=<module>] with the chains of imports that pull
+// the file into the bundles, or 404 if it isn't bundled
+func (server *Server) attachWhyListener(mux *http.ServeMux) {
+	mux.HandleFunc(whyServerPath, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		file := query.Get("file")
+		if file == "" {
+			http.Error(w, "Missing file, e.g. "+whyServerPath+"?file=app/src/util/Format.js", http.StatusBadRequest)
+			return
+		}
+
+		explanation, found := server.importExplainer.ExplainImport(file, query.Get("module"))
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		io.WriteString(w, explanation)
+	})
+}
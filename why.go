@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"github.com/mrcrowl/swarm/bundle"
+)
+
+// runWhy explains why a file is bundled, by listing the shortest chains of imports from the entry points to it
+func runWhy(cmd *command, args []string) int {
+	if len(args) != 1 {
+		cmd.printUsage()
+		return 2
+	}
+
+	_, _, _, moduleSet := loadBuild(buildArgs())
+	if *moduleFlag != "" && findModule(moduleSet, *moduleFlag) == nil {
+		return 2
+	}
+
+	explanation, found := bundle.ModuleSets{moduleSet}.ExplainImport(args[0], *moduleFlag)
+	fmt.Print(explanation)
+	if !found {
+		return 1
+	}
+	return 0
+}